@@ -0,0 +1,45 @@
+package database
+
+import "github.com/brunotm/norm/statement"
+
+// Enqueue queues a statement that doesn't return rows to be executed with the next Flush, which is
+// called automatically once Config.BatchSize statements are queued, returning its error if any.
+// Queued statements are not seen by queries until flushed, and are discarded on Rollback.
+func (t *Tx) Enqueue(stmt statement.Statement) (err error) {
+	t.mu.Lock()
+	t.queue = append(t.queue, stmt)
+	full := t.batch > 0 && len(t.queue) >= t.batch
+	t.mu.Unlock()
+
+	if full {
+		_, err = t.Flush()
+	}
+
+	return err
+}
+
+// Flush executes the statements queued with Enqueue in order, returning the total number of rows affected.
+// As database/sql has no portable support for batching statements with arguments, they are executed
+// one after the other as with ExecContext. If a statement fails the remaining statements are discarded
+// and the error is returned, after which the transaction should be rolled back.
+func (t *Tx) Flush() (affected int64, err error) {
+	t.mu.Lock()
+	queue := t.queue
+	t.queue = nil
+	t.mu.Unlock()
+
+	for x := 0; x < len(queue); x++ {
+		r, err := t.ExecContext(t.ctx, queue[x])
+		if err != nil {
+			return affected, err
+		}
+
+		n, err := r.RowsAffected()
+		if err != nil {
+			return affected, err
+		}
+		affected += n
+	}
+
+	return affected, nil
+}
@@ -0,0 +1,161 @@
+package database
+
+import (
+	"container/list"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// cache is a query results cache, optionally bounded by the number of entries
+// with a least recently used eviction policy. Values must be copied with copyValue
+// when added and retrieved.
+type cache struct {
+	max   int
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type cacheEntry struct {
+	key   string
+	value reflect.Value
+}
+
+// cacheKey returns the cache key for the given query, arguments, query mode and destination type.
+// Results are keyed by the whole query and arguments instead of a hash of them,
+// so that different queries can never collide and share results. Arguments are keyed by the
+// values bound by the driver, so that pointers and driver.Valuer arguments are keyed by their
+// current values and not their addresses, along with their types. Queries with arguments that can't be converted to
+// driver values are not cached. Results scanned into different destination types for the same query,
+// like []User and []UserSummary, are cached separately.
+func cacheKey(query string, args []interface{}, mode queryMode, dst reflect.Type) (key string, ok bool) {
+	var b strings.Builder
+	_, _ = b.WriteString(query)
+	_ = b.WriteByte(0)
+
+	for _, arg := range args {
+		v, err := driver.DefaultParameterConverter.ConvertValue(arg)
+		if err != nil {
+			return "", false
+		}
+		// the type distinguishes values printed alike, as int64(1) and float64(1)
+		_, _ = fmt.Fprintf(&b, "%T:%#v", v, v)
+		_ = b.WriteByte(0)
+	}
+
+	// single row and multiple row results for the same query are cached separately
+	_ = b.WriteByte(byte(mode))
+	_, _ = b.WriteString(dst.String())
+	return b.String(), true
+}
+
+// newCache creates a new cache bounded to max entries, or unbounded if max is 0.
+func newCache(max int) (c *cache) {
+	return &cache{
+		max:   max,
+		ll:    list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+// get returns the cached value for the given key.
+func (c *cache) get(key string) (value reflect.Value, ok bool) {
+	e, ok := c.items[key]
+	if !ok {
+		return value, false
+	}
+
+	c.ll.MoveToFront(e)
+	return e.Value.(*cacheEntry).value, true
+}
+
+// add adds the value to the cache, evicting the least recently used entry if the cache is full.
+func (c *cache) add(key string, value reflect.Value) {
+	if e, ok := c.items[key]; ok {
+		c.ll.MoveToFront(e)
+		e.Value.(*cacheEntry).value = value
+		return
+	}
+
+	c.items[key] = c.ll.PushFront(&cacheEntry{key: key, value: value})
+
+	if c.max > 0 && c.ll.Len() > c.max {
+		e := c.ll.Back()
+		c.ll.Remove(e)
+		delete(c.items, e.Value.(*cacheEntry).key)
+	}
+}
+
+// reset removes all entries from the cache.
+func (c *cache) reset() {
+	c.ll.Init()
+	c.items = map[string]*list.Element{}
+}
+
+// copyValue returns a deep copy of the given value, so that cached results
+// never share memory with the values returned to callers.
+func copyValue(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for x := 0; x < v.Len(); x++ {
+			c.Index(x).Set(copyValue(v.Index(x)))
+		}
+		return c
+
+	case reflect.Array:
+		c := reflect.New(v.Type()).Elem()
+		for x := 0; x < v.Len(); x++ {
+			c.Index(x).Set(copyValue(v.Index(x)))
+		}
+		return c
+
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+		}
+		return c
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.New(v.Type().Elem())
+		c.Elem().Set(copyValue(v.Elem()))
+		return c
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+
+		c := reflect.New(v.Type()).Elem()
+		c.Set(copyValue(v.Elem()))
+		return c
+
+	case reflect.Struct:
+		// copy the whole struct first, as unexported fields can only be copied by value
+		c := reflect.New(v.Type()).Elem()
+		c.Set(v)
+		for x := 0; x < v.NumField(); x++ {
+			if c.Field(x).CanSet() {
+				c.Field(x).Set(copyValue(v.Field(x)))
+			}
+		}
+		return c
+	}
+
+	return v
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// pkgPrefix is the prefix of the function names of this package, whose frames are skipped when capturing callers.
+var pkgPrefix = reflect.TypeOf(DB{}).PkgPath() + "."
+
+// withCaller returns an EventLogger that sets the caller of each event, before logging it with log.
+func withCaller(log EventLogger) (e EventLogger) {
+	return func(e LogEvent) {
+		if e.Caller == "" {
+			e.Caller = caller()
+		}
+		log(e)
+	}
+}
+
+// caller returns the `file:line` of the first frame outside of this package and the runtime,
+// as the code that called the transaction method being logged.
+func caller() (c string) {
+	var pcs [32]uintptr
+	n := runtime.Callers(3, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	for {
+		f, more := frames.Next()
+		internal := strings.HasPrefix(f.Function, "runtime.") ||
+			(strings.HasPrefix(f.Function, pkgPrefix) && !strings.HasSuffix(f.File, "_test.go"))
+
+		if !internal {
+			return f.File + ":" + strconv.Itoa(f.Line)
+		}
+
+		if !more {
+			return ""
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/brunotm/norm/internal/buffer"
+	"github.com/brunotm/norm/statement"
+)
+
+// copyDrivers are the package paths of the drivers implementing COPY FROM STDIN through prepared statements.
+var copyDrivers = map[string]bool{
+	"github.com/lib/pq": true,
+}
+
+// driverPkg returns the package path of the given driver.
+func driverPkg(d driver.Driver) (pkg string) {
+	t := reflect.TypeOf(d)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == nil {
+		return ""
+	}
+	return t.PkgPath()
+}
+
+// CopyFrom bulk loads the given rows into the table columns with the Postgres `COPY FROM STDIN`
+// protocol within the transaction, returning the number of rows copied. See CopyFromFunc.
+func (t *Tx) CopyFrom(table string, columns []string, rows [][]interface{}) (n int64, err error) {
+	x := 0
+	return t.CopyFromFunc(table, columns, func() (row []interface{}, err error) {
+		if x == len(rows) {
+			return nil, nil
+		}
+		x++
+		return rows[x-1], nil
+	})
+}
+
+// CopyFromFunc bulk loads rows into the table columns with the Postgres `COPY FROM STDIN` protocol
+// within the transaction, returning the number of rows copied. Rows are streamed to the database
+// as they are returned by next until it returns a nil row or an error, so they don't need to be
+// held in memory. The table and columns are quoted as is with statement.Quote, and the table can be
+// qualified as `schema.table`. A copy without table or columns returns ErrIncomplete.
+//
+// It is only supported on the Postgres dialect with the github.com/lib/pq driver, which implements COPY
+// through prepared statements, where each row is sent by executing the prepared `COPY` statement
+// with the row values and the copy is completed by executing it without values. Other drivers, like
+// github.com/jackc/pgx through database/sql, return ErrUnsupported, as their copy protocol is only
+// available on their native connections, which can't be reached from a database/sql transaction.
+// Copying stops when the transaction context is done, and is bounded by the query timeout if any.
+func (t *Tx) CopyFromFunc(table string, columns []string, next func() (row []interface{}, err error)) (n int64, err error) {
+	start := time.Now()
+
+	if t.dialect != statement.Postgres {
+		return 0, fmt.Errorf("%w: COPY, dialect: %s", statement.ErrUnsupported, t.dialect)
+	}
+
+	if !copyDrivers[t.driver] {
+		return 0, fmt.Errorf("%w: COPY, driver: %s", statement.ErrUnsupported, t.driver)
+	}
+
+	switch {
+	case table == "":
+		return 0, fmt.Errorf("%w: COPY without table", statement.ErrIncomplete)
+	case len(columns) == 0:
+		return 0, fmt.Errorf("%w: COPY without columns", statement.ErrIncomplete)
+	}
+
+	query := copyQuery(table, columns)
+
+	ctx, span := t.trace(t.ctx, "db.tx.copy", "COPY", query)
+	defer func() { span.End(err) }()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+
+	defer func() {
+		t.log(LogEvent{Op: "db.tx.copy", TxID: t.tid, Err: err, Duration: time.Since(start), Query: query, RowsAffected: n})
+	}()
+
+	stmt, err := t.tx.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	var count int64
+	var row []interface{}
+	for {
+		if err = ctx.Err(); err != nil {
+			return 0, err
+		}
+
+		if row, err = next(); err != nil {
+			return 0, err
+		}
+
+		if row == nil {
+			break
+		}
+
+		if len(row) != len(columns) {
+			return 0, fmt.Errorf("%w: copy row %d has %d values, expected %d",
+				statement.ErrInvalidArgNumber, count+1, len(row), len(columns))
+		}
+
+		if _, err = stmt.ExecContext(ctx, row...); err != nil {
+			return 0, t.classify.wrap(err)
+		}
+		count++
+	}
+
+	r, err := stmt.ExecContext(ctx)
+	if err != nil {
+		return 0, t.classify.wrap(err)
+	}
+
+	if n, err = r.RowsAffected(); err != nil || n == 0 {
+		n = count
+	}
+
+	t.stats.Execs++
+	t.stats.RowsAffected += n
+
+	return n, nil
+}
+
+// copyQuery returns the `COPY table (columns) FROM STDIN` query for the given table and columns.
+func copyQuery(table string, columns []string) (query string) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	_, _ = buf.WriteString("COPY ")
+	for x, part := range strings.Split(table, ".") {
+		if x > 0 {
+			_, _ = buf.WriteString(".")
+		}
+		_, _ = buf.WriteString(statement.Quote(part))
+	}
+
+	_, _ = buf.WriteString(" (")
+	for x := 0; x < len(columns); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		_, _ = buf.WriteString(statement.Quote(columns[x]))
+	}
+	_, _ = buf.WriteString(") FROM STDIN")
+
+	return buf.String()
+}
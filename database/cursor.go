@@ -1,9 +1,11 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"reflect"
+	"time"
 
 	"github.com/brunotm/norm/internal/scan"
 	"github.com/brunotm/norm/statement"
@@ -14,11 +16,16 @@ type Cursor struct {
 	rows      *sql.Rows
 	vType     reflect.Type
 	columns   []string
+	scanner   *scan.Scanner
 	extractor scan.PointersExtractor
+	cancel    context.CancelFunc
 }
 
 // Scan copies the current row columns into the struct fields or map values pointed at by dst.
 // If the type of dst changes during calls to scan it will return a error.
+// The columns are validated against dst on the first call as with Tx.Query, returning
+// scan.ErrColumnMismatch for multiple columns into a scalar or, on strict or positional scanning,
+// columns not matching the struct fields.
 func (c *Cursor) Scan(dst interface{}) (err error) {
 	v := reflect.ValueOf(dst)
 	if v.Kind() != reflect.Ptr || v.IsNil() {
@@ -29,11 +36,16 @@ func (c *Cursor) Scan(dst interface{}) (err error) {
 		c.vType = v.Type()
 
 		// cursor scan requires individual pointer to structs
-		if v.Kind() == reflect.Slice && c.vType.Elem().Kind() != reflect.Uint8 {
+		if e := c.vType.Elem(); e.Kind() == reflect.Slice && e.Elem().Kind() != reflect.Uint8 {
 			return scan.ErrInvalidType
 		}
 
-		if c.extractor, err = scan.FindExtractor(c.vType); err != nil {
+		// validate the columns against dst as Tx.Query does
+		if err = c.scanner.Check(c.columns, c.vType.Elem()); err != nil {
+			return err
+		}
+
+		if c.extractor, err = c.scanner.FindExtractor(c.vType); err != nil {
 			return err
 		}
 	}
@@ -44,7 +56,7 @@ func (c *Cursor) Scan(dst interface{}) (err error) {
 	}
 
 	ptr := c.extractor(c.columns, v)
-	err = c.rows.Scan(ptr...)
+	err = c.scanner.Scan(c.rows, ptr...)
 	if err != nil {
 		return err
 	}
@@ -72,34 +84,88 @@ func (c *Cursor) Err() (err error) {
 // the Cursor is closed automatically and it will suffice to check the result of Err.
 // Close is idempotent and does not affect the result of Err.
 func (c *Cursor) Close() (err error) {
-	return c.rows.Close()
+	err = c.rows.Close()
+	c.cancel()
+	return err
 }
 
 // Cursor executes a query that returns a database cursor like sql.Rows.
 // It its useful for working with large result sets or/and when memory utilization
-// is a concern.
+// is a concern, as rows are scanned one at a time with Next and Scan instead of being loaded at once.
+// Cursor results are never cached.
 //
 // The caller must call Cursor.Close() on the returned cursor in order to release
-// the sql.Rows resources.
+// the sql.Rows resources. The cursor holds the transaction connection while open,
+// and most drivers do not allow other statements in the transaction until it is closed.
+// The configured query timeout applies to the whole cursor lifetime.
 func (t *Tx) Cursor(stmt statement.Statement) (i *Cursor, err error) {
+	i, _, _, err = t.cursor(stmt)
+	return i, err
+}
+
+// RowScanner scans the current row of a result set, as the Cursor.Scan method.
+type RowScanner interface {
+	Scan(dst interface{}) error
+}
+
+// QueryFunc executes a query calling fn for each row of the result set, which can be scanned with
+// row.Scan into a struct, map or scalars as with Cursor, without loading the whole result set at once.
+// It stops at the first error returned by fn, which is returned, and closes the rows when done.
+// As with Cursor, the transaction connection is held while iterating, so fn must not execute other
+// statements in the transaction on most drivers, and QueryFunc results are never cached.
+func (t *Tx) QueryFunc(stmt statement.Statement, fn func(row RowScanner) error) (err error) {
+	start := time.Now()
+
+	c, query, args, err := t.cursor(stmt)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	defer func() {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+
+		t.log(LogEvent{Op: "db.tx.query.func", TxID: t.tid, Err: err, Duration: time.Since(start),
+			Query: t.logged(stmt, query), Args: args, RowsAffected: count})
+	}()
+
+	for c.Next() {
+		count++
+		if err = fn(c); err != nil {
+			return err
+		}
+	}
+
+	return t.classify.wrap(c.Err())
+}
+
+// cursor executes the query returning a Cursor and the built query and arguments.
+func (t *Tx) cursor(stmt statement.Statement) (i *Cursor, query string, args []interface{}, err error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	query, err := stmt.String()
-	if err != nil {
-		return nil, err
+	if query, args, err = t.build(stmt); err != nil {
+		return nil, "", nil, err
 	}
 
-	r, err := t.tx.QueryContext(t.ctx, query)
+	ctx, cancel := t.context(t.ctx)
+
+	r, err := t.rows(ctx, query, args)
 	if err != nil {
-		return nil, err
+		cancel()
+		return nil, "", nil, err
 	}
 
 	cursor := &Cursor{}
 	cursor.rows = r
+	cursor.cancel = cancel
+	cursor.scanner = t.scanner
 	if cursor.columns, err = r.Columns(); err != nil {
-		return nil, fmt.Errorf("statement: %w", err)
+		_ = cursor.Close()
+		return nil, "", nil, fmt.Errorf("statement: %w", err)
 	}
 
-	return cursor, nil
+	return cursor, query, args, nil
 }
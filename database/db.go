@@ -3,12 +3,19 @@ package database
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
-	"reflect"
 	"strconv"
+	"sync"
 	"time"
+
+	"github.com/brunotm/norm/internal/scan"
+	"github.com/brunotm/norm/statement"
 )
 
+// ErrClosed is returned when starting transactions or pinging a DB after it is closed.
+var ErrClosed = fmt.Errorf("database: closed")
+
 // Logger type for database operations
 type Logger func(message, tid string, err error, d time.Duration, query string)
 
@@ -18,30 +25,369 @@ func DefaultLogger(message, tid string, err error, d time.Duration, query string
 		message, tid, err, d.Milliseconds(), query)
 }
 
-func nopLogger(message, id string, err error, d time.Duration, query string) {}
+// LogEvent is a structured database operation log event.
+type LogEvent struct {
+	// Op is the operation, like db.tx.exec or db.tx.query.
+	Op string
+	// TxID is the transaction identifier.
+	TxID string
+	// Err is the operation error, if any.
+	Err error
+	// Duration is the operation duration.
+	Duration time.Duration
+	// Query is the executed query, if any.
+	Query string
+	// Args are the query bound arguments, if any.
+	Args []interface{}
+	// RowsAffected is the number of rows affected by an exec or scanned by a query.
+	RowsAffected int64
+	// Caller is the `file:line` of the code that called the operation, if Config.CaptureCaller is set.
+	Caller string
+}
+
+// EventLogger type for structured logging of database operations
+type EventLogger func(e LogEvent)
+
+// EventLogger adapts the Logger into an EventLogger.
+func (l Logger) EventLogger() (e EventLogger) {
+	return func(e LogEvent) {
+		l(e.Op, e.TxID, e.Err, e.Duration, e.Query)
+	}
+}
+
+func nopLogger(e LogEvent) {}
+
+// Config is the database configuration.
+type Config struct {
+	// Logger for database operations, if nil operations are not logged.
+	Logger Logger
+
+	// EventLogger for structured logging of database operations.
+	// If set it takes precedence over Logger.
+	EventLogger EventLogger
+
+	// SlowThreshold if greater than 0 is the duration above which exec and query operations are
+	// logged as slow, with the operation suffixed by .slow, like db.tx.query.slow.
+	SlowThreshold time.Duration
+
+	// SlowLogger receives the slow operation events. If nil they are logged with the EventLogger
+	// or Logger in addition to the regular events, so setting only the SlowLogger keeps the
+	// regular logging quiet while surfacing slow queries.
+	SlowLogger EventLogger
+
+	// CaptureCaller records the `file:line` of the code calling the database and transaction methods
+	// as the LogEvent.Caller of their events, skipping the frames of this package.
+	// It is opt-in as capturing the caller stack has a cost on each logged operation.
+	CaptureCaller bool
+
+	// ReadOpt are the options for transactions created with DB.Read.
+	// If nil, a read-only transaction with the driver default isolation level is used.
+	ReadOpt *sql.TxOptions
+
+	// WriteOpt are the options for transactions created with DB.Update.
+	// If nil, a read-write transaction with the driver default isolation level is used.
+	WriteOpt *sql.TxOptions
+
+	// Dialect is the statement.Dialect used to build parameterized statements.
+	Dialect statement.Dialect
+
+	// Quoting quotes identifiers that are reserved words or not lowercase when building statements,
+	// according to the Dialect. See statement.WithQuoting.
+	Quoting bool
+
+	// RequireWhere makes executing update and delete statements without a `WHERE` clause
+	// return statement.ErrMissingWhere. See statement.WithRequireWhere.
+	RequireWhere bool
+
+	// DedupArgs binds repeated argument values of a statement to a single placeholder on dialects
+	// with numbered placeholders. See statement.WithDedupArgs.
+	DedupArgs bool
+
+	// Location if not nil normalizes bound time.Time arguments to the location, after the ArgEncoders,
+	// and is the location of scanned times, in which times scanned in UTC, as returned by most drivers for
+	// timestamps without a time zone, are interpreted as wall clock times. It gives consistent round trips
+	// of times across drivers that store them with and without time zones.
+	Location *time.Location
+
+	// ArgEncoders transform each bound argument of parameterized statements in order,
+	// like converting values to the representation expected by the driver. See statement.WithArgEncoder.
+	ArgEncoders []statement.ArgEncoder
+
+	// LogInterpolated logs the queries built from statements with their arguments interpolated
+	// with statement.Interpolate, instead of the parameterized query. Interpolated queries are only
+	// logged and never executed. Argument values, which may be sensitive, are then part of the log message.
+	// It has no effect if ArgRedaction is set.
+	LogInterpolated bool
+
+	// ArgRedaction defines how the bound arguments are logged, allowing arguments holding sensitive data
+	// to be omitted from or redacted in the logs of all operations, including slow operations.
+	// Values of statements that do not implement statement.Parameterized are interpolated in the query
+	// and can't be redacted.
+	ArgRedaction ArgRedaction
+
+	// QueryCache is the transaction query cache policy.
+	QueryCache CachePolicy
+
+	// SharedCache is the policy of the query cache shared across read-only transactions, which
+	// caches the results of the QueryCache* methods beyond the transaction for the policy TTL.
+	// It is disabled by default.
+	SharedCache SharedCachePolicy
+
+	// RollbackOnCancel rolls back transactions with Tx.Rollback as soon as their context is done before
+	// they are committed or rolled back. While database/sql already rolls back the underlying transaction,
+	// this also releases the transaction prepared statements and logs the rollback as db.tx.rollback.cancel.
+	RollbackOnCancel bool
+
+	// QueryTimeout if greater than 0 is the maximum duration for each statement executed
+	// within a transaction.
+	QueryTimeout time.Duration
+
+	// BatchSize if greater than 0 is the number of statements queued with Tx.Enqueue
+	// after which they are automatically flushed. If 0 they are only flushed with Tx.Flush and Commit.
+	BatchSize int
+
+	// MaxArgs if greater than 0 is the maximum number of arguments of each statement executed with
+	// Tx.ExecBatch and Tx.ExecBatchReturning. If 0 it defaults to the maximum supported by the Dialect.
+	MaxArgs int
+
+	// MaxRows if greater than 0 is the maximum number of rows loaded by the transaction queries into slices,
+	// which return ErrMaxRows instead of loading larger result sets into memory. It can be overridden
+	// for the queries executed with a context created with WithMaxRows, or for all queries, including prepared
+	// statement queries, of transactions created with it. Cursors and QueryFunc are not limited.
+	MaxRows int
+
+	// Scan configures how query results are scanned into destinations.
+	Scan ScanConfig
+
+	// PrepareCache prepares each distinct query once per transaction, reusing the prepared
+	// statement for subsequent executions of the same query with different arguments.
+	PrepareCache bool
+
+	// StmtCache if greater than 0 is the maximum number of statements prepared on the database and shared
+	// across transactions, which use them for the queries and execs with the same query instead of preparing
+	// them once per transaction as with PrepareCache. The least recently used statements are closed when
+	// evicted, once no longer in use, and the remaining statements are closed by DB.Close.
+	// Statements are prepared on the database when not cached, which requires a connection other than
+	// the one of the transaction, so the pool must not be limited to a single connection.
+	StmtCache int
+
+	// Tracer if not nil creates spans for the transaction queries, execs, commits and rollbacks.
+	Tracer Tracer
+
+	// Metrics if not nil observes the database operations and transaction query cache lookups.
+	Metrics Metrics
+
+	// Retry is the retry policy for transactions run with DB.WithUpdateRetry.
+	Retry RetryPolicy
+
+	// ErrorClassifiers classify the driver errors returned by the transaction queries, execs and commits,
+	// which are wrapped into an *Error by the first classifier that recognizes them.
+	// If nil PostgresClassifier and MySQLClassifier are used.
+	ErrorClassifiers []ErrorClassifier
+}
+
+// ScanConfig defines how query results are scanned into destinations.
+type ScanConfig struct {
+	// BytesAsString stores []byte column values as string when scanning into
+	// map[string]interface{} or []map[string]interface{} destinations.
+	BytesAsString bool
+
+	// NullAsZero sets destinations that can't hold NULL values, like string or int64 struct fields,
+	// to their zero value when scanning NULL columns. If false scanning NULL into them returns an error.
+	NullAsZero bool
+
+	// Strict validates that the query columns match the fields of struct destinations before scanning,
+	// returning an error listing the columns without fields and the fields without columns otherwise.
+	Strict bool
+
+	// Positional maps the query columns to the fields of struct destinations by position in declaration order,
+	// regardless of their names, returning an error if the number of columns and fields don't match.
+	Positional bool
+
+	// NameMapper maps struct field names to column names for fields without a `db:"column"` tag,
+	// explicit tags always take precedence. If nil, field names are converted from CamelCase to snake_case.
+	NameMapper func(field string) string
+}
+
+// CachePolicy defines the behavior of the transaction query cache.
+type CachePolicy struct {
+	// Disabled disables the transaction query cache, making the QueryCache* methods behave like their
+	// non cached counterparts.
+	Disabled bool
+
+	// MaxEntries is the maximum number of cached results per transaction.
+	// When the limit is reached the least recently used results are evicted. If 0 the cache is unbounded.
+	MaxEntries int
+}
 
 // DB is safe sql.DB wrapper which enforces transactional access to the database,
 // transaction query caching and operation logging and plays nicely with `noorm/statement`.
 type DB struct {
 	db       *sql.DB
-	log      Logger
+	closeMu  sync.RWMutex
+	closed   bool
+	log      EventLogger
 	readOpt  *sql.TxOptions
 	writeOpt *sql.TxOptions
+	dialect  statement.Dialect
+	quote    bool
+	where    bool
+	dedup    bool
+	encoders []statement.ArgEncoder
+	inline   bool
+	cache    CachePolicy
+	shared   *sharedCache
+	timeout  time.Duration
+	batch    int
+	maxArgs  int
+	scanner  *scan.Scanner
+	prepare  bool
+	pstmts   *stmtCache
+	cancel   bool
+	tracer   Tracer
+	metrics  Metrics
+	retry    RetryPolicy
+	classify errorClassifiers
+	driver   string
 }
 
 // New creates a new database from an existing *sql.DB
 // with the given sql.IsolationLevel and logger.
 func New(db *sql.DB, level sql.IsolationLevel, logger Logger) (d *DB, err error) {
+	return NewWithConfig(db, Config{
+		Logger:   logger,
+		ReadOpt:  &sql.TxOptions{Isolation: level, ReadOnly: true},
+		WriteOpt: &sql.TxOptions{Isolation: level, ReadOnly: false},
+	})
+}
+
+// NewWithConfig creates a new database from an existing *sql.DB with the given configuration.
+func NewWithConfig(db *sql.DB, config Config) (d *DB, err error) {
+	if config.QueryTimeout < 0 {
+		return nil, fmt.Errorf("database: invalid query timeout: %s", config.QueryTimeout)
+	}
+
+	if config.BatchSize < 0 {
+		return nil, fmt.Errorf("database: invalid batch size: %d", config.BatchSize)
+	}
+
+	if config.ArgRedaction < RedactNone || config.ArgRedaction > RedactType {
+		return nil, fmt.Errorf("database: invalid argument redaction: %d", config.ArgRedaction)
+	}
+
+	if config.SlowThreshold < 0 {
+		return nil, fmt.Errorf("database: invalid slow threshold: %s", config.SlowThreshold)
+	}
+
+	if config.Retry.Backoff < 0 || config.Retry.MaxBackoff < 0 {
+		return nil, fmt.Errorf("database: invalid retry backoff: %s, max: %s", config.Retry.Backoff, config.Retry.MaxBackoff)
+	}
+
+	if config.MaxRows < 0 {
+		return nil, fmt.Errorf("database: invalid max rows: %d", config.MaxRows)
+	}
+
+	if config.MaxArgs < 0 {
+		return nil, fmt.Errorf("database: invalid max args: %d", config.MaxArgs)
+	}
+
+	if config.StmtCache < 0 {
+		return nil, fmt.Errorf("database: invalid statement cache size: %d", config.StmtCache)
+	}
+
+	if config.QueryCache.MaxEntries < 0 {
+		return nil, fmt.Errorf("database: invalid query cache max entries: %d", config.QueryCache.MaxEntries)
+	}
+
 	d = &DB{}
 	d.db = db
 	d.log = nopLogger
+	d.dialect = config.Dialect
+	d.driver = driverPkg(db.Driver())
+	d.quote = config.Quoting
+	d.where = config.RequireWhere
+	d.dedup = config.DedupArgs
+	d.encoders = config.ArgEncoders
+	if config.Location != nil {
+		d.encoders = append(config.ArgEncoders[:len(config.ArgEncoders):len(config.ArgEncoders)], statement.TimeIn(config.Location))
+	}
+	d.inline = config.LogInterpolated && config.ArgRedaction == RedactNone
+	d.cache = config.QueryCache
+	d.shared = newSharedCache(config.SharedCache)
+	d.timeout = config.QueryTimeout
+	d.batch = config.BatchSize
+	d.maxArgs = config.MaxArgs
+	d.prepare = config.PrepareCache
+	d.cancel = config.RollbackOnCancel
+	d.tracer = config.Tracer
+	d.metrics = nopMetrics{}
+	d.retry = config.Retry
+	d.classify = config.ErrorClassifiers
+	d.scanner = &scan.Scanner{
+		BytesAsString: config.Scan.BytesAsString,
+		NullAsZero:    config.Scan.NullAsZero,
+		Strict:        config.Scan.Strict,
+		Positional:    config.Scan.Positional,
+		Mapper:        config.Scan.NameMapper,
+		Location:      config.Location,
+		MaxRows:       config.MaxRows,
+	}
+
+	switch {
+	case config.EventLogger != nil:
+		d.log = config.EventLogger
+	case config.Logger != nil:
+		d.log = config.Logger.EventLogger()
+	}
+
+	// arguments are redacted before reaching any logger
+	d.log = redact(d.log, config.ArgRedaction)
+
+	if config.SlowThreshold > 0 {
+		slowLog := d.log
+		if config.SlowLogger != nil {
+			slowLog = redact(config.SlowLogger, config.ArgRedaction)
+		}
+		d.log = slow(d.log, slowLog, config.SlowThreshold)
+	}
+
+	if config.Metrics != nil {
+		d.metrics = config.Metrics
+		d.log = observe(d.log, d.metrics)
+	}
+
+	// the caller is captured before reaching the slow logger and metrics
+	if config.CaptureCaller {
+		d.log = withCaller(d.log)
+	}
+
+	d.pstmts = newStmtCache(db, d.log, config.StmtCache)
+
+	if d.classify == nil {
+		d.classify = errorClassifiers{PostgresClassifier, MySQLClassifier}
+	}
+
+	if d.retry.Retryable == nil {
+		d.retry.Retryable = IsRetryable
+	}
+
+	if d.retry.Backoff == 0 {
+		d.retry.Backoff = defaultRetryBackoff
+	}
 
-	if logger != nil {
-		d.log = logger
+	if d.retry.MaxBackoff == 0 {
+		d.retry.MaxBackoff = defaultRetryMaxBackoff
 	}
 
-	d.readOpt = &sql.TxOptions{Isolation: level, ReadOnly: true}
-	d.writeOpt = &sql.TxOptions{Isolation: level, ReadOnly: false}
+	d.readOpt = config.ReadOpt
+	if d.readOpt == nil {
+		d.readOpt = &sql.TxOptions{ReadOnly: true}
+	}
+
+	d.writeOpt = config.WriteOpt
+	if d.writeOpt == nil {
+		d.writeOpt = &sql.TxOptions{ReadOnly: false}
+	}
 
 	return d, nil
 }
@@ -54,21 +400,60 @@ func (d *DB) Tx(ctx context.Context, tid string, opts *sql.TxOptions) (tx *Tx, e
 		tid = strconv.FormatInt(time.Now().UnixNano(), 32)
 	}
 
+	if d.isClosed() {
+		return nil, ErrClosed
+	}
+
 	start := time.Now()
 	t, err := d.db.BeginTx(ctx, opts)
-	d.log("db.begin", tid, err, time.Since(start), "")
+	d.log(LogEvent{Op: "db.begin", TxID: tid, Err: err, Duration: time.Since(start)})
 
 	if err != nil {
 		return nil, err
 	}
 
-	return &Tx{
-		tid:   tid,
-		log:   d.log,
-		tx:    t,
-		ctx:   ctx,
-		cache: map[uint64]reflect.Value{},
-	}, nil
+	tx = &Tx{
+		tid:      tid,
+		log:      d.log,
+		tx:       t,
+		ctx:      ctx,
+		dialect:  d.dialect,
+		quote:    d.quote,
+		where:    d.where,
+		dedup:    d.dedup,
+		encoders: d.encoders,
+		inline:   d.inline,
+		timeout:  d.timeout,
+		batch:    d.batch,
+		maxArgs:  d.maxArgs,
+		scanner:  d.scanner,
+		tracer:   d.tracer,
+		metrics:  d.metrics,
+		classify: d.classify,
+		driver:   d.driver,
+	}
+
+	if !d.cache.Disabled {
+		tx.cache = newCache(d.cache.MaxEntries)
+	}
+
+	// results are only shared by read-only transactions, as write transactions
+	// must see their own changes and not stale results
+	if opts != nil && opts.ReadOnly {
+		tx.shared = d.shared
+	}
+
+	if d.prepare || d.pstmts != nil {
+		tx.stmts = map[string]*sql.Stmt{}
+		tx.pstmts = d.pstmts
+	}
+
+	if d.cancel && ctx.Done() != nil {
+		tx.finished = make(chan struct{})
+		go tx.watch(tx.finished)
+	}
+
+	return tx, nil
 
 }
 
@@ -86,14 +471,116 @@ func (d *DB) Update(ctx context.Context, tid string) (tx *Tx, err error) {
 	return d.Tx(ctx, tid, d.writeOpt)
 }
 
-// PingContext verifies a connection to the database is still alive,
+// WithTx creates a database transaction with the provided options and calls fn with it.
+// The transaction is committed if fn returns a nil error, or rolled back if fn returns an error or panics,
+// in which case the panic is propagated after the rollback. The fn must not commit the transaction,
+// though rolling it back is safe.
+func (d *DB) WithTx(ctx context.Context, tid string, opts *sql.TxOptions, fn func(tx *Tx) error) (err error) {
+	tx, err := d.Tx(ctx, tid, opts)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		if rerr := tx.Rollback(); rerr != nil {
+			return fmt.Errorf("%w, rollback: %s", err, rerr)
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// WithRead is like WithTx but creates a read-only transaction with the default DB isolation level.
+func (d *DB) WithRead(ctx context.Context, tid string, fn func(tx *Tx) error) (err error) {
+	return d.WithTx(ctx, tid, d.readOpt, fn)
+}
+
+// WithUpdate is like WithTx but creates a read-write transaction with the default DB isolation level.
+func (d *DB) WithUpdate(ctx context.Context, tid string, fn func(tx *Tx) error) (err error) {
+	return d.WithTx(ctx, tid, d.writeOpt, fn)
+}
+
+// Exec executes a statement that doesn't return rows within a single statement read-write transaction
+// with the default DB isolation level, which is committed if the statement succeeds or rolled back otherwise.
+// Use WithTx for transactions with other options.
+func (d *DB) Exec(ctx context.Context, tid string, stmt statement.Statement) (r sql.Result, err error) {
+	err = d.WithUpdate(ctx, tid, func(tx *Tx) (err error) {
+		r, err = tx.Exec(stmt)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Query executes a query that returns rows within a single statement read-only transaction
+// with the default DB isolation level, scanning the rows into dst as Tx.Query.
+// Use WithTx for transactions with other options.
+func (d *DB) Query(ctx context.Context, tid string, dst interface{}, stmt statement.Statement) (err error) {
+	return d.WithRead(ctx, tid, func(tx *Tx) error {
+		return tx.Query(dst, stmt)
+	})
+}
+
+// Ping verifies a connection to the database is still alive,
 // establishing a connection if necessary.
 func (d *DB) Ping(ctx context.Context) (err error) {
+	if d.isClosed() {
+		return ErrClosed
+	}
 	return d.db.PingContext(ctx)
 }
 
-// Close closes the database and prevents new queries from starting.
-// Close then waits for all queries that have started processing on the server to finish.
+// Stats returns the database connection pool statistics, such as open connections and wait counts.
+func (d *DB) Stats() (s sql.DBStats) {
+	return d.db.Stats()
+}
+
+// Underlying returns the underlying *sql.DB for operations not covered by DB, like driver specific
+// features. Operations performed on it bypass the enforced transactional access, logging, tracing and metrics.
+func (d *DB) Underlying() (db *sql.DB) {
+	return d.db
+}
+
+// Close closes the statements of the statement cache, clears the in memory shared cache and closes the database,
+// after which starting transactions returns ErrClosed. Close then waits for all queries that have started
+// processing on the server to finish. It is safe to call Close more than once.
 func (d *DB) Close() (err error) {
+	d.closeMu.Lock()
+	defer d.closeMu.Unlock()
+
+	if d.closed {
+		return nil
+	}
+	d.closed = true
+
+	if d.pstmts != nil {
+		d.pstmts.close()
+	}
+
+	if d.shared != nil {
+		if m, ok := d.shared.store.(*memoryStore); ok {
+			m.reset()
+		}
+	}
+
 	return d.db.Close()
 }
+
+// isClosed returns true if the database was closed with Close.
+func (d *DB) isClosed() bool {
+	d.closeMu.RLock()
+	defer d.closeMu.RUnlock()
+	return d.closed
+}
@@ -4,9 +4,21 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/brunotm/norm/internal/scan"
 	"github.com/brunotm/norm/statement"
 )
 
@@ -23,7 +35,8 @@ func TestTxExecSimple(t *testing.T) {
 	}
 
 	mock.ExpectBegin()
-	mock.ExpectExec("INSERT INTO users(id,name,email,role) VALUES ('123abc','john doe','johnd@email.com','admin')").
+	mock.ExpectExec("INSERT INTO users(id,name,email,role) VALUES (?,?,?,?)").
+		WithArgs("123abc", "john doe", "johnd@email.com", "admin").
 		WillReturnResult(sqlmock.NewResult(0, 1))
 	mock.ExpectCommit()
 
@@ -161,6 +174,65 @@ func TestTxQueryCache(t *testing.T) {
 	}
 }
 
+func TestTxQueryCacheArgs(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,name FROM users WHERE role = ?").WithArgs("admin").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow("123abc", "john doe"),
+	)
+	mock.ExpectQuery("SELECT id,name FROM users WHERE role = ?").WithArgs("user").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow("123abcd", "jane doe"),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	var admins []user
+	if err = tx.QueryCache(&admins, statement.Select().Columns("id", "name").From("users").Where("role = ?", "admin")); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	// same query text with different arguments must not be served from the cache
+	var users []user
+	if err = tx.QueryCache(&users, statement.Select().Columns("id", "name").From("users").Where("role = ?", "user")); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if len(admins) != 1 || admins[0].ID != "123abc" {
+		t.Fatalf("unexpected admins result: %#v", admins)
+	}
+
+	if len(users) != 1 || users[0].ID != "123abcd" {
+		t.Fatalf("unexpected users result: %#v", users)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
 func TestTxQueryCacheTypeCheck(t *testing.T) {
 	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	if err != nil {
@@ -235,6 +307,47 @@ func TestDBPing(t *testing.T) {
 	}
 }
 
+func TestDBPingClosed(t *testing.T) {
+	mdb, mock, err := sqlmock.New(
+		sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual),
+		sqlmock.MonitorPingsOption(true),
+	)
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+
+	db, err := New(mdb, sql.LevelSerializable, nil)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectPing()
+	if err = db.Ping(context.Background()); err != nil {
+		t.Fatalf("error pinging the database: %s", err)
+	}
+
+	if s := db.Stats(); s.OpenConnections != 1 {
+		t.Fatalf("expected 1 open connection, got: %d", s.OpenConnections)
+	}
+
+	mock.ExpectClose()
+	if err = db.Close(); err != nil {
+		t.Fatalf("error closing the database: %s", err)
+	}
+
+	if err = db.Ping(context.Background()); err == nil {
+		t.Fatalf("expected error pinging a closed database")
+	}
+
+	if s := db.Stats(); s.OpenConnections != 0 {
+		t.Fatalf("expected no open connections, got: %d", s.OpenConnections)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
 func TestDBClose(t *testing.T) {
 	mdb, mock, err := sqlmock.New(
 		sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual),
@@ -245,7 +358,7 @@ func TestDBClose(t *testing.T) {
 	}
 	defer mdb.Close()
 
-	db, err := New(mdb, sql.LevelSerializable, nil)
+	db, err := NewWithConfig(mdb, Config{SharedCache: SharedCachePolicy{TTL: time.Minute}})
 	if err != nil {
 		t.Fatalf("error opening norm/database.DB: %s", err)
 	}
@@ -255,6 +368,96 @@ func TestDBClose(t *testing.T) {
 		t.Fatalf("error closing the database: %s", err)
 	}
 
+	// the underlying database is only closed once
+	if err = db.Close(); err != nil {
+		t.Fatalf("expected closing twice to succeed, got: %s", err)
+	}
+
+	if _, err = db.Exec(context.Background(), "", statement.Delete().From("users").Where("id = ?", 1)); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got: %v", err)
+	}
+
+	var ids []int64
+	if err = db.Query(context.Background(), "", &ids, statement.Select().Columns("id").From("users")); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got: %v", err)
+	}
+
+	if err = db.Ping(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Fatalf("expected ErrClosed, got: %v", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestSharedCacheDstTypes(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, SharedCache: SharedCachePolicy{TTL: time.Minute}})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	type user struct {
+		ID    int64
+		Name  string
+		Email string
+	}
+
+	type userSummary struct {
+		ID   int64
+		Name string
+	}
+
+	query := statement.Select().Columns("id", "name", "email").From("users")
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "email"}).AddRow(1, "john", "john@email.com")
+	}
+
+	// each dst type is queried once and then served from the shared cache
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,name,email FROM users").WillReturnRows(rows())
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,name,email FROM users").WillReturnRows(rows())
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	var users []user
+	if err = db.WithRead(context.Background(), "", func(tx *Tx) error {
+		return tx.QueryCache(&users, query)
+	}); err != nil {
+		t.Fatalf("error querying users: %s", err)
+	}
+
+	var summaries []userSummary
+	if err = db.WithRead(context.Background(), "", func(tx *Tx) error {
+		return tx.QueryCache(&summaries, query)
+	}); err != nil {
+		t.Fatalf("error querying user summaries: %s", err)
+	}
+
+	var cached []user
+	if err = db.WithRead(context.Background(), "", func(tx *Tx) error {
+		return tx.QueryCache(&cached, query)
+	}); err != nil {
+		t.Fatalf("error querying cached users: %s", err)
+	}
+
+	if expect := []user{{ID: 1, Name: "john", Email: "john@email.com"}}; !reflect.DeepEqual(expect, users) || !reflect.DeepEqual(expect, cached) {
+		t.Fatalf("expected users: %#v, got: %#v and %#v", expect, users, cached)
+	}
+
+	if expect := []userSummary{{ID: 1, Name: "john"}}; !reflect.DeepEqual(expect, summaries) {
+		t.Fatalf("expected user summaries: %#v, got: %#v", expect, summaries)
+	}
+
 	if err = mock.ExpectationsWereMet(); err != nil {
 		t.Fatalf("mock expectations failed: %s", err)
 	}
@@ -303,3 +506,3931 @@ func TestTxPrepareExecSimple(t *testing.T) {
 		t.Fatalf("mock expectations failed: %s", err)
 	}
 }
+
+func TestTxPrepareQueryScanConfig(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Scan: ScanConfig{NullAsZero: true}})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("SELECT id,name FROM users WHERE role = ?").WillBeClosed().
+		ExpectQuery().WithArgs("admin").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow("123abc", nil).AddRow("123abcd", "jane doe"),
+	).RowsWillBeClosed()
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	stmt, err := tx.Prepare("SELECT id,name FROM users WHERE role = ?")
+	if err != nil {
+		t.Fatalf("error preparing statement: %s", err)
+	}
+
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	// prepared statements scan with the configured scanner, as Tx.Query
+	var users []user
+	if err = stmt.Query(&users, "admin"); err != nil {
+		t.Fatalf("error querying prepared statement: %s", err)
+	}
+
+	expect := []user{{ID: "123abc"}, {ID: "123abcd", Name: "jane doe"}}
+	if !reflect.DeepEqual(expect, users) {
+		t.Fatalf("expected %#v, got %#v", expect, users)
+	}
+
+	if err = stmt.Close(); err != nil {
+		t.Fatalf("error closing prepared statement: %s", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryRow(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	columns := []string{"id", "name"}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,name FROM users WHERE id = ?").WithArgs("123abc").WillReturnRows(
+		sqlmock.NewRows(columns).AddRow("123abc", "john doe"),
+	)
+	mock.ExpectQuery("SELECT id,name FROM users WHERE id = ?").WithArgs("none").WillReturnRows(
+		sqlmock.NewRows(columns),
+	)
+	mock.ExpectQuery("SELECT id,name FROM users").WillReturnRows(
+		sqlmock.NewRows(columns).AddRow("123abc", "john doe").AddRow("123abcd", "jane doe"),
+	)
+	mock.ExpectQuery("SELECT id,name FROM users").WillReturnRows(
+		sqlmock.NewRows(columns).AddRow("123abc", "john doe").AddRow("123abcd", "jane doe"),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	var u user
+	if err = tx.QueryRow(&u, statement.Select().Columns("id", "name").From("users").Where("id = ?", "123abc")); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if u.ID != "123abc" || u.Name != "john doe" {
+		t.Fatalf("unexpected result: %#v", u)
+	}
+
+	if err = tx.QueryRow(&u, statement.Select().Columns("id", "name").From("users").Where("id = ?", "none")); err != ErrNoRows {
+		t.Fatalf("expected ErrNoRows, got: %v", err)
+	}
+
+	if err = tx.QueryRow(&u, statement.Select().Columns("id", "name").From("users")); err != ErrMultipleRows {
+		t.Fatalf("expected ErrMultipleRows, got: %v", err)
+	}
+
+	if err = tx.QueryFirst(&u, statement.Select().Columns("id", "name").From("users")); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if u.ID != "123abc" {
+		t.Fatalf("expected first row, got: %#v", u)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryCacheDisabled(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, QueryCache: CachePolicy{Disabled: true}})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	for x := 0; x < 2; x++ {
+		mock.ExpectQuery("SELECT id,name FROM users").WillReturnRows(
+			sqlmock.NewRows([]string{"id", "name"}).AddRow("123abc", "john doe"),
+		)
+	}
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	// both queries must hit the database
+	for x := 0; x < 2; x++ {
+		var users []user
+		if err = tx.QueryCache(&users, statement.Select().Columns("id", "name").From("users")); err != nil {
+			t.Fatalf("error performing norm/database.DB query: %s", err)
+		}
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryCacheMaxEntries(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, QueryCache: CachePolicy{MaxEntries: 1}})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	for _, id := range []string{"a", "b", "a"} {
+		mock.ExpectQuery("SELECT id FROM users WHERE id = ?").WithArgs(id).WillReturnRows(
+			sqlmock.NewRows([]string{"id"}).AddRow(id),
+		)
+	}
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	// "a" is evicted by "b", so querying "a" again must hit the database
+	for _, id := range []string{"a", "b", "b", "a"} {
+		var ids []string
+		if err = tx.QueryCache(&ids, statement.Select().Columns("id").From("users").Where("id = ?", id)); err != nil {
+			t.Fatalf("error performing norm/database.DB query: %s", err)
+		}
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func BenchmarkTxQueryCache(b *testing.B) {
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	for _, bb := range []struct {
+		name   string
+		policy CachePolicy
+	}{
+		{name: "enabled", policy: CachePolicy{}},
+		{name: "disabled", policy: CachePolicy{Disabled: true}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				b.Fatalf("error opening mock database: %s", err)
+			}
+			defer mdb.Close()
+
+			db, err := NewWithConfig(mdb, Config{QueryCache: bb.policy})
+			if err != nil {
+				b.Fatalf("error opening norm/database.DB: %s", err)
+			}
+
+			mock.ExpectBegin()
+			for x := 0; x < b.N; x++ {
+				mock.ExpectQuery("SELECT id,name FROM users WHERE id = ?").WithArgs(x).WillReturnRows(
+					sqlmock.NewRows([]string{"id", "name"}).AddRow("123abc", "john doe"),
+				)
+			}
+
+			tx, err := db.Read(context.Background(), "someid")
+			if err != nil {
+				b.Fatalf("error opening norm/database.DB transaction: %s", err)
+			}
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for x := 0; x < b.N; x++ {
+				var users []user
+				if err = tx.QueryCache(&users, statement.Select().Columns("id", "name").From("users").Where("id = ?", x)); err != nil {
+					b.Fatalf("error performing norm/database.DB query: %s", err)
+				}
+			}
+		})
+	}
+}
+
+var errBuild = fmt.Errorf("build error")
+
+// errStatement is a statement that always fails to build
+type errStatement struct{}
+
+func (errStatement) Build(statement.Buffer) error { return errBuild }
+func (errStatement) String() (string, error)      { return "", errBuild }
+
+func TestTxBuildError(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	if _, err = tx.Exec(errStatement{}); err != errBuild {
+		t.Fatalf("expected build error, got: %v", err)
+	}
+
+	var dst []string
+	if err = tx.Query(&dst, errStatement{}); err != errBuild {
+		t.Fatalf("expected build error, got: %v", err)
+	}
+
+	// the transaction lock must have been released exactly once
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryCacheCopy(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,name,tags FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "tags"}).
+			AddRow("123abc", "john doe", []byte("admin")).
+			AddRow("123abcd", "jane doe", []byte("user")),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID   string
+		Name *string
+		Tags []byte
+	}
+
+	query := statement.Select().Columns("id", "name", "tags").From("users")
+
+	var first []user
+	if err = tx.QueryCache(&first, query); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	// mutate the results returned from the database
+	first[0].ID = "mutated"
+	*first[0].Name = "mutated"
+	first[0].Tags[0] = 'x'
+
+	var second []user
+	if err = tx.QueryCache(&second, query); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	// mutate the results returned from the cache
+	second[1].ID = "mutated"
+
+	var third []user
+	if err = tx.QueryCache(&third, query); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	for _, users := range [][]user{second, third} {
+		if users[0].ID != "123abc" || *users[0].Name != "john doe" || string(users[0].Tags) != "admin" {
+			t.Fatalf("cached result was mutated: %#v", users[0])
+		}
+	}
+
+	if third[1].ID != "123abcd" {
+		t.Fatalf("cached result was mutated: %#v", third[1])
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryTimeout(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, QueryTimeout: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users").WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("123abc"))
+	mock.ExpectExec("DELETE FROM users").WillDelayFor(time.Second).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	var ids []string
+	if err = tx.Query(&ids, statement.Select().Columns("id").From("users")); err == nil {
+		t.Fatalf("expected query timeout error")
+	}
+
+	if _, err = tx.Exec(statement.Delete().From("users")); err == nil {
+		t.Fatalf("expected exec timeout error")
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryContext(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users").WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("123abc"))
+	mock.ExpectExec("DELETE FROM users").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var ids []string
+	if err = tx.QueryContext(ctx, &ids, statement.Select().Columns("id").From("users")); err == nil {
+		t.Fatalf("expected query context error")
+	}
+
+	// a different context can be used for subsequent statements
+	if _, err = tx.ExecContext(context.Background(), statement.Delete().From("users")); err != nil {
+		t.Fatalf("error executing norm/database.DB transaction: %s", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryRowsError(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	errRows := fmt.Errorf("connection reset")
+	mock.ExpectBegin()
+	rows := sqlmock.NewRows([]string{"id"}).AddRow("a").AddRow("b").AddRow("c").RowError(2, errRows)
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(rows).RowsWillBeClosed()
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	var ids []string
+	if err = tx.Query(&ids, statement.Select().Columns("id").From("users")); err != errRows {
+		t.Fatalf("expected rows error, got: %v", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryMaps(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Scan: ScanConfig{BytesAsString: true}})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	created := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	columns := []string{"id", "name", "score", "active", "created_at", "deleted_at"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT * FROM users").WillReturnRows(
+		sqlmock.NewRows(columns).
+			AddRow(int64(1), []byte("john doe"), 9.5, true, created, nil).
+			AddRow(int64(2), []byte("jane doe"), 7.5, false, created, created),
+	)
+	mock.ExpectQuery("SELECT * FROM users LIMIT 1 OFFSET 0").WillReturnRows(
+		sqlmock.NewRows(columns).AddRow(int64(1), []byte("john doe"), 9.5, true, created, nil),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	var users []map[string]interface{}
+	if err = tx.Query(&users, statement.Select().Columns("*").From("users")); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	var user map[string]interface{}
+	if err = tx.Query(&user, statement.Select().Columns("*").From("users").Limit(1)); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	expected := map[string]interface{}{
+		"id": int64(1), "name": "john doe", "score": 9.5, "active": true, "created_at": created, "deleted_at": nil,
+	}
+
+	if len(users) != 2 || !reflect.DeepEqual(users[0], expected) {
+		t.Fatalf("expected: %#v, got: %#v", expected, users)
+	}
+
+	if !reflect.DeepEqual(user, expected) {
+		t.Fatalf("expected: %#v, got: %#v", expected, user)
+	}
+
+	if users[1]["deleted_at"] != created {
+		t.Fatalf("expected deleted_at: %s, got: %#v", created, users[1]["deleted_at"])
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryNameMapper(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Scan: ScanConfig{NameMapper: strings.ToLower}})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT userid,user_name,password FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"userid", "user_name", "password"}).AddRow("123abc", "john doe", "secret"),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		UserID   string
+		Name     string `db:"user_name"`
+		Password string `db:"-"`
+	}
+
+	var u user
+	if err = tx.QueryRow(&u, statement.Select().Columns("userid", "user_name", "password").From("users")); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if expected := (user{UserID: "123abc", Name: "john doe"}); u != expected {
+		t.Fatalf("expected: %#v, got: %#v", expected, u)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxExecReturning(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.Postgres})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	created := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO users(name,email) VALUES ($1,$2) RETURNING id,created_at").
+		WithArgs("john doe", "johnd@email.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(int64(1), created))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	var ret struct {
+		ID        int64
+		CreatedAt time.Time
+	}
+
+	insert := statement.Insert().Into("users").Columns("name", "email").
+		Values("john doe", "johnd@email.com").Returning("id", "created_at")
+
+	if err = tx.ExecReturning(&ret, insert); err != nil {
+		t.Fatalf("error executing norm/database.DB transaction: %s", err)
+	}
+
+	if ret.ID != 1 || !ret.CreatedAt.Equal(created) {
+		t.Fatalf("unexpected returning values: %#v", ret)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxExecBatch(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.SQLite})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	// 600 rows of 2 columns exceed the SQLite limit of 999 arguments
+	insert := statement.Insert().Into("users").Columns("id", "name")
+	for x := 0; x < 600; x++ {
+		insert.Values(x, "user")
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users(id,name) VALUES " + strings.TrimSuffix(strings.Repeat("(?,?),", 499), ",")).
+		WillReturnResult(sqlmock.NewResult(0, 499))
+	mock.ExpectExec("INSERT INTO users(id,name) VALUES " + strings.TrimSuffix(strings.Repeat("(?,?),", 101), ",")).
+		WillReturnResult(sqlmock.NewResult(0, 101))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	affected, err := tx.ExecBatch(insert)
+	if err != nil {
+		t.Fatalf("error executing norm/database.DB transaction: %s", err)
+	}
+
+	if affected != 600 {
+		t.Fatalf("expected 600 rows affected, got: %d", affected)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxExecBatchReturning(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	// 2 rows of 2 columns per batch
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.Postgres, MaxArgs: 4})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	insert := statement.Insert().Into("users").Columns("name", "email").Returning("id")
+	for x := 1; x <= 5; x++ {
+		insert.Values(fmt.Sprintf("user%d", x), fmt.Sprintf("user%d@email.com", x))
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO users(name,email) VALUES ($1,$2),($3,$4) RETURNING id").
+		WithArgs("user1", "user1@email.com", "user2", "user2@email.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(11).AddRow(12))
+	mock.ExpectQuery("INSERT INTO users(name,email) VALUES ($1,$2),($3,$4) RETURNING id").
+		WithArgs("user3", "user3@email.com", "user4", "user4@email.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(13).AddRow(14))
+	mock.ExpectQuery("INSERT INTO users(name,email) VALUES ($1,$2) RETURNING id").
+		WithArgs("user5", "user5@email.com").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(15))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	var ids []int64
+	if err = tx.ExecBatchReturning(&ids, insert); err != nil {
+		t.Fatalf("error executing norm/database.DB transaction: %s", err)
+	}
+
+	if expect := []int64{11, 12, 13, 14, 15}; !reflect.DeepEqual(expect, ids) {
+		t.Fatalf("expected ids: %v, got: %v", expect, ids)
+	}
+
+	var id int64
+	if err = tx.ExecBatchReturning(&id, insert); err == nil {
+		t.Fatalf("expected error for non slice dst")
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxSavepoint(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM users WHERE id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("ROLLBACK TO SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("RELEASE SAVEPOINT sp_1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("SAVEPOINT sp_2").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	if _, err = tx.Savepoint("sp_1; DROP TABLE users"); !errors.Is(err, ErrInvalidSavepoint) {
+		t.Fatalf("expected ErrInvalidSavepoint, got: %v", err)
+	}
+
+	sp, err := tx.Savepoint("sp_1")
+	if err != nil {
+		t.Fatalf("error creating savepoint: %s", err)
+	}
+
+	if _, err = tx.Exec(statement.Delete().From("users").Where("id = ?", 1)); err != nil {
+		t.Fatalf("error executing norm/database.DB transaction: %s", err)
+	}
+
+	if err = sp.RollbackTo(); err != nil {
+		t.Fatalf("error rolling back to savepoint: %s", err)
+	}
+
+	if err = sp.Release(); err != nil {
+		t.Fatalf("error releasing savepoint: %s", err)
+	}
+
+	if err = sp.RollbackTo(); !errors.Is(err, ErrInvalidSavepoint) {
+		t.Fatalf("expected ErrInvalidSavepoint for released savepoint, got: %v", err)
+	}
+
+	// outstanding savepoints are discarded with the transaction
+	if _, err = tx.Savepoint("sp_2"); err != nil {
+		t.Fatalf("error creating savepoint: %s", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back norm/database.DB transaction: %s", err)
+	}
+
+	if _, err = tx.Savepoint("sp_3"); !errors.Is(err, sql.ErrTxDone) {
+		t.Fatalf("expected sql.ErrTxDone, got: %v", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxSetLocal(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.Postgres})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	// the settings are issued in order before the first query
+	mock.ExpectBegin()
+	mock.ExpectExec("SET LOCAL statement_timeout = E'5s'").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SET LOCAL app.tenant_id = E'o''brien\\'`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT id FROM users WHERE active = $1").WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	for _, param := range []string{"", "statement_timeout = 0; DROP TABLE users; --", "a.b.c", "1work_mem"} {
+		if err = tx.SetLocal(param, "1"); !errors.Is(err, ErrInvalidSetting) {
+			t.Fatalf("expected ErrInvalidSetting for %q, got: %v", param, err)
+		}
+	}
+
+	if err = tx.SetLocal("statement_timeout", "5s"); err != nil {
+		t.Fatalf("error setting local parameter: %s", err)
+	}
+
+	if err = tx.SetLocal("app.tenant_id", `o'brien\`); err != nil {
+		t.Fatalf("error setting local parameter: %s", err)
+	}
+
+	var ids []int64
+	if err = tx.Query(&ids, statement.Select().Columns("id").From("users").Where("active = ?", true)); err != nil {
+		t.Fatalf("error executing query: %s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+
+	// SET LOCAL is only supported on Postgres, as the value is an escape string literal
+	for _, dialect := range []statement.Dialect{statement.Default, statement.MySQL} {
+		other, err := NewWithConfig(mdb, Config{Dialect: dialect})
+		if err != nil {
+			t.Fatalf("error opening norm/database.DB: %s", err)
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		tx, err = other.Update(context.Background(), "")
+		if err != nil {
+			t.Fatalf("error opening norm/database.DB transaction: %s", err)
+		}
+
+		if err = tx.SetLocal("work_mem", "64MB"); !errors.Is(err, statement.ErrUnsupported) {
+			t.Fatalf("expected ErrUnsupported on dialect %s, got: %v", dialect, err)
+		}
+
+		if err = tx.Rollback(); err != nil {
+			t.Fatalf("error rolling back transaction: %s", err)
+		}
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestDBWithTx(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	del := statement.Delete().From("users").Where("id = ?", 1)
+	errFn := fmt.Errorf("fn error")
+
+	// commit on success
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM users WHERE id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = db.WithUpdate(context.Background(), "", func(tx *Tx) error {
+		_, err := tx.Exec(del)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("error executing norm/database.DB transaction: %s", err)
+	}
+
+	// rollback on error
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err = db.WithRead(context.Background(), "", func(tx *Tx) error {
+		return errFn
+	})
+	if !errors.Is(err, errFn) {
+		t.Fatalf("expected fn error, got: %v", err)
+	}
+
+	// rollback on panic
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	func() {
+		defer func() {
+			if p := recover(); p != "fn panic" {
+				t.Fatalf("expected fn panic to propagate, got: %v", p)
+			}
+		}()
+
+		_ = db.WithUpdate(context.Background(), "", func(tx *Tx) error {
+			panic("fn panic")
+		})
+	}()
+
+	// rollback within fn is safe
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	err = db.WithUpdate(context.Background(), "", func(tx *Tx) error {
+		if err := tx.Rollback(); err != nil {
+			return err
+		}
+		return errFn
+	})
+	if !errors.Is(err, errFn) {
+		t.Fatalf("expected fn error, got: %v", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+// sqlStateError is a driver error exposing its SQLSTATE as github.com/lib/pq errors.
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "driver error: " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestDBWithUpdateRetry(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Retry: RetryPolicy{Backoff: time.Millisecond}})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	update := statement.Update().Table("accounts").Set("balance", 10).Where("id = ?", 1)
+	fn := func(attempts *int) func(tx *Tx) error {
+		return func(tx *Tx) error {
+			*attempts++
+			_, err := tx.Exec(update)
+			return err
+		}
+	}
+
+	// retry serialization failures until the transaction succeeds
+	for x := 0; x < 2; x++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE accounts SET balance = ? WHERE id = ?").WithArgs(10, 1).
+			WillReturnError(sqlStateError("40001"))
+		mock.ExpectRollback()
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE accounts SET balance = ? WHERE id = ?").WithArgs(10, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	attempts := 0
+	if err = db.WithUpdateRetry(context.Background(), "", 3, fn(&attempts)); err != nil {
+		t.Fatalf("error executing norm/database.DB transaction: %s", err)
+	}
+
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got: %d", attempts)
+	}
+
+	// stop after max retries
+	for x := 0; x < 2; x++ {
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE accounts SET balance = ? WHERE id = ?").WithArgs(10, 1).
+			WillReturnError(sqlStateError("40P01"))
+		mock.ExpectRollback()
+	}
+
+	attempts = 0
+	if err = db.WithUpdateRetry(context.Background(), "", 1, fn(&attempts)); !errors.Is(err, sqlStateError("40P01")) {
+		t.Fatalf("expected deadlock error, got: %v", err)
+	}
+
+	if attempts != 2 {
+		t.Fatalf("expected 2 attempts, got: %d", attempts)
+	}
+
+	// do not retry other errors
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE accounts SET balance = ? WHERE id = ?").WithArgs(10, 1).
+		WillReturnError(sqlStateError("23505"))
+	mock.ExpectRollback()
+
+	attempts = 0
+	if err = db.WithUpdateRetry(context.Background(), "", 3, fn(&attempts)); !errors.Is(err, sqlStateError("23505")) {
+		t.Fatalf("expected unique violation error, got: %v", err)
+	}
+
+	if attempts != 1 {
+		t.Fatalf("expected 1 attempt, got: %d", attempts)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestDBWithUpdateRetryClassifier(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	errDeadlock := fmt.Errorf("Error 1213: Deadlock found when trying to get lock")
+	db, err := NewWithConfig(mdb, Config{
+		Logger: DefaultLogger,
+		Retry: RetryPolicy{
+			Backoff:   time.Millisecond,
+			Retryable: func(err error) bool { return errors.Is(err, errDeadlock) },
+		},
+	})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM users WHERE id = ?").WithArgs(1).WillReturnError(errDeadlock)
+	mock.ExpectRollback()
+	mock.ExpectBegin()
+	mock.ExpectExec("DELETE FROM users WHERE id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = db.WithUpdateRetry(context.Background(), "", 1, func(tx *Tx) error {
+		_, err := tx.Exec(statement.Delete().From("users").Where("id = ?", 1))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("error executing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxPrepareCache(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, PrepareCache: true})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	insert := mock.ExpectPrepare("INSERT INTO users(id,name) VALUES (?,?)")
+	insert.ExpectExec().WithArgs(1, "john").WillReturnResult(sqlmock.NewResult(0, 1))
+	insert.ExpectExec().WithArgs(2, "jane").WillReturnResult(sqlmock.NewResult(0, 1))
+	selectByID := mock.ExpectPrepare("SELECT name FROM users WHERE id = ?")
+	selectByID.ExpectQuery().WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("john"))
+	selectByID.ExpectQuery().WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("jane"))
+	insert.WillBeClosed()
+	selectByID.WillBeClosed()
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	for id, name := range []string{"john", "jane"} {
+		if _, err = tx.Exec(statement.Insert().Into("users").Columns("id", "name").Values(id+1, name)); err != nil {
+			t.Fatalf("error executing norm/database.DB transaction: %s", err)
+		}
+	}
+
+	for _, id := range []int{1, 2} {
+		var name string
+		if err = tx.QueryRow(&name, statement.Select().Columns("name").From("users").Where("id = ?", id)); err != nil {
+			t.Fatalf("error querying norm/database.DB transaction: %s", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxEventLogger(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	var events []LogEvent
+	db, err := NewWithConfig(mdb, Config{EventLogger: func(e LogEvent) { events = append(events, e) }})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET role = ? WHERE role = ?").WithArgs("user", "guest").
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectQuery("SELECT id FROM users WHERE role = ?").WithArgs("user").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	if _, err = tx.Exec(statement.Update().Table("users").Set("role", "user").Where("role = ?", "guest")); err != nil {
+		t.Fatalf("error executing norm/database.DB transaction: %s", err)
+	}
+
+	var ids []int
+	if err = tx.Query(&ids, statement.Select().Columns("id").From("users").Where("role = ?", "user")); err != nil {
+		t.Fatalf("error querying norm/database.DB transaction: %s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+
+	expect := []LogEvent{
+		{Op: "db.begin", TxID: "someid"},
+		{Op: "db.tx.exec", TxID: "someid", Query: "UPDATE users SET role = ? WHERE role = ?",
+			Args: []interface{}{"user", "guest"}, RowsAffected: 3},
+		{Op: "db.tx.query", TxID: "someid", Query: "SELECT id FROM users WHERE role = ?",
+			Args: []interface{}{"user"}, RowsAffected: 2},
+		{Op: "db.tx.commit", TxID: "someid"},
+	}
+
+	for x := range events {
+		events[x].Duration = 0
+	}
+
+	if !reflect.DeepEqual(expect, events) {
+		t.Fatalf("expected events: %#v, got: %#v", expect, events)
+	}
+}
+
+type testSpan struct {
+	name  string
+	attrs map[string]string
+	err   error
+	ended bool
+}
+
+func (s *testSpan) End(err error) {
+	s.err = err
+	s.ended = true
+}
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span) {
+	s := &testSpan{name: name, attrs: map[string]string{}}
+	for _, a := range attrs {
+		s.attrs[a.Key] = a.Value
+	}
+
+	t.spans = append(t.spans, s)
+	return ctx, s
+}
+
+func TestTxTracer(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	tracer := &testTracer{}
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Tracer: tracer})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	errExec := fmt.Errorf("exec error")
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("-- tracing\nSELECT id FROM users").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM users WHERE id = ?").WithArgs(1).WillReturnError(errExec)
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	var ids []int
+	if err = tx.Query(&ids, statement.Select().Comment("tracing").Columns("id").From("users")); err != nil {
+		t.Fatalf("error querying norm/database.DB transaction: %s", err)
+	}
+
+	if _, err = tx.Exec(statement.Delete().From("users").Where("id = ?", 1)); !errors.Is(err, errExec) {
+		t.Fatalf("expected exec error, got: %v", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+
+	expect := []*testSpan{
+		{name: "db.tx.query", ended: true, attrs: map[string]string{
+			AttrDBTxID: "someid", AttrDBOperation: "SELECT", AttrDBStatement: "-- tracing\nSELECT id FROM users"}},
+		{name: "db.tx.exec", ended: true, err: errExec, attrs: map[string]string{
+			AttrDBTxID: "someid", AttrDBOperation: "DELETE", AttrDBStatement: "DELETE FROM users WHERE id = ?"}},
+		{name: "db.tx.rollback", ended: true, attrs: map[string]string{
+			AttrDBTxID: "someid", AttrDBOperation: "ROLLBACK"}},
+	}
+
+	if !reflect.DeepEqual(expect, tracer.spans) {
+		for x := range tracer.spans {
+			t.Logf("span: %#v", tracer.spans[x])
+		}
+		t.Fatalf("unexpected spans")
+	}
+}
+
+type testMetrics struct {
+	ops    map[string]int
+	errors int
+	hits   int
+	misses int
+}
+
+func (m *testMetrics) ObserveQuery(op string, d time.Duration, err error) {
+	m.ops[op]++
+	if err != nil {
+		m.errors++
+	}
+}
+
+func (m *testMetrics) ObserveCache(hit bool) {
+	if hit {
+		m.hits++
+	} else {
+		m.misses++
+	}
+}
+
+func TestTxMetrics(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	metrics := &testMetrics{ops: map[string]int{}}
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Metrics: metrics})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectExec("DELETE FROM users").WillReturnError(fmt.Errorf("exec error"))
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	for x := 0; x < 2; x++ {
+		var ids []int
+		if err = tx.QueryCache(&ids, statement.Select().Columns("id").From("users")); err != nil {
+			t.Fatalf("error querying norm/database.DB transaction: %s", err)
+		}
+	}
+
+	if _, err = tx.Exec(statement.Delete().From("users")); err == nil {
+		t.Fatalf("expected exec error")
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+
+	expect := &testMetrics{
+		ops: map[string]int{
+			"db.begin":              1,
+			"db.tx.query.cache.add": 1,
+			"db.tx.query.cache.get": 1,
+			"db.tx.exec":            1,
+			"db.tx.rollback":        1,
+		},
+		errors: 1,
+		hits:   1,
+		misses: 1,
+	}
+
+	if !reflect.DeepEqual(expect, metrics) {
+		t.Fatalf("expected metrics: %#v, got: %#v", expect, metrics)
+	}
+}
+
+func TestTxQueryStatementSQL(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.Postgres})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,name FROM users WHERE role = $1").WithArgs("admin").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow("123abc", "john doe"),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	var built, raw []user
+	if err = tx.QueryCache(&built, statement.Select().Columns("id", "name").From("users").Where("role = ?", "admin")); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	// the hand written query builds to the same query and arguments and should hit the cache
+	if err = tx.QueryCache(&raw, statement.SQL("SELECT id,name FROM users WHERE role = ?", "admin")); err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if !reflect.DeepEqual(built, raw) {
+		t.Fatalf("expected %#v, got %#v", built, raw)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxExplain(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	query := statement.Select().Columns("id").From("users").Where("email = ?", "john@email.com")
+
+	cases := []struct {
+		name    string
+		dialect statement.Dialect
+		opts    []ExplainOption
+		expect  string
+		rows    *sqlmock.Rows
+		plan    string
+		wantErr bool
+	}{
+		{
+			name:    "postgres",
+			dialect: statement.Postgres,
+			expect:  "EXPLAIN SELECT id FROM users WHERE email = $1",
+			rows: sqlmock.NewRows([]string{"QUERY PLAN"}).
+				AddRow("Index Scan using users_email_idx on users  (cost=0.28..8.29 rows=1 width=4)").
+				AddRow("  Index Cond: (email = 'john@email.com'::text)"),
+			plan: "Index Scan using users_email_idx on users  (cost=0.28..8.29 rows=1 width=4)\n  Index Cond: (email = 'john@email.com'::text)",
+		},
+		{
+			name:    "postgres_analyze_json",
+			dialect: statement.Postgres,
+			opts:    []ExplainOption{ExplainAnalyze(), ExplainFormat("json")},
+			expect:  "EXPLAIN (ANALYZE, FORMAT JSON) SELECT id FROM users WHERE email = $1",
+			rows:    sqlmock.NewRows([]string{"QUERY PLAN"}).AddRow(`[{"Plan": {"Node Type": "Seq Scan"}}]`),
+			plan:    `[{"Plan": {"Node Type": "Seq Scan"}}]`,
+		},
+		{
+			name:    "mysql_traditional",
+			dialect: statement.MySQL,
+			expect:  "EXPLAIN SELECT id FROM users WHERE email = ?",
+			rows: sqlmock.NewRows([]string{"id", "select_type", "table", "type", "key"}).
+				AddRow(1, "SIMPLE", "users", "ALL", nil),
+			plan: "id\tselect_type\ttable\ttype\tkey\n1\tSIMPLE\tusers\tALL\tNULL",
+		},
+		{
+			name:    "mysql_analyze_tree",
+			dialect: statement.MySQL,
+			opts:    []ExplainOption{ExplainAnalyze(), ExplainFormat("tree")},
+			expect:  "EXPLAIN ANALYZE SELECT id FROM users WHERE email = ?",
+			rows:    sqlmock.NewRows([]string{"EXPLAIN"}).AddRow("-> Table scan on users  (actual time=0.05..0.06 rows=1 loops=1)"),
+			plan:    "-> Table scan on users  (actual time=0.05..0.06 rows=1 loops=1)",
+		},
+		{
+			name:    "mysql_analyze_json",
+			dialect: statement.MySQL,
+			opts:    []ExplainOption{ExplainAnalyze(), ExplainFormat("json")},
+			wantErr: true,
+		},
+		{
+			name:    "oracle",
+			dialect: statement.Oracle,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: tt.dialect})
+			if err != nil {
+				t.Fatalf("error opening norm/database.DB: %s", err)
+			}
+
+			mock.ExpectBegin()
+			if !tt.wantErr {
+				mock.ExpectQuery(tt.expect).WithArgs("john@email.com").WillReturnRows(tt.rows)
+			}
+			mock.ExpectRollback()
+
+			tx, err := db.Read(context.Background(), "")
+			if err != nil {
+				t.Fatalf("error opening norm/database.DB transaction: %s", err)
+			}
+			defer tx.Rollback()
+
+			plan, err := tx.Explain(query, tt.opts...)
+			if tt.wantErr {
+				if !errors.Is(err, statement.ErrUnsupported) {
+					t.Fatalf("expected statement.ErrUnsupported, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error explaining query: %s", err)
+			}
+
+			if plan != tt.plan {
+				t.Fatalf("expected plan: %q, got: %q", tt.plan, plan)
+			}
+		})
+	}
+
+	// explain errors are classified as other queries
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.Postgres})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("EXPLAIN SELECT id FROM users WHERE email = $1").WithArgs("john@email.com").
+		WillReturnError(sqlStateError("57014"))
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	if _, err = tx.Explain(query); ErrorClassOf(err) != Timeout {
+		t.Fatalf("expected classified timeout error, got: %v", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxCursor(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	query := statement.Select().Columns("id", "name").From("users").Where("active = ?", true)
+
+	// cursors are never cached, so each cursor executes the query
+	mock.ExpectBegin()
+	for x := 0; x < 2; x++ {
+		mock.ExpectQuery("SELECT id,name FROM users WHERE active = ?").WithArgs(true).WillReturnRows(
+			sqlmock.NewRows([]string{"id", "name"}).AddRow("123abc", "john doe").AddRow("123abcd", "jane doe"),
+		).RowsWillBeClosed()
+	}
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	for x := 0; x < 2; x++ {
+		cursor, err := tx.Cursor(query)
+		if err != nil {
+			t.Fatalf("error opening cursor: %s", err)
+		}
+
+		var users []user
+		for cursor.Next() {
+			var u user
+			if err = cursor.Scan(&u); err != nil {
+				t.Fatalf("error scanning cursor: %s", err)
+			}
+			users = append(users, u)
+		}
+
+		if err = cursor.Err(); err != nil {
+			t.Fatalf("error iterating cursor: %s", err)
+		}
+
+		if err = cursor.Close(); err != nil {
+			t.Fatalf("error closing cursor: %s", err)
+		}
+
+		expect := []user{{ID: "123abc", Name: "john doe"}, {ID: "123abcd", Name: "jane doe"}}
+		if !reflect.DeepEqual(expect, users) {
+			t.Fatalf("expected %#v, got %#v", expect, users)
+		}
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxCursorCheck(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	query := statement.Select().Columns("id", "name", "email").From("users")
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "name", "email"}).AddRow("123abc", "john doe", "john@doe.com")
+	}
+
+	cases := []struct {
+		name   string
+		config ScanConfig
+		dst    interface{}
+	}{
+		{"scalar", ScanConfig{}, new(string)},
+		{"strict", ScanConfig{Strict: true}, &user{}},
+		{"positional", ScanConfig{Positional: true}, &user{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Scan: c.config})
+			if err != nil {
+				t.Fatalf("error opening norm/database.DB: %s", err)
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT id,name,email FROM users").WillReturnRows(rows()).RowsWillBeClosed()
+			mock.ExpectQuery("SELECT id,name,email FROM users").WillReturnRows(rows()).RowsWillBeClosed()
+			mock.ExpectRollback()
+
+			tx, err := db.Read(context.Background(), "")
+			if err != nil {
+				t.Fatalf("error opening norm/database.DB transaction: %s", err)
+			}
+
+			cursor, err := tx.Cursor(query)
+			if err != nil {
+				t.Fatalf("error opening cursor: %s", err)
+			}
+
+			if !cursor.Next() {
+				t.Fatalf("expected a row, got: %v", cursor.Err())
+			}
+
+			if err = cursor.Scan(c.dst); !errors.Is(err, scan.ErrColumnMismatch) {
+				t.Fatalf("expected scan.ErrColumnMismatch, got: %v", err)
+			}
+
+			if err = cursor.Close(); err != nil {
+				t.Fatalf("error closing cursor: %s", err)
+			}
+
+			err = tx.QueryFunc(query, func(row RowScanner) error { return row.Scan(c.dst) })
+			if !errors.Is(err, scan.ErrColumnMismatch) {
+				t.Fatalf("expected scan.ErrColumnMismatch, got: %v", err)
+			}
+
+			if err = tx.Rollback(); err != nil {
+				t.Fatalf("error rolling back transaction: %s", err)
+			}
+
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("mock expectations failed: %s", err)
+			}
+		})
+	}
+}
+
+func TestTxQueryHierarchy(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.Postgres})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	// electronics > phones > android, books
+	query := statement.Hierarchy("categories", "id", "parent_id", statement.Cond("parent_id IS NULL")).
+		Columns("id", "parent_id", "name").NoCycles()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`WITH RECURSIVE hierarchy (id,parent_id,name,depth,path) AS (SELECT t.id,t.parent_id,t.name,1 AS depth,'/' || CAST(t.id AS TEXT) || '/' AS path FROM categories t WHERE parent_id IS NULL UNION ALL SELECT c.id,c.parent_id,c.name,h.depth + 1,h.path || CAST(c.id AS TEXT) || '/' FROM categories c INNER JOIN hierarchy h ON c.parent_id = h.id WHERE h.path NOT LIKE '%/' || CAST(c.id AS TEXT) || '/%') SELECT * FROM hierarchy ORDER BY path`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "parent_id", "name", "depth", "path"}).
+			AddRow(1, nil, "electronics", 1, "/1/").
+			AddRow(2, 1, "phones", 2, "/1/2/").
+			AddRow(3, 2, "android", 3, "/1/2/3/").
+			AddRow(4, nil, "books", 1, "/4/"))
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type category struct {
+		ID       int64
+		ParentID *int64
+		Name     string
+		Depth    int
+		Path     string
+	}
+
+	var categories []category
+	if err = tx.Query(&categories, query); err != nil {
+		t.Fatalf("error executing query: %s", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	one, two := int64(1), int64(2)
+	expect := []category{
+		{ID: 1, Name: "electronics", Depth: 1, Path: "/1/"},
+		{ID: 2, ParentID: &one, Name: "phones", Depth: 2, Path: "/1/2/"},
+		{ID: 3, ParentID: &two, Name: "android", Depth: 3, Path: "/1/2/3/"},
+		{ID: 4, Name: "books", Depth: 1, Path: "/4/"},
+	}
+
+	if !reflect.DeepEqual(expect, categories) {
+		t.Fatalf("expected categories: %#v, got: %#v", expect, categories)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryFunc(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	query := statement.Select().Columns("id", "total").From("orders").Where("status = ?", "paid")
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id", "total"}).AddRow(1, 10).AddRow(2, 25).AddRow(3, 7)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,total FROM orders WHERE status = ?").WithArgs("paid").
+		WillReturnRows(rows()).RowsWillBeClosed()
+	mock.ExpectQuery("SELECT id,total FROM orders WHERE status = ?").WithArgs("paid").
+		WillReturnRows(rows()).RowsWillBeClosed()
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type order struct {
+		ID    int64
+		Total int64
+	}
+
+	// streaming aggregation
+	var sum, count int64
+	err = tx.QueryFunc(query, func(row RowScanner) error {
+		var o order
+		if err := row.Scan(&o); err != nil {
+			return err
+		}
+
+		sum += o.Total
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error querying rows: %s", err)
+	}
+
+	if sum != 42 || count != 3 {
+		t.Fatalf("expected sum 42 of 3 rows, got: %d of %d", sum, count)
+	}
+
+	// stop at the first callback error
+	errStop := errors.New("stop")
+	count = 0
+	err = tx.QueryFunc(query, func(row RowScanner) error {
+		if count++; count == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Fatalf("expected callback error, got: %v", err)
+	}
+
+	if count != 2 {
+		t.Fatalf("expected 2 rows, got: %d", count)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxMaxRows(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	if _, err = NewWithConfig(mdb, Config{MaxRows: -1}); err == nil {
+		t.Fatalf("expected error for negative max rows")
+	}
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, MaxRows: 3})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	query := statement.Select().Columns("id").From("events")
+	rows := func() *sqlmock.Rows {
+		return sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3).AddRow(4).AddRow(5)
+	}
+
+	mock.ExpectBegin()
+	for x := 0; x < 3; x++ {
+		mock.ExpectQuery("SELECT id FROM events").WillReturnRows(rows()).RowsWillBeClosed()
+	}
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	var ids []int64
+	if err = tx.Query(&ids, query); !errors.Is(err, ErrMaxRows) {
+		t.Fatalf("expected ErrMaxRows, got: %v", err)
+	}
+
+	// overridden per call
+	ids = nil
+	if err = tx.QueryContext(WithMaxRows(context.Background(), 10), &ids, query); err != nil {
+		t.Fatalf("error querying rows: %s", err)
+	}
+
+	if len(ids) != 5 {
+		t.Fatalf("expected 5 rows, got: %d", len(ids))
+	}
+
+	// disabled per call
+	ids = nil
+	if err = tx.QueryContext(WithMaxRows(context.Background(), 0), &ids, query); err != nil || len(ids) != 5 {
+		t.Fatalf("expected 5 rows, got: %d, err: %v", len(ids), err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	// prepared statements, limited by the configuration or overridden in the transaction context
+	for _, tt := range []struct {
+		ctx    context.Context
+		expect error
+	}{
+		{context.Background(), ErrMaxRows},
+		{WithMaxRows(context.Background(), 10), nil},
+	} {
+		mock.ExpectBegin()
+		mock.ExpectPrepare("SELECT id FROM events").WillBeClosed().
+			ExpectQuery().WillReturnRows(rows()).RowsWillBeClosed()
+		mock.ExpectRollback()
+
+		if tx, err = db.Read(tt.ctx, ""); err != nil {
+			t.Fatalf("error opening norm/database.DB transaction: %s", err)
+		}
+
+		stmt, err := tx.Prepare("SELECT id FROM events")
+		if err != nil {
+			t.Fatalf("error preparing statement: %s", err)
+		}
+
+		ids = nil
+		if err = stmt.Query(&ids); !errors.Is(err, tt.expect) || (tt.expect == nil && len(ids) != 5) {
+			t.Fatalf("expected error %v or 5 rows, got: %d, err: %v", tt.expect, len(ids), err)
+		}
+
+		if err = stmt.Close(); err != nil {
+			t.Fatalf("error closing prepared statement: %s", err)
+		}
+
+		if err = tx.Rollback(); err != nil {
+			t.Fatalf("error rolling back transaction: %s", err)
+		}
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxConcurrentUse(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	const workers = 8
+
+	// operations from multiple goroutines are serialized in any order on the transaction connection
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectBegin()
+	for x := 0; x < workers; x++ {
+		mock.ExpectQuery("SELECT name FROM users WHERE id = ?").WithArgs(x).
+			WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow(fmt.Sprintf("user%d", x)))
+		mock.ExpectExec("UPDATE users SET seen = ? WHERE id = ?").WithArgs(true, x).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for x := 0; x < workers; x++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+
+			var name string
+			if err := tx.QueryCache(&name, statement.Select().Columns("name").From("users").Where("id = ?", id)); err != nil {
+				errs <- err
+				return
+			}
+
+			if name != fmt.Sprintf("user%d", id) {
+				errs <- fmt.Errorf("unexpected name for id %d: %s", id, name)
+				return
+			}
+
+			if _, err := tx.Exec(statement.Update().Table("users").Set("seen", true).Where("id = ?", id)); err != nil {
+				errs <- err
+			}
+		}(x)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("error in concurrent transaction use: %s", err)
+	}
+
+	mock.ExpectCommit()
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing norm/database.DB transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryScanErrorLogged(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	var events []LogEvent
+	db, err := NewWithConfig(mdb, Config{EventLogger: func(e LogEvent) { events = append(events, e) }})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("abc"))
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("abc"))
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	query := statement.Select().Columns("id").From("users")
+
+	// malformed destinations
+	var ids []int
+	if err = tx.Query(&ids, query); err == nil {
+		t.Fatalf("expected error scanning string into int")
+	}
+
+	var id int
+	if err = tx.QueryRow(id, query); err == nil {
+		t.Fatalf("expected error scanning into non pointer")
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+
+	var scans []LogEvent
+	for _, e := range events {
+		if e.Op == "db.tx.query.scan" {
+			scans = append(scans, e)
+		}
+	}
+
+	if len(scans) != 2 {
+		t.Fatalf("expected 2 scan error events, got: %#v", events)
+	}
+
+	for _, e := range scans {
+		if e.Err == nil || e.TxID != "someid" || e.Query != "SELECT id FROM users" {
+			t.Fatalf("unexpected scan error event: %#v", e)
+		}
+	}
+}
+
+func TestTxQueryCacheDistinctQueries(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	queries := []struct {
+		stmt   statement.Statement
+		expect []string
+	}{
+		{stmt: statement.Select().Columns("name").From("users"), expect: []string{"john", "jane"}},
+		{stmt: statement.Select().Columns("name").From("roles"), expect: []string{"admin"}},
+		{stmt: statement.SQL("SELECT name FROM users WHERE id = ?", 1), expect: []string{"john"}},
+		{stmt: statement.SQL("SELECT name FROM users WHERE id = ?", "1"), expect: []string{"jane"}},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT name FROM users").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("john").AddRow("jane"))
+	mock.ExpectQuery("SELECT name FROM roles").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("admin"))
+	mock.ExpectQuery("SELECT name FROM users WHERE id = ?").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("john"))
+	mock.ExpectQuery("SELECT name FROM users WHERE id = ?").WithArgs("1").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("jane"))
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	// the first round populates the cache and the second is served from it
+	for round := 0; round < 2; round++ {
+		for _, q := range queries {
+			var names []string
+			if err = tx.QueryCache(&names, q.stmt); err != nil {
+				t.Fatalf("error performing norm/database.DB query: %s", err)
+			}
+
+			if !reflect.DeepEqual(q.expect, names) {
+				t.Fatalf("round %d: expected %#v, got %#v", round, q.expect, names)
+			}
+		}
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+
+	all, _ := cacheKey("SELECT 1", nil, queryAll, reflect.TypeOf(&[]int{}))
+	row, _ := cacheKey("SELECT 1", nil, queryRow, reflect.TypeOf(&[]int{}))
+	if all == row {
+		t.Fatalf("expected distinct cache keys for different query modes")
+	}
+}
+
+func TestTxQueryCachePointerArgs(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT name FROM users WHERE id = ?").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("john"))
+	mock.ExpectQuery("SELECT name FROM users WHERE id = ?").WithArgs(2).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("jane"))
+	mock.ExpectQuery("SELECT name FROM users WHERE score = ?").WithArgs(1).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("john"))
+	mock.ExpectQuery("SELECT name FROM users WHERE score = ?").WithArgs(1.0).WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("jane"))
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	// the same pointer with a changed value is a different query, while the same value is cached
+	id := new(int)
+	for _, q := range []struct {
+		id     int
+		expect string
+	}{{1, "john"}, {2, "jane"}, {2, "jane"}} {
+		*id = q.id
+
+		var names []string
+		if err = tx.QueryCache(&names, statement.SQL("SELECT name FROM users WHERE id = ?", id)); err != nil {
+			t.Fatalf("error performing norm/database.DB query: %s", err)
+		}
+
+		if len(names) != 1 || names[0] != q.expect {
+			t.Fatalf("expected %s for id %d, got %#v", q.expect, q.id, names)
+		}
+	}
+
+	// arguments of different types printed alike are different queries
+	for _, q := range []struct {
+		score  interface{}
+		expect string
+	}{{1, "john"}, {1.0, "jane"}, {1, "john"}} {
+		var names []string
+		if err = tx.QueryCache(&names, statement.SQL("SELECT name FROM users WHERE score = ?", q.score)); err != nil {
+			t.Fatalf("error performing norm/database.DB query: %s", err)
+		}
+
+		if len(names) != 1 || names[0] != q.expect {
+			t.Fatalf("expected %s for score %#v, got %#v", q.expect, q.score, names)
+		}
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+
+	// arguments that can't be converted to driver values are not cached
+	if _, ok := cacheKey("SELECT 1", []interface{}{struct{}{}}, queryAll, reflect.TypeOf(&[]int{})); ok {
+		t.Fatalf("expected no cache key for unsupported argument")
+	}
+}
+
+func TestDBExecQuery(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	update := statement.Update().Table("users").Set("active", false).Where("id = ?", 1)
+	query := statement.Select().Columns("name").From("users").Where("active = ?", true)
+
+	// committed on success
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET active = ? WHERE id = ?").WithArgs(false, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT name FROM users WHERE active = ?").WithArgs(true).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("john"))
+	mock.ExpectCommit()
+
+	r, err := db.Exec(context.Background(), "", update)
+	if err != nil {
+		t.Fatalf("error executing statement: %s", err)
+	}
+
+	if n, _ := r.RowsAffected(); n != 1 {
+		t.Fatalf("expected 1 row affected, got: %d", n)
+	}
+
+	var names []string
+	if err = db.Query(context.Background(), "", &names, query); err != nil {
+		t.Fatalf("error querying statement: %s", err)
+	}
+
+	if !reflect.DeepEqual([]string{"john"}, names) {
+		t.Fatalf("unexpected names: %#v", names)
+	}
+
+	// rolled back on error
+	errExec := fmt.Errorf("exec error")
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET active = ? WHERE id = ?").WithArgs(false, 1).WillReturnError(errExec)
+	mock.ExpectRollback()
+
+	if _, err = db.Exec(context.Background(), "", update); !errors.Is(err, errExec) {
+		t.Fatalf("expected exec error, got: %v", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestGetSelect(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	columns := []string{"id", "name"}
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT id,name FROM users WHERE id = ?").WithArgs("123abc").WillReturnRows(
+		sqlmock.NewRows(columns).AddRow("123abc", "john doe"),
+	)
+	mock.ExpectQuery("SELECT id,name FROM users WHERE id = ?").WithArgs("none").WillReturnRows(
+		sqlmock.NewRows(columns),
+	)
+	mock.ExpectQuery("SELECT id,name FROM users").WillReturnRows(
+		sqlmock.NewRows(columns).AddRow("123abc", "john doe").AddRow("123abcd", "jane doe"),
+	)
+	mock.ExpectQuery("SELECT name FROM users").WillReturnRows(
+		sqlmock.NewRows([]string{"name"}).AddRow("john doe").AddRow("jane doe"),
+	)
+	mock.ExpectRollback()
+
+	tx, err := db.Read(context.Background(), "someid")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	type user struct {
+		ID   string
+		Name string
+	}
+
+	u, err := Get[user](tx, statement.Select().Columns("id", "name").From("users").Where("id = ?", "123abc"))
+	if err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if u.ID != "123abc" || u.Name != "john doe" {
+		t.Fatalf("unexpected result: %#v", u)
+	}
+
+	if _, err = Get[*user](tx, statement.Select().Columns("id", "name").From("users").Where("id = ?", "none")); err != ErrNoRows {
+		t.Fatalf("expected ErrNoRows, got: %v", err)
+	}
+
+	users, err := Select[user](tx, statement.Select().Columns("id", "name").From("users"))
+	if err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if !reflect.DeepEqual([]user{{"123abc", "john doe"}, {"123abcd", "jane doe"}}, users) {
+		t.Fatalf("unexpected result: %#v", users)
+	}
+
+	names, err := Select[string](tx, statement.Select().Columns("name").From("users"))
+	if err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if !reflect.DeepEqual([]string{"john doe", "jane doe"}, names) {
+		t.Fatalf("unexpected result: %#v", names)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQuoting(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.Postgres, Quoting: true})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE orders SET "order" = $1 WHERE id = $2`).WithArgs(2, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, err = db.Exec(context.Background(), "", statement.Update().Table("orders").Set("order", 2).Where("id = ?", 1)); err != nil {
+		t.Fatalf("error executing statement: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestUnderlying(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := New(mdb, sql.LevelSerializable, DefaultLogger)
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	if db.Underlying() != mdb {
+		t.Fatalf("expected the underlying *sql.DB")
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("CALL refresh_totals(?)").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	err = db.WithUpdate(context.Background(), "", func(tx *Tx) error {
+		_, err := tx.Underlying().ExecContext(context.Background(), "CALL refresh_totals(?)", 1)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("error executing on the underlying transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxCopyFrom(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	// the mock driver implements COPY through prepared statements as github.com/lib/pq
+	copyDrivers[driverPkg(mdb.Driver())] = true
+	defer delete(copyDrivers, driverPkg(mdb.Driver()))
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.Postgres})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	prepare := mock.ExpectPrepare(`COPY "public"."users" ("id", "name") FROM STDIN`)
+	prepare.ExpectExec().WithArgs(1, "john").WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare.ExpectExec().WithArgs(2, "jane").WillReturnResult(sqlmock.NewResult(0, 0))
+	prepare.ExpectExec().WithArgs().WillReturnResult(sqlmock.NewResult(0, 2))
+	prepare.WillBeClosed()
+	mock.ExpectPrepare(`COPY "users" ("id", "name") FROM STDIN`).WillBeClosed()
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	n, err := tx.CopyFrom("public.users", []string{"id", "name"}, [][]interface{}{{1, "john"}, {2, "jane"}})
+	if err != nil {
+		t.Fatalf("error copying rows: %s", err)
+	}
+
+	if n != 2 {
+		t.Fatalf("expected 2 rows copied, got: %d", n)
+	}
+
+	if _, err = tx.CopyFrom("users", []string{"id", "name"}, [][]interface{}{{1}}); !errors.Is(err, statement.ErrInvalidArgNumber) {
+		t.Fatalf("expected statement.ErrInvalidArgNumber, got: %v", err)
+	}
+
+	// incomplete copies fail before preparing the query
+	if _, err = tx.CopyFrom("users", nil, [][]interface{}{{1}}); !errors.Is(err, statement.ErrIncomplete) {
+		t.Fatalf("expected statement.ErrIncomplete, got: %v", err)
+	}
+
+	if _, err = tx.CopyFrom("", []string{"id"}, [][]interface{}{{1}}); !errors.Is(err, statement.ErrIncomplete) {
+		t.Fatalf("expected statement.ErrIncomplete, got: %v", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxCopyFromUnsupported(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.MySQL})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	if _, err = tx.CopyFrom("users", []string{"id"}, [][]interface{}{{1}}); !errors.Is(err, statement.ErrUnsupported) {
+		t.Fatalf("expected statement.ErrUnsupported, got: %v", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	// Postgres drivers other than github.com/lib/pq are not supported
+	db, err = NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.Postgres})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	if tx, err = db.Update(context.Background(), ""); err != nil {
+		t.Fatalf("error opening norm/database.DB transaction: %s", err)
+	}
+
+	if _, err = tx.CopyFrom("users", []string{"id"}, [][]interface{}{{1}}); !errors.Is(err, statement.ErrUnsupported) ||
+		!strings.Contains(err.Error(), "github.com/DATA-DOG/go-sqlmock") {
+		t.Fatalf("expected statement.ErrUnsupported for the driver, got: %v", err)
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxRequireWhere(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, RequireWhere: true})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	_, err = db.Exec(context.Background(), "", statement.Delete().From("users"))
+	if !errors.Is(err, statement.ErrMissingWhere) {
+		t.Fatalf("expected statement.ErrMissingWhere, got: %v", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxArgEncoders(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, ArgEncoders: []statement.ArgEncoder{statement.BoolInt}})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET active = ? WHERE id = ?").WithArgs(0, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if _, err = db.Exec(context.Background(), "", statement.Update().Table("users").Set("active", false).Where("id = ?", 1)); err != nil {
+		t.Fatalf("error executing statement: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestSharedCache(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, SharedCache: SharedCachePolicy{TTL: time.Minute}})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	query := statement.Select().Columns("code", "name").From("countries").Where("code = ?", "BR")
+	columns := []string{"code", "name"}
+
+	type country struct {
+		Code string
+		Name string
+	}
+
+	// the first read transaction loads the results, the second is served from the shared cache
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT code,name FROM countries WHERE code = ?").WithArgs("BR").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("BR", "Brazil"))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	// write transactions don't use the shared cache
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT code,name FROM countries WHERE code = ?").WithArgs("BR").
+		WillReturnRows(sqlmock.NewRows(columns).AddRow("BR", "Brasil"))
+	mock.ExpectCommit()
+
+	for x := 0; x < 2; x++ {
+		var c []country
+		err = db.WithRead(context.Background(), "", func(tx *Tx) error {
+			return tx.QueryCache(&c, query)
+		})
+		if err != nil {
+			t.Fatalf("error performing norm/database.DB query: %s", err)
+		}
+
+		if !reflect.DeepEqual([]country{{"BR", "Brazil"}}, c) {
+			t.Fatalf("unexpected result: %#v", c)
+		}
+
+		// modifying the results must not affect the cached values
+		c[0].Name = "changed"
+	}
+
+	var c []country
+	err = db.WithUpdate(context.Background(), "", func(tx *Tx) error {
+		return tx.QueryCache(&c, query)
+	})
+	if err != nil {
+		t.Fatalf("error performing norm/database.DB query: %s", err)
+	}
+
+	if !reflect.DeepEqual([]country{{"BR", "Brasil"}}, c) {
+		t.Fatalf("unexpected result: %#v", c)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Now()
+	s := newMemoryStore()
+	s.nowFunc = func() time.Time { return now }
+
+	s.Set("a", 1, time.Second)
+
+	if v, ok := s.Get("a"); !ok || v != 1 {
+		t.Fatalf("expected cached value, got: %v, %t", v, ok)
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := s.Get("a"); ok {
+		t.Fatalf("expected expired value")
+	}
+
+	if len(s.items) != 0 {
+		t.Fatalf("expected expired entry to be removed, got: %d entries", len(s.items))
+	}
+
+	// expired entries are swept when adding entries
+	for x := 0; x < sweepInterval; x++ {
+		s.Set(strconv.Itoa(x), x, time.Second)
+		now = now.Add(time.Millisecond)
+	}
+
+	if len(s.items) >= sweepInterval {
+		t.Fatalf("expected expired entries to be removed, got: %d entries", len(s.items))
+	}
+}
+
+func TestTxLogInterpolated(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	var queries []string
+	logger := func(e LogEvent) {
+		if e.Query != "" {
+			queries = append(queries, e.Query)
+		}
+	}
+
+	db, err := NewWithConfig(mdb, Config{EventLogger: logger, Dialect: statement.Postgres, LogInterpolated: true})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	type user struct {
+		ID   int
+		Name string
+	}
+
+	// statements are executed parameterized and logged interpolated
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET name = $1 WHERE id = $2").WithArgs("O'Brien", 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id,name FROM users WHERE id = $1").WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "O'Brien"))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error starting transaction: %s", err)
+	}
+
+	if _, err = tx.Exec(statement.Update().Table("users").Set("name", "O'Brien").Where("id = ?", 1)); err != nil {
+		t.Fatalf("error executing statement: %s", err)
+	}
+
+	var u user
+	if err = tx.QueryRow(&u, statement.Select().Columns("id", "name").From("users").Where("id = ?", 1)); err != nil {
+		t.Fatalf("error querying statement: %s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing transaction: %s", err)
+	}
+
+	expect := []string{
+		"UPDATE users SET name = 'O''Brien' WHERE id = 1",
+		"SELECT id,name FROM users WHERE id = 1",
+	}
+
+	if !reflect.DeepEqual(expect, queries) {
+		t.Fatalf("expected logged queries: %#v, got: %#v", expect, queries)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestSlowLogger(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	var slowOps []string
+	db, err := NewWithConfig(mdb, Config{
+		SlowThreshold: 20 * time.Millisecond,
+		SlowLogger:    func(e LogEvent) { slowOps = append(slowOps, e.Op+" "+e.Query) },
+	})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	// only the delayed statements are logged as slow
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET active = ? WHERE id = ?").WithArgs(false, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE users SET active = ? WHERE id = ?").WithArgs(false, 2).WillDelayFor(50 * time.Millisecond).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id FROM users").WillDelayFor(50 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	err = db.WithUpdate(context.Background(), "", func(tx *Tx) (err error) {
+		for _, id := range []int{1, 2} {
+			if _, err = tx.Exec(statement.Update().Table("users").Set("active", false).Where("id = ?", id)); err != nil {
+				return err
+			}
+		}
+
+		var ids []int
+		return tx.Query(&ids, statement.Select().Columns("id").From("users"))
+	})
+	if err != nil {
+		t.Fatalf("error executing transaction: %s", err)
+	}
+
+	expect := []string{
+		"db.tx.exec.slow UPDATE users SET active = ? WHERE id = ?",
+		"db.tx.query.slow SELECT id FROM users",
+	}
+
+	if !reflect.DeepEqual(expect, slowOps) {
+		t.Fatalf("expected slow operations: %#v, got: %#v", expect, slowOps)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+
+	if _, err = NewWithConfig(mdb, Config{SlowThreshold: -1}); err == nil {
+		t.Fatalf("expected error for negative slow threshold")
+	}
+}
+
+func TestCaptureCaller(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	callers := map[string]string{}
+	db, err := NewWithConfig(mdb, Config{
+		CaptureCaller: true,
+		SlowThreshold: time.Nanosecond,
+		EventLogger:   func(e LogEvent) { callers[e.Op] = e.Caller },
+	})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET active = ? WHERE id = ?").WithArgs(false, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	_, file, line, _ := runtime.Caller(0)
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error starting transaction: %s", err)
+	}
+
+	if _, err = tx.Exec(statement.Update().Table("users").Set("active", false).Where("id = ?", 1)); err != nil {
+		t.Fatalf("error executing statement: %s", err)
+	}
+
+	var ids []int
+	if err = tx.Query(&ids, statement.Select().Columns("id").From("users")); err != nil {
+		t.Fatalf("error executing query: %s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing transaction: %s", err)
+	}
+
+	expect := map[string]string{
+		"db.begin":         fmt.Sprintf("%s:%d", file, line+1),
+		"db.tx.exec":       fmt.Sprintf("%s:%d", file, line+6),
+		"db.tx.exec.slow":  fmt.Sprintf("%s:%d", file, line+6),
+		"db.tx.query":      fmt.Sprintf("%s:%d", file, line+11),
+		"db.tx.query.slow": fmt.Sprintf("%s:%d", file, line+11),
+		"db.tx.commit":     fmt.Sprintf("%s:%d", file, line+15),
+	}
+
+	if !reflect.DeepEqual(expect, callers) {
+		t.Fatalf("expected callers: %#v, got: %#v", expect, callers)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxExecAffectedInsertID(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.MySQL})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users(name) VALUES (?)").WithArgs("john").WillReturnResult(sqlmock.NewResult(42, 1))
+	mock.ExpectExec("UPDATE users SET active = ? WHERE active = ?").WithArgs(false, true).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("DELETE FROM users WHERE id = ?").WithArgs(1).
+		WillReturnResult(sqlmock.NewErrorResult(errors.New("not supported")))
+	mock.ExpectRollback()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error starting transaction: %s", err)
+	}
+
+	id, err := tx.ExecInsertID(statement.Insert().Into("users").Columns("name").Values("john"))
+	if err != nil || id != 42 {
+		t.Fatalf("expected id 42, got: %d, err: %v", id, err)
+	}
+
+	affected, err := tx.ExecAffected(statement.Update().Table("users").Set("active", false).Where("active = ?", true))
+	if err != nil || affected != 3 {
+		t.Fatalf("expected 3 rows affected, got: %d, err: %v", affected, err)
+	}
+
+	if _, err = tx.ExecAffected(statement.Delete().From("users").Where("id = ?", 1)); err == nil {
+		t.Fatalf("expected error for unsupported rows affected")
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+
+	// postgres does not report the last insert id
+	pdb, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.Postgres})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	ptx, err := pdb.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error starting transaction: %s", err)
+	}
+
+	if _, err = ptx.ExecInsertID(statement.Insert().Into("users").Columns("name").Values("john")); !errors.Is(err, statement.ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got: %v", err)
+	}
+
+	if err = ptx.Rollback(); err != nil {
+		t.Fatalf("error rolling back transaction: %s", err)
+	}
+}
+
+func TestTxStats(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	for id, affected := range []int64{2, 3, 5} {
+		mock.ExpectExec("UPDATE users SET active = ? WHERE group_id = ?").WithArgs(false, id).
+			WillReturnResult(sqlmock.NewResult(0, affected))
+	}
+	mock.ExpectQuery("SELECT id FROM users").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error starting transaction: %s", err)
+	}
+
+	for id := 0; id < 3; id++ {
+		if _, err = tx.Exec(statement.Update().Table("users").Set("active", false).Where("group_id = ?", id)); err != nil {
+			t.Fatalf("error executing statement: %s", err)
+		}
+	}
+
+	var ids []int
+	if err = tx.Query(&ids, statement.Select().Columns("id").From("users")); err != nil {
+		t.Fatalf("error querying statement: %s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing transaction: %s", err)
+	}
+
+	expect := TxStats{Execs: 3, RowsAffected: 10, Queries: 1, RowsReturned: 2}
+	if stats := tx.Stats(); expect != stats {
+		t.Fatalf("expected stats: %+v, got: %+v", expect, stats)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxRollbackAfterCommit(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	t.Run("committed", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		tx, err := db.Update(context.Background(), "")
+		if err != nil {
+			t.Fatalf("error starting transaction: %s", err)
+		}
+
+		if err = tx.Commit(); err != nil {
+			t.Fatalf("error committing transaction: %s", err)
+		}
+
+		// no rollback is expected by the mock
+		if err = tx.Rollback(); err != nil {
+			t.Fatalf("expected rollback after commit to do nothing, got: %s", err)
+		}
+	})
+
+	t.Run("commit_error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectCommit().WillReturnError(errors.New("serialization failure"))
+
+		tx, err := db.Update(context.Background(), "")
+		if err != nil {
+			t.Fatalf("error starting transaction: %s", err)
+		}
+
+		if err = tx.Commit(); err == nil {
+			t.Fatalf("expected commit error")
+		}
+
+		// the failed commit finished the transaction
+		if err = tx.Rollback(); err != nil {
+			t.Fatalf("expected rollback after failed commit to succeed, got: %s", err)
+		}
+	})
+
+	t.Run("context_done", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		tx, err := db.Update(ctx, "")
+		if err != nil {
+			t.Fatalf("error starting transaction: %s", err)
+		}
+
+		cancel()
+		if err = tx.Commit(); !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got: %v", err)
+		}
+
+		// the transaction left open by the failed commit is rolled back
+		if err = tx.Rollback(); err != nil {
+			t.Fatalf("expected rollback after failed commit to succeed, got: %s", err)
+		}
+	})
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxRollbackOnCancel(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	rolledBack := make(chan error, 1)
+	db, err := NewWithConfig(mdb, Config{RollbackOnCancel: true, EventLogger: func(e LogEvent) {
+		if e.Op == "db.tx.rollback.cancel" {
+			rolledBack <- e.Err
+		}
+	}})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET active = ? WHERE id = ?").WithArgs(false, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	tx, err := db.Update(ctx, "")
+	if err != nil {
+		t.Fatalf("error starting transaction: %s", err)
+	}
+
+	if _, err = tx.Exec(statement.Update().Table("users").Set("active", false).Where("id = ?", 1)); err != nil {
+		t.Fatalf("error executing statement: %s", err)
+	}
+
+	cancel()
+
+	select {
+	case <-rolledBack:
+	case <-time.After(time.Second):
+		t.Fatalf("expected the transaction to be rolled back on cancel")
+	}
+
+	// the transaction is done and commit fails
+	if err = tx.Commit(); err == nil {
+		t.Fatalf("expected commit of a rolled back transaction to fail")
+	}
+
+	if err = tx.Rollback(); err != nil {
+		t.Fatalf("expected rollback of a rolled back transaction to do nothing, got: %s", err)
+	}
+
+	// database/sql may be rolling back the transaction concurrently
+	for x := 0; x < 100; x++ {
+		if err = mock.ExpectationsWereMet(); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+
+	// transactions finished before the context is done are not rolled back, the connection
+	// of the rolled back transaction may still be in use by database/sql so a new one is used
+	mdb, mock, err = sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db.db = mdb
+	mock.ExpectBegin()
+	mock.ExpectCommit()
+
+	ctx, cancel = context.WithCancel(context.Background())
+	if tx, err = db.Update(ctx, ""); err != nil {
+		t.Fatalf("error starting transaction: %s", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing transaction: %s", err)
+	}
+	cancel()
+
+	select {
+	case <-rolledBack:
+		t.Fatalf("unexpected rollback of a committed transaction")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestTxQueryMulti(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	type user struct {
+		ID   int
+		Name string
+	}
+
+	type order struct {
+		ID     int
+		UserID int
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("CALL user_orders(?)").WithArgs(1).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow(1, "john"),
+		sqlmock.NewRows([]string{"id", "user_id"}).AddRow(10, 1).AddRow(11, 1))
+	mock.ExpectQuery("CALL user_orders(?)").WithArgs(2).WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name"}).AddRow(2, "jane"))
+	mock.ExpectCommit()
+
+	err = db.WithRead(context.Background(), "", func(tx *Tx) (err error) {
+		var users []user
+		var orders []order
+		if err = tx.QueryMulti(statement.SQL("CALL user_orders(?)", 1), &users, &orders); err != nil {
+			return err
+		}
+
+		if expect := []user{{ID: 1, Name: "john"}}; !reflect.DeepEqual(expect, users) {
+			t.Fatalf("expected users: %#v, got: %#v", expect, users)
+		}
+
+		if expect := []order{{ID: 10, UserID: 1}, {ID: 11, UserID: 1}}; !reflect.DeepEqual(expect, orders) {
+			t.Fatalf("expected orders: %#v, got: %#v", expect, orders)
+		}
+
+		// a missing result set is an error
+		users, orders = nil, nil
+		if err = tx.QueryMulti(statement.SQL("CALL user_orders(?)", 2), &users, &orders); err == nil {
+			t.Fatalf("expected error for missing result set")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error executing transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
+
+func TestArgRedaction(t *testing.T) {
+	email, token := "john.doe@email.com", "secret-token"
+
+	cases := []struct {
+		name      string
+		redaction ArgRedaction
+		expect    []interface{}
+	}{
+		{name: "omit", redaction: RedactOmit, expect: nil},
+		{name: "hash", redaction: RedactHash, expect: []interface{}{
+			RedactHash.redact([]interface{}{token})[0], RedactHash.redact([]interface{}{email})[0]}},
+		{name: "type", redaction: RedactType, expect: []interface{}{"string(12)", "string(18)"}},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("error opening mock database: %s", err)
+			}
+			defer mdb.Close()
+
+			var events []LogEvent
+			db, err := NewWithConfig(mdb, Config{
+				ArgRedaction:    tt.redaction,
+				LogInterpolated: true,
+				EventLogger:     func(e LogEvent) { events = append(events, e) },
+			})
+			if err != nil {
+				t.Fatalf("error opening norm/database.DB: %s", err)
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectExec("UPDATE users SET token = ? WHERE email = ?").WithArgs(token, email).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectPrepare("SELECT id FROM users WHERE email = ? AND token = ?").
+				ExpectQuery().WithArgs(email, token).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+			mock.ExpectCommit()
+
+			err = db.WithUpdate(context.Background(), "", func(tx *Tx) (err error) {
+				if _, err = tx.Exec(statement.Update().Table("users").Set("token", token).Where("email = ?", email)); err != nil {
+					return err
+				}
+
+				stmt, err := tx.Prepare("SELECT id FROM users WHERE email = ? AND token = ?")
+				if err != nil {
+					return err
+				}
+
+				var ids []int
+				return stmt.Query(&ids, email, token)
+			})
+			if err != nil {
+				t.Fatalf("error executing transaction: %s", err)
+			}
+
+			var execs int
+			for _, e := range events {
+				logged := fmt.Sprintf("%+v", e)
+				if strings.Contains(logged, email) || strings.Contains(logged, token) {
+					t.Fatalf("raw argument logged: %s", logged)
+				}
+
+				if e.Op == "db.tx.exec" {
+					execs++
+					if !reflect.DeepEqual(tt.expect, e.Args) {
+						t.Fatalf("expected args: %#v, got: %#v", tt.expect, e.Args)
+					}
+				}
+			}
+
+			if execs != 1 {
+				t.Fatalf("expected 1 exec event, got: %d", execs)
+			}
+
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("mock expectations failed: %s", err)
+			}
+		})
+	}
+}
+
+func TestOperation(t *testing.T) {
+	cases := map[string]string{
+		"SELECT id FROM users":                       "SELECT",
+		"-- request id: 1\nUPDATE users SET a = 1":   "UPDATE",
+		"/*action='list'*/\nSELECT id FROM users":    "SELECT",
+		"-- c\n/*action='list'*/\nDELETE FROM users": "DELETE",
+		"EXPLAIN /*action='list'*/\nSELECT 1":        "EXPLAIN",
+		"/* unterminated":                            "",
+	}
+
+	for query, expect := range cases {
+		if op := operation(query); expect != op {
+			t.Fatalf("expected operation: %q for %q, got: %q", expect, query, op)
+		}
+	}
+}
+
+func TestTxCallbacks(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	register := func(tx *Tx, calls *[]string) {
+		tx.OnCommit(func() { *calls = append(*calls, "commit1") })
+		tx.OnCommit(func() { panic("callback failure") })
+		tx.OnCommit(func() { *calls = append(*calls, "commit2") })
+		tx.OnRollback(func() { *calls = append(*calls, "rollback1") })
+		tx.OnRollback(func() { *calls = append(*calls, "rollback2") })
+	}
+
+	t.Run("commit", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectCommit()
+
+		tx, err := db.Update(context.Background(), "")
+		if err != nil {
+			t.Fatalf("error starting transaction: %s", err)
+		}
+
+		var calls []string
+		register(tx, &calls)
+
+		if err = tx.Commit(); err != nil {
+			t.Fatalf("error committing transaction: %s", err)
+		}
+
+		if err = tx.Rollback(); err != nil {
+			t.Fatalf("error rolling back transaction: %s", err)
+		}
+
+		if !reflect.DeepEqual(calls, []string{"commit1", "commit2"}) {
+			t.Fatalf("expected commit callbacks, got: %v", calls)
+		}
+	})
+
+	t.Run("rollback", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectRollback()
+
+		tx, err := db.Update(context.Background(), "")
+		if err != nil {
+			t.Fatalf("error starting transaction: %s", err)
+		}
+
+		var calls []string
+		register(tx, &calls)
+
+		if err = tx.Rollback(); err != nil {
+			t.Fatalf("error rolling back transaction: %s", err)
+		}
+
+		if !reflect.DeepEqual(calls, []string{"rollback1", "rollback2"}) {
+			t.Fatalf("expected rollback callbacks, got: %v", calls)
+		}
+	})
+
+	t.Run("commit_error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectCommit().WillReturnError(errors.New("serialization failure"))
+
+		tx, err := db.Update(context.Background(), "")
+		if err != nil {
+			t.Fatalf("error starting transaction: %s", err)
+		}
+
+		var calls []string
+		register(tx, &calls)
+
+		if err = tx.Commit(); err == nil {
+			t.Fatalf("expected commit error")
+		}
+
+		if len(calls) != 0 {
+			t.Fatalf("expected no callbacks after failed commit, got: %v", calls)
+		}
+
+		if err = tx.Rollback(); err != nil {
+			t.Fatalf("error rolling back transaction: %s", err)
+		}
+
+		if !reflect.DeepEqual(calls, []string{"rollback1", "rollback2"}) {
+			t.Fatalf("expected rollback callbacks, got: %v", calls)
+		}
+	})
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %s", err)
+	}
+}
+
+func TestTxEnqueue(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, BatchSize: 2})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET name = ? WHERE id = ?").WithArgs("a", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM sessions WHERE user_id = ?").WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec("UPDATE users SET name = ? WHERE id = ?").WithArgs("b", 2).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	tx, err := db.Update(context.Background(), "")
+	if err != nil {
+		t.Fatalf("error starting transaction: %s", err)
+	}
+
+	if err = tx.Enqueue(statement.Update().Table("users").Set("name", "a").Where("id = ?", 1)); err != nil {
+		t.Fatalf("error queueing statement: %s", err)
+	}
+
+	if tx.Stats().Execs != 0 {
+		t.Fatalf("expected no statements executed before the batch is full")
+	}
+
+	// the batch is full and flushed automatically
+	if err = tx.Enqueue(statement.Delete().From("sessions").Where("user_id = ?", 1)); err != nil {
+		t.Fatalf("error queueing statement: %s", err)
+	}
+
+	if s := tx.Stats(); s.Execs != 2 || s.RowsAffected != 4 {
+		t.Fatalf("expected 2 statements executed, got: %#v", s)
+	}
+
+	if err = tx.Enqueue(statement.Update().Table("users").Set("name", "b").Where("id = ?", 2)); err != nil {
+		t.Fatalf("error queueing statement: %s", err)
+	}
+
+	// commit flushes the remaining statement
+	if err = tx.Commit(); err != nil {
+		t.Fatalf("error committing transaction: %s", err)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %s", err)
+	}
+
+	t.Run("flush_error", func(t *testing.T) {
+		failure := errors.New("constraint violation")
+		mock.ExpectBegin()
+		mock.ExpectExec("DELETE FROM users WHERE id = ?").WithArgs(1).WillReturnError(failure)
+		mock.ExpectRollback()
+
+		tx, err := db.Update(context.Background(), "")
+		if err != nil {
+			t.Fatalf("error starting transaction: %s", err)
+		}
+
+		_ = tx.Enqueue(statement.Delete().From("users").Where("id = ?", 1))
+
+		// the failed flush prevents the commit
+		if err = tx.Commit(); !errors.Is(err, failure) {
+			t.Fatalf("expected flush error, got: %v", err)
+		}
+
+		if err = tx.Rollback(); err != nil {
+			t.Fatalf("error rolling back transaction: %s", err)
+		}
+
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("unmet expectations: %s", err)
+		}
+	})
+}
+
+// locationArg matches time arguments bound in the given location.
+type locationArg struct {
+	t time.Time
+}
+
+func (a locationArg) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	return ok && t.Equal(a.t) && t.Location() == a.t.Location()
+}
+
+func TestLocation(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	loc := time.FixedZone("BRT", -3*3600)
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Location: loc})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	created := time.Date(2024, 3, 10, 12, 30, 0, 0, time.UTC)
+	local := created.In(loc)
+
+	type event struct {
+		ID        int64        `db:"id"`
+		CreatedAt time.Time    `db:"created_at"`
+		UpdatedAt *time.Time   `db:"updated_at"`
+		DeletedAt sql.NullTime `db:"deleted_at"`
+	}
+
+	cases := []struct {
+		name   string
+		stored time.Time
+	}{
+		// drivers storing times without time zones, like MySQL DATETIME columns, scan the wall clock
+		// time of the bound argument in UTC
+		{name: "naive", stored: time.Date(2024, 3, 10, 9, 30, 0, 0, time.UTC)},
+		// drivers storing times with time zones scan the same instant in the session time zone
+		{name: "zoned", stored: created.In(time.FixedZone("CET", 3600))},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectExec("INSERT INTO events(created_at,id) VALUES (?,?)").
+				WithArgs(locationArg{local}, 1).WillReturnResult(sqlmock.NewResult(1, 1))
+			mock.ExpectQuery("SELECT * FROM events WHERE id = ?").WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"id", "created_at", "updated_at", "deleted_at"}).
+					AddRow(1, tt.stored, tt.stored, tt.stored))
+			mock.ExpectQuery("SELECT created_at FROM events WHERE id = ?").WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(tt.stored))
+			mock.ExpectCommit()
+
+			tx, err := db.Update(context.Background(), "")
+			if err != nil {
+				t.Fatalf("error starting transaction: %s", err)
+			}
+
+			if _, err = tx.Exec(statement.Insert().Into("events").Columns("created_at", "id").Values(created, 1)); err != nil {
+				t.Fatalf("error executing statement: %s", err)
+			}
+
+			var e event
+			if err = tx.QueryRow(&e, statement.Select().Columns("*").From("events").Where("id = ?", 1)); err != nil {
+				t.Fatalf("error querying events: %s", err)
+			}
+
+			for _, scanned := range []time.Time{e.CreatedAt, *e.UpdatedAt, e.DeletedAt.Time} {
+				if !scanned.Equal(created) || scanned.Location() != loc {
+					t.Fatalf("expected %s, got: %s", local, scanned)
+				}
+			}
+
+			var m []map[string]interface{}
+			if err = tx.Query(&m, statement.Select().Columns("created_at").From("events").Where("id = ?", 1)); err != nil {
+				t.Fatalf("error querying events: %s", err)
+			}
+
+			if scanned := m[0]["created_at"].(time.Time); !scanned.Equal(created) || scanned.Location() != loc {
+				t.Fatalf("expected %s, got: %s", local, scanned)
+			}
+
+			if err = tx.Commit(); err != nil {
+				t.Fatalf("error committing transaction: %s", err)
+			}
+
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("unmet expectations: %s", err)
+			}
+		})
+	}
+}
+
+// countingDriver is a driver.Connector counting the prepared statements, which take delay to prepare,
+// and the closed statements. Queries return a single row with an id column.
+type countingDriver struct {
+	delay    time.Duration
+	prepares int64
+	closes   int64
+}
+
+func (d *countingDriver) Connect(context.Context) (driver.Conn, error) { return &countingConn{d}, nil }
+func (d *countingDriver) Driver() driver.Driver                        { return nil }
+
+type countingConn struct{ d *countingDriver }
+
+func (c *countingConn) Prepare(query string) (driver.Stmt, error) {
+	atomic.AddInt64(&c.d.prepares, 1)
+	time.Sleep(c.d.delay)
+	return &countingStmt{c.d}, nil
+}
+func (c *countingConn) Close() error              { return nil }
+func (c *countingConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *countingConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	return c, nil
+}
+func (c *countingConn) Commit() error   { return nil }
+func (c *countingConn) Rollback() error { return nil }
+
+type countingStmt struct{ d *countingDriver }
+
+func (s *countingStmt) Close() error  { atomic.AddInt64(&s.d.closes, 1); return nil }
+func (s *countingStmt) NumInput() int { return -1 }
+func (s *countingStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+func (s *countingStmt) Query(args []driver.Value) (driver.Rows, error) { return &countingRows{}, nil }
+
+type countingRows struct{ done bool }
+
+func (r *countingRows) Columns() []string { return []string{"id"} }
+func (r *countingRows) Close() error      { return nil }
+func (r *countingRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = int64(1)
+	return nil
+}
+
+func TestStmtCache(t *testing.T) {
+	d := &countingDriver{}
+	db, err := NewWithConfig(sql.OpenDB(d), Config{Logger: DefaultLogger, StmtCache: 1})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	users := statement.Select().Columns("id").From("users").Where("id = ?", 1)
+	orders := statement.Select().Columns("id").From("orders").Where("id = ?", 1)
+
+	for x := 0; x < 10; x++ {
+		err = db.WithRead(context.Background(), "", func(tx *Tx) (err error) {
+			var id int64
+			return tx.QueryRow(&id, users)
+		})
+		if err != nil {
+			t.Fatalf("error querying users: %s", err)
+		}
+	}
+
+	// the statement is prepared once on the database and once on the connection of the
+	// transactions, if different, instead of once per transaction
+	if n := atomic.LoadInt64(&d.prepares); n > 2 {
+		t.Fatalf("expected at most 2 prepares, got: %d", n)
+	}
+
+	// the users statement is evicted while in use, and closed after the transaction is done
+	err = db.WithRead(context.Background(), "", func(tx *Tx) (err error) {
+		var id int64
+		if err = tx.QueryRow(&id, users); err != nil {
+			return err
+		}
+
+		if err = tx.QueryRow(&id, orders); err != nil {
+			return err
+		}
+
+		if n := atomic.LoadInt64(&d.closes); n != 0 {
+			t.Fatalf("expected statements in use to be open, got %d closed", n)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("error querying users and orders: %s", err)
+	}
+
+	if n := atomic.LoadInt64(&d.closes); n == 0 {
+		t.Fatalf("expected the evicted statement to be closed")
+	}
+
+	if err = db.Close(); err != nil {
+		t.Fatalf("error closing norm/database.DB: %s", err)
+	}
+
+	if prepares, closes := atomic.LoadInt64(&d.prepares), atomic.LoadInt64(&d.closes); prepares != closes {
+		t.Fatalf("expected all %d prepared statements to be closed, got: %d", prepares, closes)
+	}
+}
+
+func BenchmarkStmtCache(b *testing.B) {
+	for _, bb := range []struct {
+		name   string
+		config Config
+	}{
+		{name: "stmt_cache", config: Config{StmtCache: 16}},
+		{name: "prepare_cache", config: Config{PrepareCache: true}},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			d := &countingDriver{delay: 100 * time.Microsecond}
+			db, err := NewWithConfig(sql.OpenDB(d), bb.config)
+			if err != nil {
+				b.Fatalf("error opening norm/database.DB: %s", err)
+			}
+			defer db.Close()
+
+			stmt := statement.Select().Columns("id").From("users").Where("id = ?", 1)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+
+			for x := 0; x < b.N; x++ {
+				err = db.WithRead(context.Background(), "", func(tx *Tx) (err error) {
+					var id int64
+					return tx.QueryRow(&id, stmt)
+				})
+				if err != nil {
+					b.Fatalf("error querying norm/database.DB: %s", err)
+				}
+			}
+
+			b.ReportMetric(float64(atomic.LoadInt64(&d.prepares))/float64(b.N), "prepares/op")
+		})
+	}
+}
+
+// MySQLError mirrors the github.com/go-sql-driver/mysql error type.
+type MySQLError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *MySQLError) Error() string { return fmt.Sprintf("Error %d: %s", e.Number, e.Message) }
+
+func TestErrorClassification(t *testing.T) {
+	insert := statement.Insert().Into("users").Columns("id", "email").Values(1, "a@b.c")
+	query := "INSERT INTO users(id,email) VALUES (?,?)"
+
+	cases := []struct {
+		name        string
+		classifiers []ErrorClassifier
+		err         error
+		class       ErrorClass
+		code        string
+	}{
+		{name: "postgres_unique", err: sqlStateError("23505"), class: UniqueViolation, code: "23505"},
+		{name: "postgres_foreign_key", err: sqlStateError("23503"), class: ForeignKeyViolation, code: "23503"},
+		{name: "postgres_not_null", err: sqlStateError("23502"), class: NotNull, code: "23502"},
+		{name: "postgres_deadlock", err: sqlStateError("40P01"), class: Deadlock, code: "40P01"},
+		{name: "postgres_serialization", err: sqlStateError("40001"), class: Serialization, code: "40001"},
+		{name: "postgres_timeout", err: sqlStateError("57014"), class: Timeout, code: "57014"},
+		{name: "postgres_unknown", err: sqlStateError("42601")},
+		{name: "mysql_unique", err: &MySQLError{Number: 1062, Message: "Duplicate entry"}, class: UniqueViolation, code: "1062"},
+		{name: "mysql_wrapped", err: fmt.Errorf("driver: %w", &MySQLError{Number: 1452}), class: ForeignKeyViolation, code: "1452"},
+		{name: "mysql_lock_wait", err: &MySQLError{Number: 1205}, class: Timeout, code: "1205"},
+		{name: "deadline", err: context.DeadlineExceeded, class: Timeout},
+		{name: "unclassified", err: errors.New("connection refused")},
+		{
+			name: "custom",
+			classifiers: []ErrorClassifier{ErrorClassifierFunc(func(err error) (ErrorClass, string, bool) {
+				return UniqueViolation, "2627", err.Error() == "mssql: duplicate key"
+			})},
+			err:   errors.New("mssql: duplicate key"),
+			class: UniqueViolation,
+			code:  "2627",
+		},
+		{
+			name:        "custom_no_builtin",
+			classifiers: []ErrorClassifier{MySQLClassifier},
+			err:         sqlStateError("23505"),
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			if err != nil {
+				t.Fatalf("error opening mock database: %s", err)
+			}
+			defer mdb.Close()
+
+			db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, ErrorClassifiers: tt.classifiers})
+			if err != nil {
+				t.Fatalf("error opening norm/database.DB: %s", err)
+			}
+
+			mock.ExpectBegin()
+			mock.ExpectExec(query).WithArgs(1, "a@b.c").WillReturnError(tt.err)
+			mock.ExpectRollback()
+
+			err = db.WithUpdate(context.Background(), "", func(tx *Tx) error {
+				_, err := tx.Exec(insert)
+				return err
+			})
+
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("expected driver error to be wrapped, got: %v", err)
+			}
+
+			if class := ErrorClassOf(err); class != tt.class {
+				t.Fatalf("expected class: %q, got: %q", tt.class, class)
+			}
+
+			var e *Error
+			if ok := errors.As(err, &e); ok != (tt.class != "") {
+				t.Fatalf("expected *Error: %t, got: %v", tt.class != "", err)
+			}
+
+			if e != nil && (e.Code != tt.code || e.Error() != tt.err.Error()) {
+				t.Fatalf("expected code: %s, message: %s, got: %s, %s", tt.code, tt.err, e.Code, e)
+			}
+
+			if err = mock.ExpectationsWereMet(); err != nil {
+				t.Fatalf("mock expectations failed: %s", err)
+			}
+		})
+	}
+}
+
+// decimal is an exact decimal type implementing sql.Scanner and driver.Valuer,
+// as github.com/shopspring/decimal.
+type decimal struct {
+	big.Rat
+}
+
+func (d *decimal) Scan(src interface{}) error {
+	var text string
+	switch v := src.(type) {
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return fmt.Errorf("invalid decimal: %T", src)
+	}
+
+	if _, ok := d.SetString(text); !ok {
+		return fmt.Errorf("invalid decimal: %s", text)
+	}
+	return nil
+}
+
+func (d decimal) Value() (driver.Value, error) {
+	return d.FloatString(9), nil
+}
+
+func TestDecimalRoundTrip(t *testing.T) {
+	mdb, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer mdb.Close()
+
+	db, err := NewWithConfig(mdb, Config{Logger: DefaultLogger, Dialect: statement.Postgres,
+		ArgEncoders: []statement.ArgEncoder{statement.BigDecimal}})
+	if err != nil {
+		t.Fatalf("error opening norm/database.DB: %s", err)
+	}
+
+	// beyond the 15 to 17 significant digits of float64
+	const value = "12345678901234567890.123456789"
+
+	type entry struct {
+		Amount decimal
+		Total  big.Rat
+	}
+
+	var in entry
+	if err = in.Amount.Scan(value); err != nil {
+		t.Fatalf("error parsing decimal: %s", err)
+	}
+	in.Total.SetString(value)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO ledger(amount,total) VALUES ($1,$2)").WithArgs(value, value).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT amount,total FROM ledger").
+		WillReturnRows(sqlmock.NewRows([]string{"amount", "total"}).AddRow([]byte(value), []byte(value)))
+	mock.ExpectCommit()
+
+	var out entry
+	err = db.WithUpdate(context.Background(), "", func(tx *Tx) (err error) {
+		if _, err = tx.Exec(statement.Insert().Into("ledger").Columns("amount", "total").Values(in.Amount, &in.Total)); err != nil {
+			return err
+		}
+
+		return tx.QueryRow(&out, statement.Select().Columns("amount", "total").From("ledger"))
+	})
+	if err != nil {
+		t.Fatalf("error executing norm/database.DB transaction: %s", err)
+	}
+
+	if out.Amount.FloatString(9) != value || out.Total.FloatString(9) != value {
+		t.Fatalf("expected: %s, got: %s, %s", value, out.Amount.FloatString(9), out.Total.FloatString(9))
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("mock expectations failed: %s", err)
+	}
+}
@@ -0,0 +1,114 @@
+// Package dbtest provides a recording fake of the database.Querier and database.Execer interfaces
+// for unit testing code built on norm/database without a database.
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"github.com/brunotm/norm/statement"
+)
+
+// Call is a statement executed or queried through a Recorder.
+type Call struct {
+	// Op is the operation, either "exec" or "query".
+	Op string
+	// Query is the built parameterized query, or the interpolated query for statements
+	// that do not implement statement.Parameterized.
+	Query string
+	// Args are the query arguments.
+	Args []interface{}
+}
+
+// Recorder is a fake database.Querier and database.Execer that records the executed and queried statements.
+// Results are provided by ExecFunc and QueryFunc, which can populate dst and return errors to simulate
+// database failures. Without them, executions return a zero Result and queries leave dst unchanged.
+// It is safe for concurrent use.
+type Recorder struct {
+	// Dialect is the dialect used to build the statements.
+	Dialect statement.Dialect
+	// ExecFunc returns the result for an executed statement.
+	ExecFunc func(call Call) (r sql.Result, err error)
+	// QueryFunc populates dst for a queried statement.
+	QueryFunc func(call Call, dst interface{}) (err error)
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Result is a sql.Result with fixed values.
+type Result struct {
+	InsertID int64
+	Affected int64
+}
+
+// LastInsertId implements the sql.Result interface.
+func (r Result) LastInsertId() (int64, error) {
+	return r.InsertID, nil
+}
+
+// RowsAffected implements the sql.Result interface.
+func (r Result) RowsAffected() (int64, error) {
+	return r.Affected, nil
+}
+
+// ExecContext implements the database.Execer interface. Statements that fail to build are not recorded.
+func (r *Recorder) ExecContext(ctx context.Context, stmt statement.Statement) (res sql.Result, err error) {
+	call, err := r.record("exec", stmt)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.ExecFunc == nil {
+		return Result{}, nil
+	}
+
+	return r.ExecFunc(call)
+}
+
+// QueryContext implements the database.Querier interface. Statements that fail to build are not recorded.
+func (r *Recorder) QueryContext(ctx context.Context, dst interface{}, stmt statement.Statement) (err error) {
+	call, err := r.record("query", stmt)
+	if err != nil {
+		return err
+	}
+
+	if r.QueryFunc == nil {
+		return nil
+	}
+
+	return r.QueryFunc(call, dst)
+}
+
+// Calls returns the recorded calls in execution order.
+func (r *Recorder) Calls() (calls []Call) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append(calls, r.calls...)
+}
+
+// Reset removes the recorded calls.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = nil
+}
+
+func (r *Recorder) record(op string, stmt statement.Statement) (call Call, err error) {
+	call.Op = op
+	if s, ok := stmt.(statement.Parameterized); ok {
+		call.Query, call.Args, err = s.SQL(statement.WithDialect(r.Dialect))
+	} else {
+		call.Query, err = stmt.String()
+	}
+
+	if err != nil {
+		return Call{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+	return call, nil
+}
@@ -0,0 +1,81 @@
+package dbtest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/brunotm/norm/database"
+	"github.com/brunotm/norm/statement"
+)
+
+var _ database.QueryExecer = (*Recorder)(nil)
+
+type user struct {
+	ID   int64  `db:"id"`
+	Name string `db:"name"`
+}
+
+// rename is a service function under test using the database interfaces.
+func rename(ctx context.Context, db database.QueryExecer, id int64, name string) (u user, err error) {
+	if err = db.QueryContext(ctx, &u, statement.Select().Columns("id", "name").From("users").Where("id = ?", id)); err != nil {
+		return u, err
+	}
+
+	r, err := db.ExecContext(ctx, statement.Update().Table("users").Set("name", name).Where("id = ?", id))
+	if err != nil {
+		return u, err
+	}
+
+	if n, _ := r.RowsAffected(); n != 1 {
+		return u, sql.ErrNoRows
+	}
+
+	u.Name = name
+	return u, nil
+}
+
+func TestRecorder(t *testing.T) {
+	r := &Recorder{
+		Dialect: statement.Postgres,
+		QueryFunc: func(call Call, dst interface{}) error {
+			*dst.(*user) = user{ID: call.Args[0].(int64), Name: "old"}
+			return nil
+		},
+		ExecFunc: func(call Call) (sql.Result, error) {
+			return Result{Affected: 1}, nil
+		},
+	}
+
+	u, err := rename(context.Background(), r, 7, "new")
+	if err != nil {
+		t.Fatalf("error renaming user: %s", err)
+	}
+
+	if u != (user{ID: 7, Name: "new"}) {
+		t.Fatalf("unexpected user: %#v", u)
+	}
+
+	expect := []Call{
+		{Op: "query", Query: "SELECT id,name FROM users WHERE id = $1", Args: []interface{}{int64(7)}},
+		{Op: "exec", Query: "UPDATE users SET name = $1 WHERE id = $2", Args: []interface{}{"new", int64(7)}},
+	}
+
+	if calls := r.Calls(); !reflect.DeepEqual(expect, calls) {
+		t.Fatalf("expected calls: %#v, got: %#v", expect, calls)
+	}
+
+	r.Reset()
+	failure := errors.New("connection refused")
+	r.ExecFunc = func(call Call) (sql.Result, error) { return nil, failure }
+
+	if _, err = rename(context.Background(), r, 7, "new"); !errors.Is(err, failure) {
+		t.Fatalf("expected exec error, got: %v", err)
+	}
+
+	if n := len(r.Calls()); n != 2 {
+		t.Fatalf("expected 2 calls, got: %d", n)
+	}
+}
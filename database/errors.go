@@ -0,0 +1,172 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+)
+
+// ErrorClass is the classification of a database error.
+type ErrorClass string
+
+const (
+	// UniqueViolation is a unique or primary key constraint violation.
+	UniqueViolation ErrorClass = "unique_violation"
+	// ForeignKeyViolation is a foreign key constraint violation.
+	ForeignKeyViolation ErrorClass = "foreign_key_violation"
+	// NotNull is a not null constraint violation.
+	NotNull ErrorClass = "not_null_violation"
+	// Deadlock is a deadlock detected by the database.
+	Deadlock ErrorClass = "deadlock"
+	// Serialization is a serialization failure of a concurrent transaction.
+	Serialization ErrorClass = "serialization_failure"
+	// Timeout is a statement or lock timeout, or a context deadline exceeded.
+	Timeout ErrorClass = "timeout"
+)
+
+// Error is a driver error classified by an ErrorClassifier, returned by the transaction queries,
+// execs and commits. It can be matched with errors.As and unwraps to the driver error,
+// so its message is the one of the driver error.
+//
+//	var e *database.Error
+//	if errors.As(err, &e) && e.Class == database.UniqueViolation {
+//		// respond with a 409
+//	}
+type Error struct {
+	// Class is the error classification.
+	Class ErrorClass
+	// Code is the driver specific error code, as the SQLSTATE or the MySQL error number.
+	Code string
+	// Err is the driver error.
+	Err error
+}
+
+// Error returns the driver error message.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the driver error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ErrorClassOf returns the class of the given error, or an empty class if it is not a classified *Error.
+func ErrorClassOf(err error) (class ErrorClass) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Class
+	}
+	return ""
+}
+
+// ErrorClassifier classifies driver errors from their error codes.
+type ErrorClassifier interface {
+	// Classify returns the class and the driver specific code of the error,
+	// or false if the error is not recognized by the classifier.
+	Classify(err error) (class ErrorClass, code string, ok bool)
+}
+
+// ErrorClassifierFunc is an adapter to allow the use of ordinary functions as an ErrorClassifier.
+type ErrorClassifierFunc func(err error) (class ErrorClass, code string, ok bool)
+
+// Classify calls f(err).
+func (f ErrorClassifierFunc) Classify(err error) (class ErrorClass, code string, ok bool) {
+	return f(err)
+}
+
+var (
+	// PostgresClassifier classifies errors by their SQLSTATE for drivers whose errors expose
+	// a `SQLState() string` method, like github.com/lib/pq and github.com/jackc/pgx.
+	PostgresClassifier ErrorClassifier = ErrorClassifierFunc(classifyPostgres)
+
+	// MySQLClassifier classifies errors by their error number for github.com/go-sql-driver/mysql,
+	// whose *mysql.MySQLError is matched by its type name and `Number uint16` field, as to not
+	// depend on the driver.
+	MySQLClassifier ErrorClassifier = ErrorClassifierFunc(classifyMySQL)
+)
+
+// postgresClasses maps the Postgres SQLSTATE codes to error classes.
+var postgresClasses = map[string]ErrorClass{
+	"23505": UniqueViolation,
+	"23503": ForeignKeyViolation,
+	"23502": NotNull,
+	"40P01": Deadlock,
+	"40001": Serialization,
+	"57014": Timeout, // query_canceled, as by statement_timeout
+	"55P03": Timeout, // lock_not_available, as by lock_timeout
+}
+
+// mysqlClasses maps the MySQL error numbers to error classes.
+var mysqlClasses = map[uint16]ErrorClass{
+	1062: UniqueViolation, // ER_DUP_ENTRY
+	1586: UniqueViolation, // ER_DUP_ENTRY_WITH_KEY_NAME
+	1216: ForeignKeyViolation,
+	1217: ForeignKeyViolation,
+	1451: ForeignKeyViolation, // ER_ROW_IS_REFERENCED_2
+	1452: ForeignKeyViolation, // ER_NO_REFERENCED_ROW_2
+	1048: NotNull,             // ER_BAD_NULL_ERROR
+	1213: Deadlock,            // ER_LOCK_DEADLOCK
+	1205: Timeout,             // ER_LOCK_WAIT_TIMEOUT
+	3024: Timeout,             // ER_QUERY_TIMEOUT
+}
+
+func classifyPostgres(err error) (class ErrorClass, code string, ok bool) {
+	var e interface{ SQLState() string }
+	if !errors.As(err, &e) {
+		return "", "", false
+	}
+
+	code = e.SQLState()
+	class, ok = postgresClasses[code]
+	return class, code, ok
+}
+
+func classifyMySQL(err error) (class ErrorClass, code string, ok bool) {
+	for ; err != nil; err = errors.Unwrap(err) {
+		v := reflect.ValueOf(err)
+		if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct || v.Elem().Type().Name() != "MySQLError" {
+			continue
+		}
+
+		f := v.Elem().FieldByName("Number")
+		if !f.IsValid() || f.Kind() != reflect.Uint16 {
+			return "", "", false
+		}
+
+		n := uint16(f.Uint())
+		class, ok = mysqlClasses[n]
+		return class, strconv.Itoa(int(n)), ok
+	}
+
+	return "", "", false
+}
+
+// errorClassifiers classifies errors with the configured classifiers in order.
+type errorClassifiers []ErrorClassifier
+
+// wrap returns the given error as an *Error if it is classified, or the error itself otherwise.
+// Errors not recognized by the classifiers are classified as Timeout if the context deadline was exceeded.
+func (c errorClassifiers) wrap(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	var e *Error
+	if errors.As(err, &e) {
+		return err
+	}
+
+	for _, cl := range c {
+		if class, code, ok := cl.Classify(err); ok {
+			return &Error{Class: class, Code: code, Err: err}
+		}
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &Error{Class: Timeout, Err: err}
+	}
+
+	return err
+}
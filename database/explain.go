@@ -0,0 +1,174 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/brunotm/norm/internal/buffer"
+	"github.com/brunotm/norm/internal/scan"
+	"github.com/brunotm/norm/statement"
+)
+
+// ExplainOption configures the query plan returned by Tx.Explain.
+type ExplainOption func(o *explainOptions)
+
+type explainOptions struct {
+	analyze bool
+	format  string
+}
+
+// ExplainAnalyze executes the statement and includes the actual run times and row counts in the plan,
+// as `EXPLAIN ANALYZE` on the Postgres and MySQL dialects. Note that the statement side effects are
+// not discarded unless the transaction is rolled back.
+func ExplainAnalyze() ExplainOption {
+	return func(o *explainOptions) {
+		o.analyze = true
+	}
+}
+
+// ExplainFormat sets the format of the plan, like TEXT, JSON, XML or YAML on the Postgres dialect
+// and TRADITIONAL, JSON or TREE on the MySQL dialect, where plans with ExplainAnalyze are only in the TREE format.
+func ExplainFormat(format string) ExplainOption {
+	return func(o *explainOptions) {
+		o.format = strings.ToUpper(format)
+	}
+}
+
+// Explain returns the query plan for the given statement according to the transaction dialect,
+// as `EXPLAIN (ANALYZE, FORMAT JSON) stmt` on the Postgres dialect, `EXPLAIN ANALYZE stmt` or
+// `EXPLAIN FORMAT=JSON stmt` on the MySQL dialect, and `EXPLAIN QUERY PLAN stmt` on the SQLite dialect.
+// Single column plans are returned with a line for each row, while plans with multiple columns,
+// like the MySQL traditional format, are returned as tab separated rows after a header with the columns.
+// The Oracle and SQLServer dialects are not supported.
+func (t *Tx) Explain(stmt statement.Statement, opts ...ExplainOption) (plan string, err error) {
+	start := time.Now()
+
+	o := explainOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	query, args, err := t.build(stmt)
+	if err != nil {
+		return "", err
+	}
+
+	if query, err = explain(t.dialect, o, query); err != nil {
+		return "", err
+	}
+
+	ctx, span := t.trace(t.ctx, "db.tx.explain", "EXPLAIN", query)
+	defer func() { span.End(err) }()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+
+	r, err := t.rows(ctx, query, args)
+	if err == nil {
+		plan, err = loadPlan(r)
+	}
+
+	t.log(LogEvent{Op: "db.tx.explain", TxID: t.tid, Err: err, Duration: time.Since(start), Query: query, Args: args})
+	return plan, err
+}
+
+// explain prefixes the given query with the EXPLAIN clause for the dialect.
+func explain(d statement.Dialect, o explainOptions, query string) (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	_, _ = buf.WriteString("EXPLAIN ")
+
+	switch d {
+	case statement.Postgres:
+		switch {
+		case o.analyze && o.format != "":
+			_, _ = buf.WriteString("(ANALYZE, FORMAT ")
+			_, _ = buf.WriteString(o.format)
+			_, _ = buf.WriteString(") ")
+		case o.format != "":
+			_, _ = buf.WriteString("(FORMAT ")
+			_, _ = buf.WriteString(o.format)
+			_, _ = buf.WriteString(") ")
+		case o.analyze:
+			_, _ = buf.WriteString("ANALYZE ")
+		}
+
+	case statement.MySQL:
+		if o.analyze {
+			// EXPLAIN ANALYZE only produces TREE plans, which is implied
+			if o.format != "" && o.format != "TREE" {
+				return "", fmt.Errorf("%w: EXPLAIN ANALYZE FORMAT=%s, dialect: %s", statement.ErrUnsupported, o.format, d)
+			}
+			_, _ = buf.WriteString("ANALYZE ")
+			break
+		}
+		if o.format != "" {
+			_, _ = buf.WriteString("FORMAT=")
+			_, _ = buf.WriteString(o.format)
+			_, _ = buf.WriteString(" ")
+		}
+
+	case statement.SQLite:
+		if o.analyze || o.format != "" {
+			return "", fmt.Errorf("%w: EXPLAIN options, dialect: %s", statement.ErrUnsupported, d)
+		}
+		_, _ = buf.WriteString("QUERY PLAN ")
+
+	case statement.Oracle, statement.SQLServer:
+		return "", fmt.Errorf("%w: EXPLAIN, dialect: %s", statement.ErrUnsupported, d)
+
+	default:
+		if o.format != "" {
+			return "", fmt.Errorf("%w: EXPLAIN FORMAT, dialect: %s", statement.ErrUnsupported, d)
+		}
+		if o.analyze {
+			_, _ = buf.WriteString("ANALYZE ")
+		}
+	}
+
+	_, _ = buf.WriteString(query)
+	return buf.String(), nil
+}
+
+// loadPlan loads the query plan from the given rows.
+func loadPlan(r *sql.Rows) (plan string, err error) {
+	columns, err := r.Columns()
+	if err != nil {
+		_ = r.Close()
+		return "", err
+	}
+
+	var lines []string
+	if len(columns) == 1 {
+		if _, err = scan.Load(r, &lines); err != nil {
+			return "", err
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+
+	var rows []map[string]interface{}
+	if _, err = (&scan.Scanner{BytesAsString: true}).Load(r, &rows); err != nil {
+		return "", err
+	}
+
+	lines = append(lines, strings.Join(columns, "\t"))
+	values := make([]string, len(columns))
+	for _, row := range rows {
+		for x, c := range columns {
+			if row[c] == nil {
+				values[x] = "NULL"
+				continue
+			}
+			values[x] = fmt.Sprint(row[c])
+		}
+		lines = append(lines, strings.Join(values, "\t"))
+	}
+
+	return strings.Join(lines, "\n"), nil
+}
@@ -0,0 +1,22 @@
+package database
+
+import (
+	"github.com/brunotm/norm/statement"
+)
+
+// Get executes a query that returns exactly one row within the given transaction, scanning it into a value of type T.
+// It returns ErrNoRows if the query returns no rows and ErrMultipleRows if it returns more than one,
+// in which case the value will hold the first row.
+func Get[T any](tx *Tx, stmt statement.Statement) (v T, err error) {
+	err = tx.QueryRow(&v, stmt)
+	return v, err
+}
+
+// Select executes a query within the given transaction, scanning the resulting rows into a slice of T.
+func Select[T any](tx *Tx, stmt statement.Statement) (v []T, err error) {
+	if err = tx.Query(&v, stmt); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
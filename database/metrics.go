@@ -0,0 +1,31 @@
+package database
+
+import (
+	"time"
+)
+
+// Metrics receives observations of database operations, allowing them to be exported
+// to a metrics system like Prometheus.
+type Metrics interface {
+	// ObserveQuery observes the duration and result of the given operation, like db.begin,
+	// db.tx.exec, db.tx.query, db.tx.commit or db.tx.rollback. Each operation logged by the
+	// database is observed with the same operation name.
+	ObserveQuery(op string, d time.Duration, err error)
+
+	// ObserveCache observes a transaction query cache lookup, with hit being false if the
+	// results were not cached and the query was executed.
+	ObserveCache(hit bool)
+}
+
+type nopMetrics struct{}
+
+func (nopMetrics) ObserveQuery(string, time.Duration, error) {}
+func (nopMetrics) ObserveCache(bool)                         {}
+
+// observe returns an EventLogger that observes each event with the given Metrics before logging it.
+func observe(log EventLogger, metrics Metrics) (e EventLogger) {
+	return func(e LogEvent) {
+		metrics.ObserveQuery(e.Op, e.Duration, e.Err)
+		log(e)
+	}
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/brunotm/norm/statement"
+)
+
+// Querier executes queries that return rows, scanning them into dst.
+// It is implemented by Tx and DB, and allows code that only reads data to be tested
+// with a fake implementation, like dbtest.Recorder, instead of a database.
+type Querier interface {
+	QueryContext(ctx context.Context, dst interface{}, stmt statement.Statement) (err error)
+}
+
+// Execer executes statements that don't return rows.
+// It is implemented by Tx and DB, and allows code that writes data to be tested
+// with a fake implementation, like dbtest.Recorder, instead of a database.
+type Execer interface {
+	ExecContext(ctx context.Context, stmt statement.Statement) (r sql.Result, err error)
+}
+
+// QueryExecer is the combination of Querier and Execer.
+type QueryExecer interface {
+	Querier
+	Execer
+}
+
+var (
+	_ QueryExecer = (*Tx)(nil)
+	_ QueryExecer = (*DB)(nil)
+)
+
+// ExecContext executes a statement that doesn't return rows within a single statement transaction as Exec,
+// without a transaction id.
+func (d *DB) ExecContext(ctx context.Context, stmt statement.Statement) (r sql.Result, err error) {
+	return d.Exec(ctx, "", stmt)
+}
+
+// QueryContext executes a query that returns rows within a single statement transaction as Query,
+// without a transaction id.
+func (d *DB) QueryContext(ctx context.Context, dst interface{}, stmt statement.Statement) (err error) {
+	return d.Query(ctx, "", dst, stmt)
+}
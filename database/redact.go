@@ -0,0 +1,72 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+// ArgRedaction defines how the bound arguments of queries are logged.
+type ArgRedaction int
+
+const (
+	// RedactNone logs the arguments as is.
+	RedactNone ArgRedaction = iota
+	// RedactOmit omits the arguments.
+	RedactOmit
+	// RedactHash logs each argument as the first 8 bytes of the hex encoded SHA-256 of its value,
+	// as sha256:1c8ad0e8e6317e4c, so equal values can be correlated without being revealed.
+	RedactHash
+	// RedactType logs each argument as its type and length if it has one, as string(12).
+	RedactType
+)
+
+// redact returns an EventLogger that redacts the arguments of each event before logging it with log.
+func redact(log EventLogger, r ArgRedaction) (e EventLogger) {
+	if r == RedactNone {
+		return log
+	}
+
+	return func(e LogEvent) {
+		if len(e.Args) > 0 {
+			e.Args = r.redact(e.Args)
+		}
+		log(e)
+	}
+}
+
+// redact returns the redacted arguments.
+func (r ArgRedaction) redact(args []interface{}) (redacted []interface{}) {
+	if r == RedactOmit {
+		return nil
+	}
+
+	redacted = make([]interface{}, len(args))
+	for x, arg := range args {
+		switch r {
+		case RedactHash:
+			sum := sha256.Sum256([]byte(fmt.Sprintf("%T:%v", arg, arg)))
+			redacted[x] = "sha256:" + hex.EncodeToString(sum[:8])
+		default:
+			redacted[x] = redactType(arg)
+		}
+	}
+
+	return redacted
+}
+
+// redactType returns the type of the given argument and its length if it has one.
+func redactType(arg interface{}) (s string) {
+	if arg == nil {
+		return "nil"
+	}
+
+	v := reflect.ValueOf(arg)
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return fmt.Sprintf("%T(%d)", arg, v.Len())
+	}
+
+	return fmt.Sprintf("%T", arg)
+}
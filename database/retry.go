@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	defaultRetryBackoff    = 10 * time.Millisecond
+	defaultRetryMaxBackoff = time.Second
+)
+
+// RetryPolicy defines how transactions run with DB.WithUpdateRetry are retried.
+type RetryPolicy struct {
+	// Retryable reports whether a transaction that failed with the given error can be safely
+	// retried, allowing the classification of driver specific errors. If nil IsRetryable is used.
+	// For example with github.com/go-sql-driver/mysql, which does not expose the SQLSTATE of errors:
+	//
+	//	func(err error) bool {
+	//		var e *mysql.MySQLError
+	//		return errors.As(err, &e) && (e.Number == 1213 || e.Number == 1205)
+	//	}
+	Retryable func(err error) bool
+
+	// Backoff is the duration to wait before the first retry, doubling for each subsequent retry.
+	// If 0 it defaults to 10ms.
+	Backoff time.Duration
+
+	// MaxBackoff is the maximum duration to wait between retries. If 0 it defaults to 1s.
+	MaxBackoff time.Duration
+}
+
+// IsRetryable reports whether the given error is a serialization failure (SQLSTATE 40001)
+// or a deadlock (SQLSTATE 40P01), for drivers whose errors expose a `SQLState() string` method,
+// like github.com/lib/pq and github.com/jackc/pgx, or an *Error classified as Deadlock or Serialization.
+func IsRetryable(err error) (ok bool) {
+	switch ErrorClassOf(err) {
+	case Deadlock, Serialization:
+		return true
+	}
+
+	var e interface{ SQLState() string }
+	if !errors.As(err, &e) {
+		return false
+	}
+
+	switch e.SQLState() {
+	case "40001", "40P01":
+		return true
+	}
+
+	return false
+}
+
+// WithUpdateRetry is like WithUpdate but rolls back and runs fn again in a new transaction when it fails
+// with a retryable error, like serialization failures and deadlocks, up to maxRetries times with an exponential
+// backoff between attempts, according to the DB RetryPolicy. The fn may be called multiple times and must not
+// have side effects outside of the transaction. It returns the last error if all attempts fail.
+func (d *DB) WithUpdateRetry(ctx context.Context, tid string, maxRetries int, fn func(tx *Tx) error) (err error) {
+	backoff := d.retry.Backoff
+
+	for attempt := 0; ; attempt++ {
+		if err = d.WithUpdate(ctx, tid, fn); err == nil || attempt >= maxRetries || !d.retry.Retryable(err) {
+			return err
+		}
+
+		d.log(LogEvent{Op: "db.tx.retry", TxID: tid, Err: err, Duration: backoff})
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		if backoff *= 2; backoff > d.retry.MaxBackoff {
+			backoff = d.retry.MaxBackoff
+		}
+	}
+}
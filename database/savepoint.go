@@ -0,0 +1,82 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/brunotm/norm/statement"
+)
+
+var (
+	// ErrInvalidSavepoint is returned by Savepoint when the savepoint name is not a valid identifier.
+	ErrInvalidSavepoint = fmt.Errorf("database: invalid savepoint name")
+
+	savepointName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,127}$`)
+)
+
+// Savepoint represents a savepoint within a transaction, which allows rolling back
+// part of the work done in the transaction without aborting it.
+type Savepoint struct {
+	tx       *Tx
+	name     string
+	released bool
+}
+
+// Savepoint creates a savepoint with the given name within the transaction.
+// The name must start with a letter or underscore, followed by up to 127 letters, digits or underscores.
+func (t *Tx) Savepoint(name string) (s *Savepoint, err error) {
+	if !savepointName.MatchString(name) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidSavepoint, name)
+	}
+
+	query := "SAVEPOINT " + name
+	if t.dialect == statement.SQLServer {
+		query = "SAVE TRANSACTION " + name
+	}
+
+	if err = t.command("db.tx.savepoint", query, false); err != nil {
+		return nil, err
+	}
+
+	return &Savepoint{tx: t, name: name}, nil
+}
+
+// Name returns the savepoint name.
+func (s *Savepoint) Name() (name string) {
+	return s.name
+}
+
+// Release releases the savepoint, keeping the work done since it was created.
+// On dialects without savepoint release, like Oracle and SQLServer, it only invalidates the savepoint.
+func (s *Savepoint) Release() (err error) {
+	if s.released {
+		return nil
+	}
+
+	switch s.tx.dialect {
+	case statement.Oracle, statement.SQLServer:
+	default:
+		if err = s.tx.command("db.tx.savepoint.release", "RELEASE SAVEPOINT "+s.name, false); err != nil {
+			return err
+		}
+	}
+
+	s.released = true
+	return nil
+}
+
+// RollbackTo rolls back the work done in the transaction since the savepoint was created.
+// The savepoint remains valid and can be rolled back to again, or released.
+// Since the rolled back work may have changed the query results, the transaction query cache is cleared.
+func (s *Savepoint) RollbackTo() (err error) {
+	if s.released {
+		return fmt.Errorf("%w: %s already released", ErrInvalidSavepoint, s.name)
+	}
+
+	query := "ROLLBACK TO SAVEPOINT " + s.name
+	if s.tx.dialect == statement.SQLServer {
+		query = "ROLLBACK TRANSACTION " + s.name
+	}
+
+	return s.tx.command("db.tx.savepoint.rollback", query, true)
+}
@@ -0,0 +1,37 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/brunotm/norm/statement"
+)
+
+var (
+	// ErrInvalidSetting is returned by SetLocal when the parameter name is not a valid setting name.
+	ErrInvalidSetting = fmt.Errorf("database: invalid setting name")
+
+	settingName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}(\.[A-Za-z_][A-Za-z0-9_]{0,62})?$`)
+)
+
+// SetLocal sets the given run-time parameter for the remainder of the transaction with `SET LOCAL param = 'value'`,
+// as for tuning the statement_timeout or work_mem of a single transaction, or setting a custom `app.tenant_id`
+// for row level security policies. The setting is reverted when the transaction is committed or rolled back,
+// and as it may change the query results, the transaction query cache is cleared.
+// The parameter name must be an identifier, optionally qualified as `prefix.name`, and the value is quoted
+// as a Postgres escape string literal, which Postgres converts to the parameter type. It is only supported
+// on the Postgres dialect.
+func (t *Tx) SetLocal(param, value string) (err error) {
+	if !settingName.MatchString(param) {
+		return fmt.Errorf("%w: %q", ErrInvalidSetting, param)
+	}
+
+	if t.dialect != statement.Postgres {
+		return fmt.Errorf("%w: SET LOCAL, dialect: %s", statement.ErrUnsupported, t.dialect)
+	}
+
+	// escape string syntax, so backslashes are literal regardless of standard_conforming_strings
+	value = strings.NewReplacer(`\`, `\\`, `'`, `''`).Replace(value)
+	return t.command("db.tx.set.local", "SET LOCAL "+param+" = E'"+value+"'", true)
+}
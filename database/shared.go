@@ -0,0 +1,129 @@
+package database
+
+import (
+	"reflect"
+	"sync"
+	"time"
+)
+
+// SharedCacheStore is an in-process store for the query results shared across transactions, as to bound
+// the cached results with a size limited cache instead of the default unbounded in memory store.
+// Stored values are private copies of the query results as Go values, which are not serializable,
+// so they must be kept in the process memory and returned as is. Out of process stores like Redis
+// or memcached are not supported. Implementations must be safe for concurrent use and must not
+// return values after their ttl.
+type SharedCacheStore interface {
+	Get(key string) (value interface{}, ok bool)
+	Set(key string, value interface{}, ttl time.Duration)
+}
+
+// SharedCachePolicy defines the behavior of the query cache shared across read-only transactions.
+type SharedCachePolicy struct {
+	// TTL is the duration for which the results are cached. If 0 the shared cache is disabled.
+	TTL time.Duration
+
+	// Store is the in-process store for the cached results. If nil, an unbounded in memory store is used.
+	Store SharedCacheStore
+}
+
+// sharedCache is a query results cache shared across read-only transactions. Like the transaction
+// query cache, values must be copied with copyValue when added and retrieved.
+type sharedCache struct {
+	store SharedCacheStore
+	ttl   time.Duration
+}
+
+// newSharedCache creates a shared cache for the given policy, or nil if it is disabled.
+func newSharedCache(p SharedCachePolicy) (c *sharedCache) {
+	if p.TTL <= 0 {
+		return nil
+	}
+
+	if p.Store == nil {
+		p.Store = newMemoryStore()
+	}
+
+	return &sharedCache{store: p.Store, ttl: p.TTL}
+}
+
+// get returns the cached value for the given key.
+func (c *sharedCache) get(key string) (value reflect.Value, ok bool) {
+	v, ok := c.store.Get(key)
+	if !ok {
+		return value, false
+	}
+
+	value, ok = v.(reflect.Value)
+	return value, ok
+}
+
+// add adds the value to the cache.
+func (c *sharedCache) add(key string, value reflect.Value) {
+	c.store.Set(key, value, c.ttl)
+}
+
+// memoryStore is an in memory SharedCacheStore. Expired entries are removed when retrieved
+// and periodically when adding entries.
+type memoryStore struct {
+	mu      sync.Mutex
+	items   map[string]memoryEntry
+	writes  int
+	nowFunc func() time.Time
+}
+
+type memoryEntry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// sweepInterval is the number of writes between removals of expired entries.
+const sweepInterval = 1024
+
+func newMemoryStore() (s *memoryStore) {
+	return &memoryStore{items: map[string]memoryEntry{}, nowFunc: time.Now}
+}
+
+// Get implements the SharedCacheStore interface.
+func (s *memoryStore) Get(key string) (value interface{}, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	if !s.nowFunc().Before(e.expires) {
+		delete(s.items, key)
+		return nil, false
+	}
+
+	return e.value, true
+}
+
+// reset removes all entries.
+func (s *memoryStore) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.items = map[string]memoryEntry{}
+}
+
+// Set implements the SharedCacheStore interface.
+func (s *memoryStore) Set(key string, value interface{}, ttl time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.nowFunc()
+	s.items[key] = memoryEntry{value: value, expires: now.Add(ttl)}
+
+	s.writes++
+	if s.writes%sweepInterval != 0 {
+		return
+	}
+
+	for k, e := range s.items {
+		if !now.Before(e.expires) {
+			delete(s.items, k)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"strings"
+	"time"
+)
+
+// slow returns an EventLogger that logs each exec and query event taking longer than the threshold
+// to the slow logger, with the operation suffixed by .slow, before logging it with log.
+func slow(log, slowLog EventLogger, threshold time.Duration) (e EventLogger) {
+	return func(e LogEvent) {
+		if e.Duration > threshold && slowOp(e.Op) {
+			s := e
+			s.Op += ".slow"
+			slowLog(s)
+		}
+		log(e)
+	}
+}
+
+// slowOp returns true if the operation executes a statement,
+// excluding the query cache lookups which never reach the database.
+func slowOp(op string) bool {
+	switch {
+	case strings.HasSuffix(op, ".get"):
+		return false
+	case strings.HasPrefix(op, "db.tx.exec"), strings.HasPrefix(op, "db.tx.query"),
+		strings.HasPrefix(op, "db.tx.stmt.exec"), strings.HasPrefix(op, "db.tx.stmt.query"):
+		return true
+	}
+	return false
+}
@@ -2,15 +2,15 @@ package database
 
 import (
 	"database/sql"
-	"fmt"
 	"time"
-
-	"github.com/brunotm/norm/internal/scan"
 )
 
+// Stmt is a prepared statement within a transaction.
+// As the transaction operations, its executions are serialized with the transaction lock.
 type Stmt struct {
-	tx   *Tx
-	stmt *sql.Stmt
+	tx    *Tx
+	stmt  *sql.Stmt
+	query string
 }
 
 // Close closes the statement.
@@ -18,7 +18,7 @@ func (s *Stmt) Close() (err error) {
 	start := time.Now()
 	err = s.stmt.Close()
 
-	s.tx.log("db.tx.stmt.close", s.tx.tid, err, time.Since(start), "")
+	s.tx.log(LogEvent{Op: "db.tx.stmt.close", TxID: s.tx.tid, Err: err, Duration: time.Since(start)})
 	return err
 }
 
@@ -26,9 +26,21 @@ func (s *Stmt) Close() (err error) {
 // returns a Result summarizing the effect of the statement.
 func (s *Stmt) Exec(args ...interface{}) (r sql.Result, err error) {
 	start := time.Now()
+	s.tx.mu.Lock()
+	defer s.tx.mu.Unlock()
+
 	r, err = s.stmt.ExecContext(s.tx.ctx, args...)
+	err = s.tx.classify.wrap(err)
+
+	var affected int64
+	if err == nil {
+		affected, _ = r.RowsAffected()
+		s.tx.stats.Execs++
+		s.tx.stats.RowsAffected += affected
+	}
 
-	s.tx.log("db.tx.stmt.exec", s.tx.tid, err, time.Since(start), fmt.Sprintf("%+v", args))
+	s.tx.log(LogEvent{Op: "db.tx.stmt.exec", TxID: s.tx.tid, Err: err, Duration: time.Since(start),
+		Query: s.query, Args: args, RowsAffected: affected})
 	return r, err
 }
 
@@ -36,15 +48,26 @@ func (s *Stmt) Exec(args ...interface{}) (r sql.Result, err error) {
 // and returns the query results as a *Rows.
 func (s *Stmt) Query(dst interface{}, args ...interface{}) (err error) {
 	start := time.Now()
+	s.tx.mu.Lock()
+	defer s.tx.mu.Unlock()
 
 	r, err := s.stmt.QueryContext(s.tx.ctx, args...)
-	if err != nil {
+	if err = s.tx.classify.wrap(err); err != nil {
+		s.tx.log(LogEvent{Op: "db.tx.stmt.query", TxID: s.tx.tid, Err: err, Duration: time.Since(start),
+			Query: s.query, Args: args})
 		return err
 	}
 	defer r.Close()
 
-	_, err = scan.Load(r, dst)
-	s.tx.log("db.tx.stmt.query", s.tx.tid, err, time.Since(start), fmt.Sprintf("%+v", args))
+	count, err := s.tx.scanner.LoadLimit(r, dst, s.tx.maxRows(s.tx.ctx))
+	err = s.tx.classify.wrap(err)
+	if err == nil {
+		s.tx.stats.Queries++
+		s.tx.stats.RowsReturned += int64(count)
+	}
+
+	s.tx.log(LogEvent{Op: "db.tx.stmt.query", TxID: s.tx.tid, Err: err, Duration: time.Since(start),
+		Query: s.query, Args: args, RowsAffected: int64(count)})
 	return err
 
 }
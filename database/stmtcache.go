@@ -0,0 +1,137 @@
+package database
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// stmtCache is a cache of statements prepared on the database and shared across transactions, which bind
+// them to the transaction with sql.Tx.StmtContext. It is bounded by the number of entries with a least
+// recently used eviction policy. Entries are reference counted by the transactions using them, so that
+// evicted statements are only closed when they are no longer in use.
+type stmtCache struct {
+	mu     sync.Mutex
+	db     *sql.DB
+	log    EventLogger
+	max    int
+	ll     *list.List
+	items  map[string]*list.Element
+	closed bool
+}
+
+type stmtEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int
+	evicted bool
+}
+
+// newStmtCache creates a statement cache bounded to max entries, or nil if max is 0.
+func newStmtCache(db *sql.DB, log EventLogger, max int) (c *stmtCache) {
+	if max <= 0 {
+		return nil
+	}
+
+	return &stmtCache{
+		db:    db,
+		log:   log,
+		max:   max,
+		ll:    list.New(),
+		items: map[string]*list.Element{},
+	}
+}
+
+// acquire returns the cached prepared statement for the given query, preparing it on the database if needed.
+// The entry must be released when the transaction no longer uses the statement.
+func (c *stmtCache) acquire(ctx context.Context, tid, query string) (e *stmtEntry, err error) {
+	if e = c.get(query); e != nil {
+		return e, nil
+	}
+
+	// prepare without holding the lock, as it is a round trip to the database
+	start := time.Now()
+	stmt, err := c.db.PrepareContext(ctx, query)
+	c.log(LogEvent{Op: "db.prepare", TxID: tid, Err: err, Duration: time.Since(start), Query: query})
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// the query was prepared concurrently by another transaction
+	if el, ok := c.items[query]; ok {
+		_ = stmt.Close()
+		c.ll.MoveToFront(el)
+		e = el.Value.(*stmtEntry)
+		e.refs++
+		return e, nil
+	}
+
+	e = &stmtEntry{query: query, stmt: stmt, refs: 1}
+	if c.closed {
+		// not cached, closed on release
+		e.evicted = true
+		return e, nil
+	}
+
+	c.items[query] = c.ll.PushFront(e)
+	if c.ll.Len() > c.max {
+		c.evict(c.ll.Back())
+	}
+
+	return e, nil
+}
+
+// get returns the cached entry for the query, or nil if it is not cached.
+func (c *stmtCache) get(query string) (e *stmtEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[query]
+	if !ok {
+		return nil
+	}
+
+	c.ll.MoveToFront(el)
+	e = el.Value.(*stmtEntry)
+	e.refs++
+	return e
+}
+
+// evict removes the given element from the cache, closing its statement if it is not in use.
+// Must be called with the cache lock held.
+func (c *stmtCache) evict(el *list.Element) {
+	e := c.ll.Remove(el).(*stmtEntry)
+	delete(c.items, e.query)
+
+	e.evicted = true
+	if e.refs == 0 {
+		_ = e.stmt.Close()
+	}
+}
+
+// release releases the given entry, closing its statement if it was evicted and is no longer in use.
+func (c *stmtCache) release(e *stmtEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e.refs--
+	if e.evicted && e.refs == 0 {
+		_ = e.stmt.Close()
+	}
+}
+
+// close closes the cached statements, statements in use are closed when released.
+func (c *stmtCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	for c.ll.Len() > 0 {
+		c.evict(c.ll.Back())
+	}
+}
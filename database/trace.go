@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"strings"
+)
+
+// Attribute is a span attribute.
+type Attribute struct {
+	Key   string
+	Value string
+}
+
+// Tracer creates spans for database operations. It can be implemented with a thin
+// adapter over an OpenTelemetry trace.Tracer, without adding the dependency to this package.
+type Tracer interface {
+	// Start starts a span with the given name and attributes as a child of the span in ctx, if any,
+	// returning a context holding the created span.
+	Start(ctx context.Context, name string, attrs ...Attribute) (context.Context, Span)
+}
+
+// Span is a span created by a Tracer.
+type Span interface {
+	// End ends the span, setting an error status if err is not nil.
+	End(err error)
+}
+
+// Span attribute keys, following the OpenTelemetry database semantic conventions.
+const (
+	AttrDBStatement = "db.statement"
+	AttrDBOperation = "db.operation"
+	AttrDBTxID      = "db.transaction.id"
+)
+
+type nopSpan struct{}
+
+func (nopSpan) End(error) {}
+
+// trace starts a span for the given transaction operation if a tracer is configured.
+func (t *Tx) trace(ctx context.Context, name, operation, query string) (context.Context, Span) {
+	if t.tracer == nil {
+		return ctx, nopSpan{}
+	}
+
+	attrs := []Attribute{{Key: AttrDBTxID, Value: t.tid}, {Key: AttrDBOperation, Value: operation}}
+	if query != "" {
+		attrs = append(attrs, Attribute{Key: AttrDBStatement, Value: query})
+	}
+
+	return t.tracer.Start(ctx, name, attrs...)
+}
+
+// operation returns the operation name for the given query, which is its first keyword
+// after any leading comments, like SELECT or INSERT.
+func operation(query string) (op string) {
+	for {
+		query = strings.TrimSpace(query)
+
+		var end string
+		switch {
+		case strings.HasPrefix(query, "--"):
+			end = "\n"
+		case strings.HasPrefix(query, "/*"):
+			end = "*/"
+		}
+
+		if end == "" {
+			break
+		}
+
+		idx := strings.Index(query, end)
+		if idx == -1 {
+			return ""
+		}
+		query = query[idx+len(end):]
+	}
+
+	if idx := strings.IndexAny(query, " \t\n("); idx != -1 {
+		query = query[:idx]
+	}
+
+	return strings.ToUpper(query)
+}
@@ -3,8 +3,8 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"hash/maphash"
 	"reflect"
 	"sync"
 	"time"
@@ -13,16 +13,125 @@ import (
 	"github.com/brunotm/norm/statement"
 )
 
-// Tx represents a database transaction
+var (
+	// ErrNoRows is returned by QueryRow and QueryFirst when the query returns no rows.
+	ErrNoRows = sql.ErrNoRows
+
+	// ErrMultipleRows is returned by QueryRow when the query returns more than one row.
+	ErrMultipleRows = fmt.Errorf("database: query returned multiple rows")
+
+	// ErrMaxRows is returned by the queries loading more rows than Config.MaxRows or the limit set with WithMaxRows.
+	ErrMaxRows = scan.ErrMaxRows
+)
+
+// maxRowsKey is the context key for the maximum number of rows set with WithMaxRows.
+type maxRowsKey struct{}
+
+// WithMaxRows returns a copy of the context which overrides Config.MaxRows with the given maximum number of rows
+// for the queries executed with it, as with Tx.QueryContext, or for all queries of transactions created with it.
+// If n is 0 the number of rows is not limited.
+func WithMaxRows(ctx context.Context, n int) context.Context {
+	return context.WithValue(ctx, maxRowsKey{}, n)
+}
+
+// maxRows returns the maximum number of rows for queries executed with the given context.
+func (t *Tx) maxRows(ctx context.Context) int {
+	if n, ok := ctx.Value(maxRowsKey{}).(int); ok {
+		return n
+	}
+	return t.scanner.MaxRows
+}
+
+// queryMode defines how query results are loaded into the destination.
+type queryMode int
+
+const (
+	// queryAll loads all rows in the result set
+	queryAll queryMode = iota
+	// queryRow loads exactly one row from the result set
+	queryRow
+	// queryFirst loads the first row from the result set
+	queryFirst
+)
+
+// Tx represents a database transaction.
+//
+// A Tx is safe for use by multiple goroutines, though its operations are serialized and never
+// run concurrently, as a transaction is bound to a single database connection. A Cursor holds the
+// connection until it is closed, and while it is open most drivers fail other statements on the
+// same transaction. For concurrent queries use a separate transaction for each goroutine.
 type Tx struct {
-	mu    sync.Mutex
-	tid   string
-	log   Logger
-	done  bool
-	tx    *sql.Tx
-	ctx   context.Context
-	hash  maphash.Hash
-	cache map[uint64]reflect.Value
+	mu       sync.Mutex
+	tid      string
+	log      EventLogger
+	done     bool
+	finished chan struct{}
+	tx       *sql.Tx
+	ctx      context.Context
+	dialect  statement.Dialect
+	quote    bool
+	where    bool
+	dedup    bool
+	encoders []statement.ArgEncoder
+	inline   bool
+	timeout  time.Duration
+	batch    int
+	maxArgs  int
+	queue    []statement.Statement
+	scanner  *scan.Scanner
+	cache    *cache
+	shared   *sharedCache
+	stmts    map[string]*sql.Stmt
+	pstmts   *stmtCache
+	entries  []*stmtEntry
+	tracer   Tracer
+	metrics  Metrics
+	classify errorClassifiers
+	driver   string
+	stats    TxStats
+
+	onCommit   []func()
+	onRollback []func()
+}
+
+// TxStats are the cumulative counts of the statements executed within a transaction.
+type TxStats struct {
+	// Execs is the number of statements executed without returning rows, including copies.
+	Execs int64
+	// RowsAffected is the total number of rows affected by the executed statements, as reported by the driver.
+	RowsAffected int64
+	// Queries is the number of queries whose results were loaded from the database,
+	// excluding the results returned from the query caches and cursors.
+	Queries int64
+	// RowsReturned is the total number of rows loaded by the queries.
+	RowsReturned int64
+}
+
+// context returns the context for a single operation within the transaction,
+// bounded by the configured query timeout if any.
+func (t *Tx) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.timeout > 0 {
+		return context.WithTimeout(ctx, t.timeout)
+	}
+
+	return ctx, func() {}
+}
+
+// Underlying returns the underlying *sql.Tx for operations not covered by Tx within the same transaction,
+// like calling stored procedures with output parameters or driver specific features.
+// Operations performed on it bypass logging, tracing, metrics and query timeouts and are not serialized
+// with the other Tx operations, so it must not be used concurrently with them. Writes performed on it
+// are not seen by the transaction query cache, which may return stale results afterwards.
+// The transaction must still be committed or rolled back with Tx.
+func (t *Tx) Underlying() (tx *sql.Tx) {
+	return t.tx
+}
+
+// Stats returns the cumulative counts of the statements executed within the transaction so far.
+func (t *Tx) Stats() (s TxStats) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
 }
 
 // Prepare creates a prepared statement for use within a transaction.
@@ -30,31 +139,145 @@ func (t *Tx) Prepare(query string) (stmt *Stmt, err error) {
 	start := time.Now()
 
 	s, err := t.tx.PrepareContext(t.ctx, query)
-	t.log("db.tx.prepare", t.tid, err, time.Since(start), query)
+	t.log(LogEvent{Op: "db.tx.prepare", TxID: t.tid, Err: err, Duration: time.Since(start), Query: query})
 	if err != nil {
 		return nil, err
 	}
 
-	return &Stmt{tx: t, stmt: s}, err
+	return &Stmt{tx: t, stmt: s, query: query}, err
 }
 
 // Exec executes a query that doesn't return rows.
 func (t *Tx) Exec(stmt statement.Statement) (r sql.Result, err error) {
+	return t.ExecContext(t.ctx, stmt)
+}
+
+// ExecContext is like Exec but executes the query with the given context, which allows the
+// use of different deadlines for each statement. The transaction remains bound to the context
+// it was created with, and is rolled back if it is done.
+func (t *Tx) ExecContext(ctx context.Context, stmt statement.Statement) (r sql.Result, err error) {
 	start := time.Now()
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	query, err := stmt.String()
+	query, args, err := t.build(stmt)
 	if err != nil {
 		return nil, err
 	}
 
-	r, err = t.tx.ExecContext(t.ctx, query)
+	ctx, span := t.trace(ctx, "db.tx.exec", operation(query), query)
+	defer func() { span.End(err) }()
+
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+
+	r, err = t.exec(ctx, query, args)
 
-	t.log("db.tx.exec", t.tid, err, time.Since(start), query)
+	var affected int64
+	if err == nil {
+		affected, _ = r.RowsAffected()
+		t.stats.Execs++
+		t.stats.RowsAffected += affected
+	}
+
+	t.log(LogEvent{Op: "db.tx.exec", TxID: t.tid, Err: err, Duration: time.Since(start),
+		Query: t.logged(stmt, query), Args: args, RowsAffected: affected})
 	return r, err
 }
 
+// ExecBatch executes the insert statement split in batches of rows, so that each batch is within
+// the maximum number of arguments supported by the transaction dialect, or Config.MaxArgs if set.
+// It returns the total number of rows affected by all batches.
+func (t *Tx) ExecBatch(stmt *statement.InsertStatement) (affected int64, err error) {
+	for _, batch := range t.batches(stmt) {
+		r, err := t.Exec(batch)
+		if err != nil {
+			return affected, err
+		}
+
+		n, err := r.RowsAffected()
+		if err != nil {
+			return affected, err
+		}
+		affected += n
+	}
+
+	return affected, nil
+}
+
+// ExecBatchReturning executes the insert statement with a `RETURNING` clause split in batches of rows as ExecBatch,
+// appending the rows returned by each batch to dst, which must be a pointer to a slice, like *[]int64 or *[]T.
+// Batches are executed in order, so the returned rows follow the order of the inserted rows as long as the
+// database returns the rows of each batch in insertion order, as Postgres does for multi row `VALUES`.
+func (t *Tx) ExecBatchReturning(dst interface{}, stmt *statement.InsertStatement) (err error) {
+	if v := reflect.ValueOf(dst); v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("database: dst must be a pointer to a slice, got: %T", dst)
+	}
+
+	for _, batch := range t.batches(stmt) {
+		if err = t.ExecReturning(dst, batch); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// batches splits the insert statement rows in batches within the transaction maximum number of arguments.
+func (t *Tx) batches(stmt *statement.InsertStatement) (batches []*statement.InsertStatement) {
+	opts := []statement.Option{statement.WithDialect(t.dialect)}
+	if t.maxArgs > 0 {
+		opts = append(opts, statement.WithMaxArgs(t.maxArgs))
+	}
+
+	return stmt.Batches(opts...)
+}
+
+// ExecAffected executes a query that doesn't return rows, returning the number of rows affected by it.
+// It returns an error if the driver can't report the number of rows affected.
+func (t *Tx) ExecAffected(stmt statement.Statement) (affected int64, err error) {
+	r, err := t.Exec(stmt)
+	if err != nil {
+		return 0, err
+	}
+
+	if affected, err = r.RowsAffected(); err != nil {
+		return 0, fmt.Errorf("database: rows affected not supported by driver: %w", err)
+	}
+
+	return affected, nil
+}
+
+// ExecInsertID executes an insert statement, returning the id generated by the database for the inserted row.
+// As the Postgres, Oracle and SQLServer drivers do not report generated ids, it returns statement.ErrUnsupported
+// on those dialects without executing the statement, in which case use a `RETURNING` clause with ExecReturning.
+// It returns an error if the driver can't report the generated id.
+func (t *Tx) ExecInsertID(stmt statement.Statement) (id int64, err error) {
+	switch t.dialect {
+	case statement.Postgres, statement.Oracle, statement.SQLServer:
+		return 0, fmt.Errorf("%w: LastInsertId, dialect: %s, use RETURNING with ExecReturning",
+			statement.ErrUnsupported, t.dialect)
+	}
+
+	r, err := t.Exec(stmt)
+	if err != nil {
+		return 0, err
+	}
+
+	if id, err = r.LastInsertId(); err != nil {
+		return 0, fmt.Errorf("database: last insert id not supported by driver, use RETURNING with ExecReturning: %w", err)
+	}
+
+	return id, nil
+}
+
+// ExecReturning executes a statement with a `RETURNING` clause, like an INSERT, UPDATE or DELETE,
+// scanning the returned columns into dst. This is required for drivers that can't report the
+// returned values through sql.Result.
+func (t *Tx) ExecReturning(dst interface{}, stmt statement.Statement) (err error) {
+	return t.query(t.ctx, dst, stmt, false, queryAll)
+}
+
 // ExecSQL is like Exec but accepts a raw SQL statement and values for interpolation
 func (t *Tx) ExecSQL(query string, values ...interface{}) (r sql.Result, err error) {
 	stmt := &statement.Part{Query: query, Values: values}
@@ -63,107 +286,411 @@ func (t *Tx) ExecSQL(query string, values ...interface{}) (r sql.Result, err err
 
 // Query executes a query that returns rows.
 func (t *Tx) Query(dst interface{}, stmt statement.Statement) (err error) {
-	return t.query(dst, stmt, false)
+	return t.query(t.ctx, dst, stmt, false, queryAll)
+}
+
+// QueryContext is like Query but executes the query with the given context, which allows the
+// use of different deadlines for each statement. The transaction remains bound to the context
+// it was created with, and is rolled back if it is done.
+func (t *Tx) QueryContext(ctx context.Context, dst interface{}, stmt statement.Statement) (err error) {
+	return t.query(ctx, dst, stmt, false, queryAll)
 }
 
 // QuerySQL is like Query but accepts a raw SQL statement and values for interpolation
 func (t *Tx) QuerySQL(dst interface{}, query string, values ...interface{}) (err error) {
 	stmt := &statement.Part{Query: query, Values: values}
-	return t.query(dst, stmt, false)
+	return t.query(t.ctx, dst, stmt, false, queryAll)
 }
 
 // QueryCache is like Query, but will add query results to or return already cached
-// results from the transaction query cache.
+// results from the transaction query cache, and from the shared query cache in read-only transactions
+// if configured with Config.SharedCache.
 func (t *Tx) QueryCache(dst interface{}, stmt statement.Statement) (err error) {
-	return t.query(dst, stmt, true)
+	return t.query(t.ctx, dst, stmt, true, queryAll)
 }
 
 // QueryCacheSQL is like QueryCache but accepts a raw SQL statement and values for interpolation
 func (t *Tx) QueryCacheSQL(dst interface{}, query string, values ...interface{}) (err error) {
 	stmt := &statement.Part{Query: query, Values: values}
-	return t.query(dst, stmt, true)
+	return t.query(t.ctx, dst, stmt, true, queryAll)
 }
 
-func (t *Tx) query(dst interface{}, stmt statement.Statement, cache bool) (err error) {
+// QueryRow executes a query that returns exactly one row, scanning it into dst.
+// It returns ErrNoRows if the query returns no rows and ErrMultipleRows if it returns more than one,
+// in which case dst will hold the first row.
+func (t *Tx) QueryRow(dst interface{}, stmt statement.Statement) (err error) {
+	return t.query(t.ctx, dst, stmt, false, queryRow)
+}
+
+// QueryRowCache is like QueryRow, but will add query results to or return already cached
+// results from the transaction query cache.
+func (t *Tx) QueryRowCache(dst interface{}, stmt statement.Statement) (err error) {
+	return t.query(t.ctx, dst, stmt, true, queryRow)
+}
+
+// QueryFirst is like QueryRow but scans the first row into dst, ignoring any remaining rows.
+// It returns ErrNoRows if the query returns no rows.
+func (t *Tx) QueryFirst(dst interface{}, stmt statement.Statement) (err error) {
+	return t.query(t.ctx, dst, stmt, false, queryFirst)
+}
+
+// QueryMulti executes a query returning multiple result sets, like a stored procedure call or a batch
+// of statements, scanning each result set into the destination at the same position in dsts.
+// It returns an error if the query returns fewer result sets than destinations, while any
+// result sets beyond the destinations are discarded. Results are not cached.
+func (t *Tx) QueryMulti(stmt statement.Statement, dsts ...interface{}) (err error) {
 	start := time.Now()
 
-	query, err := stmt.String()
+	query, args, err := t.build(stmt)
 	if err != nil {
 		return err
 	}
 
+	ctx, span := t.trace(t.ctx, "db.tx.query", operation(query), query)
+	defer func() { span.End(err) }()
+
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	var key uint64
-	if cache {
-		if _, err = t.hash.WriteString(query); err != nil {
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+
+	var count int
+	defer func() {
+		t.log(LogEvent{Op: "db.tx.query", TxID: t.tid, Err: err, Duration: time.Since(start),
+			Query: t.logged(stmt, query), Args: args, RowsAffected: int64(count)})
+	}()
+
+	r, err := t.rows(ctx, query, args)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	for x := 0; x < len(dsts); x++ {
+		if x > 0 && !r.NextResultSet() {
+			if err = r.Err(); err == nil {
+				err = fmt.Errorf("database: query returned %d result sets, expected %d", x, len(dsts))
+			}
 			return err
 		}
 
-		key = t.hash.Sum64()
-		t.hash.Reset()
+		n, err := t.scanner.LoadSetLimit(r, dsts[x], t.maxRows(ctx))
+		count += n
+		if err != nil {
+			return t.classify.wrap(err)
+		}
+	}
 
-		if r, ok := t.cache[key]; ok {
-			dstValue := reflect.ValueOf(dst)
+	t.stats.Queries++
+	t.stats.RowsReturned += int64(count)
+	return nil
+}
 
-			if dstValue.Kind() != reflect.Ptr {
-				err := fmt.Errorf("database: dst must be a pointer type")
-				t.log("db.tx.query.cache.get", t.tid, err, time.Since(start), query)
-				return err
-			}
+func (t *Tx) query(ctx context.Context, dst interface{}, stmt statement.Statement, cache bool, mode queryMode) (err error) {
+	start := time.Now()
 
-			if dstValue.Elem().Type() != r.Type() {
-				err := fmt.Errorf("database: invalid cached dst type: %s, expected: %s",
-					dstValue.Type().String(), r.Type().String())
-				t.log("db.tx.query.cache.get", t.tid, err, time.Since(start), query)
-				return err
-			}
+	query, args, err := t.build(stmt)
+	if err != nil {
+		return err
+	}
+	logged := t.logged(stmt, query)
 
-			dstValue.Elem().Set(r)
-			t.log("db.tx.query.cache.get", t.tid, nil, time.Since(start), query)
+	ctx, span := t.trace(ctx, "db.tx.query", operation(query), query)
+	defer func() { span.End(err) }()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// skip the cache entirely when disabled
+	cache = cache && t.cache != nil
+
+	var key string
+	if cache {
+		if v := reflect.ValueOf(dst); v.Kind() != reflect.Ptr || v.IsNil() {
+			err := fmt.Errorf("database: dst must be a pointer type")
+			t.log(LogEvent{Op: "db.tx.query.cache.get", TxID: t.tid, Err: err, Duration: time.Since(start), Query: logged, Args: args})
+			return err
+		}
+		key, cache = cacheKey(query, args, mode, reflect.TypeOf(dst))
+	}
+
+	if cache {
+		op := "db.tx.query.cache.get"
+		r, ok := t.cache.get(key)
+		if !ok && t.shared != nil {
+			op = "db.tx.query.shared.get"
+			r, ok = t.shared.get(key)
+		}
+
+		// distinct types with the same name share the key, and are queried again on a mismatch
+		dstValue := reflect.ValueOf(dst).Elem()
+		ok = ok && dstValue.Type() == r.Type()
+		t.metrics.ObserveCache(ok)
+
+		if ok {
+			dstValue.Set(copyValue(r))
+			t.log(LogEvent{Op: op, TxID: t.tid, Duration: time.Since(start), Query: logged, Args: args})
 			return nil
 		}
 	}
 
-	r, err := t.tx.QueryContext(t.ctx, query)
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+
+	// a done context while loading aborts the scan and closes the rows
+	r, err := t.rows(ctx, query, args)
 	if err != nil {
-		t.log("db.tx.query", t.tid, err, time.Since(start), query)
+		t.log(LogEvent{Op: "db.tx.query", TxID: t.tid, Err: err, Duration: time.Since(start), Query: logged, Args: args})
 		return err
 	}
 	defer r.Close()
 
-	if _, err = scan.Load(r, dst); err != nil {
-		t.log("db.tx.query", t.tid, err, time.Since(start), query)
+	var count int
+	switch mode {
+	case queryAll:
+		count, err = t.scanner.LoadLimit(r, dst, t.maxRows(ctx))
+	case queryRow, queryFirst:
+		count, err = t.scanner.LoadRow(r, dst)
+	}
+	err = t.classify.wrap(err)
+
+	if err == nil {
+		t.stats.Queries++
+		t.stats.RowsReturned += int64(count)
+	}
+
+	if err != nil {
+		t.log(LogEvent{Op: "db.tx.query.scan", TxID: t.tid, Err: err, Duration: time.Since(start),
+			Query: logged, Args: args, RowsAffected: int64(count)})
 		return err
 	}
 
+	if mode != queryAll {
+		switch {
+		case count == 0:
+			err = ErrNoRows
+		case count > 1 && mode == queryRow:
+			err = ErrMultipleRows
+		}
+	}
+
+	if err != nil {
+		t.log(LogEvent{Op: "db.tx.query", TxID: t.tid, Err: err, Duration: time.Since(start),
+			Query: logged, Args: args, RowsAffected: int64(count)})
+		return err
+	}
+
+	op := "db.tx.query"
 	if cache {
-		t.cache[key] = reflect.ValueOf(dst).Elem()
-		t.log("db.tx.query.cache.add", t.tid, nil, time.Since(start), query)
-	} else {
-		t.log("db.tx.query", t.tid, err, time.Since(start), query)
+		t.cache.add(key, copyValue(reflect.ValueOf(dst).Elem()))
+		op = "db.tx.query.cache.add"
+
+		if t.shared != nil {
+			t.shared.add(key, copyValue(reflect.ValueOf(dst).Elem()))
+		}
 	}
 
+	t.log(LogEvent{Op: op, TxID: t.tid, Duration: time.Since(start), Query: logged, Args: args, RowsAffected: int64(count)})
+
 	return nil
 }
 
-// Commit the transaction.
+// exec executes the query with the given arguments, using a prepared statement
+// if the transaction prepare cache is enabled. Must be called with the transaction lock held.
+func (t *Tx) exec(ctx context.Context, query string, args []interface{}) (r sql.Result, err error) {
+	if t.stmts == nil {
+		r, err = t.tx.ExecContext(ctx, query, args...)
+		return r, t.classify.wrap(err)
+	}
+
+	stmt, err := t.prepared(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err = stmt.ExecContext(ctx, args...)
+	return r, t.classify.wrap(err)
+}
+
+// command executes the given transaction scoped command without arguments, as the savepoint and
+// SET LOCAL statements, logging it with the given operation and clearing the transaction query cache
+// on success if reset is true.
+func (t *Tx) command(op, query string, reset bool) (err error) {
+	start := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return sql.ErrTxDone
+	}
+
+	ctx, cancel := t.context(t.ctx)
+	defer cancel()
+
+	_, err = t.tx.ExecContext(ctx, query)
+	if err == nil && reset && t.cache != nil {
+		t.cache.reset()
+	}
+
+	t.log(LogEvent{Op: op, TxID: t.tid, Err: err, Duration: time.Since(start), Query: query})
+	return err
+}
+
+// rows executes the query with the given arguments and returns the resulting rows, using a prepared
+// statement if the transaction prepare cache is enabled. Must be called with the transaction lock held.
+func (t *Tx) rows(ctx context.Context, query string, args []interface{}) (r *sql.Rows, err error) {
+	if t.stmts == nil {
+		r, err = t.tx.QueryContext(ctx, query, args...)
+		return r, t.classify.wrap(err)
+	}
+
+	stmt, err := t.prepared(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err = stmt.QueryContext(ctx, args...)
+	return r, t.classify.wrap(err)
+}
+
+// prepared returns the prepared statement for the given query from the transaction
+// prepared statements, preparing it if needed, or binding it from the DB statement cache if enabled.
+// Must be called with the transaction lock held.
+func (t *Tx) prepared(ctx context.Context, query string) (stmt *sql.Stmt, err error) {
+	if stmt, ok := t.stmts[query]; ok {
+		return stmt, nil
+	}
+
+	if t.pstmts != nil {
+		e, err := t.pstmts.acquire(ctx, t.tid, query)
+		if err != nil {
+			return nil, err
+		}
+
+		t.entries = append(t.entries, e)
+		t.stmts[query] = t.tx.StmtContext(ctx, e.stmt)
+		return t.stmts[query], nil
+	}
+
+	start := time.Now()
+	stmt, err = t.tx.PrepareContext(ctx, query)
+	t.log(LogEvent{Op: "db.tx.prepare", TxID: t.tid, Err: err, Duration: time.Since(start), Query: query})
+	if err != nil {
+		return nil, err
+	}
+
+	t.stmts[query] = stmt
+	return stmt, nil
+}
+
+// closeStmts closes the transaction prepared statements, releasing the statements
+// from the DB statement cache. Must be called with the transaction lock held.
+func (t *Tx) closeStmts() {
+	for query, stmt := range t.stmts {
+		_ = stmt.Close()
+		delete(t.stmts, query)
+	}
+
+	for _, e := range t.entries {
+		t.pstmts.release(e)
+	}
+	t.entries = nil
+}
+
+// build builds the given statement into a query and its arguments for the transaction dialect.
+// Statements that do not implement statement.Parameterized have their values interpolated.
+func (t *Tx) build(stmt statement.Statement) (query string, args []interface{}, err error) {
+	if s, ok := stmt.(statement.Parameterized); ok {
+		return s.SQL(t.options()...)
+	}
+
+	query, err = stmt.String()
+	return query, nil, err
+}
+
+// options returns the statement build options for the transaction.
+func (t *Tx) options() (opts []statement.Option) {
+	opts = []statement.Option{statement.WithDialect(t.dialect)}
+	if t.quote {
+		opts = append(opts, statement.WithQuoting())
+	}
+	if t.where {
+		opts = append(opts, statement.WithRequireWhere())
+	}
+	if t.dedup {
+		opts = append(opts, statement.WithDedupArgs())
+	}
+	if len(t.encoders) > 0 {
+		opts = append(opts, statement.WithArgEncoder(t.encoders...))
+	}
+	return opts
+}
+
+// logged returns the query to be logged for the given statement and its built query,
+// which is interpolated if the transaction logs interpolated queries.
+func (t *Tx) logged(stmt statement.Statement, query string) (q string) {
+	if !t.inline {
+		return query
+	}
+
+	if _, ok := stmt.(statement.Parameterized); !ok {
+		return query
+	}
+
+	q, err := statement.Interpolate(stmt, t.options()...)
+	if err != nil {
+		return query
+	}
+
+	return q
+}
+
+// Commit the transaction. Statements queued with Enqueue are flushed first,
+// and the transaction is not committed if flushing fails.
 func (t *Tx) Commit() (err error) {
+	if _, err = t.Flush(); err != nil {
+		return err
+	}
+
 	start := time.Now()
+
+	var callbacks []func()
+	defer func() { t.callbacks("db.tx.commit.callback", callbacks) }()
+
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	err = t.tx.Commit()
-	t.done = true
+	_, span := t.trace(t.ctx, "db.tx.commit", "COMMIT", "")
+	defer func() { span.End(err) }()
+
+	t.closeStmts()
+	err = t.classify.wrap(t.tx.Commit())
+
+	// a failed commit can leave the transaction open, as when the context is done before committing,
+	// so it is only marked as done on success and a subsequent Rollback still releases it
+	if err == nil {
+		t.finish()
+		callbacks = t.onCommit
+	}
 
-	t.log("db.tx.commit", t.tid, err, time.Since(start), "")
+	t.log(LogEvent{Op: "db.tx.commit", TxID: t.tid, Err: err, Duration: time.Since(start)})
 	return err
 }
 
-// Rollback aborts the transaction.
+// Rollback aborts the transaction. It is safe to call after Commit, as in a deferred call, and does nothing
+// if the transaction was already committed or rolled back. After a failed Commit the transaction is rolled back
+// if it is still open, returning nil if it was already finished by the failed commit.
 func (t *Tx) Rollback() (err error) {
+	return t.rollback("db.tx.rollback")
+}
+
+func (t *Tx) rollback(op string) (err error) {
 	start := time.Now()
+
+	var callbacks []func()
+	defer func() { t.callbacks("db.tx.rollback.callback", callbacks) }()
+
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
@@ -171,9 +698,79 @@ func (t *Tx) Rollback() (err error) {
 		return nil
 	}
 
+	_, span := t.trace(t.ctx, op, "ROLLBACK", "")
+	defer func() { span.End(err) }()
+
+	t.closeStmts()
+	t.queue = nil
 	err = t.tx.Rollback()
-	t.done = true
+	t.finish()
 
-	t.log("db.tx.rollback", t.tid, err, time.Since(start), "")
+	// the transaction was already finished by a failed commit or by its context being done
+	if errors.Is(err, sql.ErrTxDone) {
+		err = nil
+	}
+
+	if err == nil {
+		callbacks = t.onRollback
+	}
+
+	t.log(LogEvent{Op: op, TxID: t.tid, Err: err, Duration: time.Since(start)})
 	return err
 }
+
+// OnCommit registers a callback to be called after the transaction is successfully committed,
+// for side effects that must only happen once the changes are durable, like publishing events.
+// Callbacks are called in registration order after Commit returns the transaction lock,
+// and are never called if the transaction is rolled back.
+func (t *Tx) OnCommit(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onCommit = append(t.onCommit, fn)
+}
+
+// OnRollback registers a callback to be called after the transaction is rolled back,
+// including rollbacks due to the transaction context being done with Config.RollbackOnCancel.
+// Callbacks are called in registration order after Rollback returns the transaction lock,
+// and are never called if the transaction is committed.
+func (t *Tx) OnRollback(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.onRollback = append(t.onRollback, fn)
+}
+
+// callbacks calls the given callbacks in order, logging and recovering from panics
+// so that a panicking callback does not prevent the remaining ones from being called.
+func (t *Tx) callbacks(op string, callbacks []func()) {
+	for _, fn := range callbacks {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.log(LogEvent{Op: op, TxID: t.tid, Err: fmt.Errorf("panic: %v", r)})
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+// finish marks the transaction as done, stopping the context watcher if any.
+// Must be called with the transaction lock held.
+func (t *Tx) finish() {
+	t.done = true
+	if t.finished != nil {
+		close(t.finished)
+		t.finished = nil
+	}
+}
+
+// watch rolls back the transaction if its context is done before it is finished.
+// Commit and Rollback hold the transaction lock, so the rollback either finds the transaction done
+// or prevents them from running until it is rolled back.
+func (t *Tx) watch(finished <-chan struct{}) {
+	select {
+	case <-t.ctx.Done():
+		_ = t.rollback("db.tx.rollback.cancel")
+	case <-finished:
+	}
+}
@@ -1,5 +1,5 @@
 module github.com/brunotm/norm
 
-go 1.16
+go 1.18
 
 require github.com/DATA-DOG/go-sqlmock v1.5.0
@@ -0,0 +1,389 @@
+package scan
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidArray is returned when a value can't be encoded or decoded as a Postgres array.
+	ErrInvalidArray = fmt.Errorf("statement: invalid array")
+)
+
+// Array binds and scans slices as Postgres arrays, using the text encoding of github.com/lib/pq,
+// as `{1,2}` for numbers, `{"a","b"}` for strings, `{t,f}` for booleans and `{"\\x0102"}` for bytes.
+// Nested slices are encoded as multidimensional arrays and nil pointers as NULL elements.
+type Array struct {
+	V interface{}
+}
+
+// Value implements the driver.Valuer interface.
+func (a Array) Value() (v driver.Value, err error) {
+	rv := reflect.ValueOf(a.V)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, fmt.Errorf("%w: expected slice, got %T", ErrInvalidArray, a.V)
+	}
+
+	if rv.Kind() == reflect.Slice && rv.IsNil() {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	if err = encodeArray(&buf, rv); err != nil {
+		return nil, err
+	}
+
+	return buf.String(), nil
+}
+
+// Scan implements the sql.Scanner interface, V must be a pointer to a slice.
+func (a Array) Scan(src interface{}) (err error) {
+	rv := reflect.ValueOf(a.V)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("%w: expected pointer to slice, got %T", ErrInvalidArray, a.V)
+	}
+
+	var text string
+	switch src := src.(type) {
+	case nil:
+		rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+		return nil
+	case []byte:
+		text = string(src)
+	case string:
+		text = src
+	default:
+		return fmt.Errorf("%w: cannot scan %T", ErrInvalidArray, src)
+	}
+
+	p := &arrayParser{text: text}
+	if err = p.parse(rv.Elem()); err != nil {
+		return err
+	}
+
+	if p.pos != len(p.text) {
+		return fmt.Errorf("%w: unexpected data after array: %q", ErrInvalidArray, text)
+	}
+
+	return nil
+}
+
+func encodeArray(buf *bytes.Buffer, v reflect.Value) (err error) {
+	_ = buf.WriteByte('{')
+
+	for x := 0; x < v.Len(); x++ {
+		if x > 0 {
+			_ = buf.WriteByte(',')
+		}
+
+		if err = encodeElem(buf, v.Index(x)); err != nil {
+			return err
+		}
+	}
+
+	_ = buf.WriteByte('}')
+	return nil
+}
+
+func encodeElem(buf *bytes.Buffer, v reflect.Value) (err error) {
+	if valuer, ok := v.Interface().(driver.Valuer); ok {
+		if v.Kind() == reflect.Ptr && v.IsNil() {
+			_, _ = buf.WriteString("NULL")
+			return nil
+		}
+
+		value, err := valuer.Value()
+		if err != nil {
+			return err
+		}
+
+		if value == nil {
+			_, _ = buf.WriteString("NULL")
+			return nil
+		}
+		v = reflect.ValueOf(value)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			_, _ = buf.WriteString("NULL")
+			return nil
+		}
+		return encodeElem(buf, v.Elem())
+
+	case reflect.Bool:
+		if v.Bool() {
+			_ = buf.WriteByte('t')
+		} else {
+			_ = buf.WriteByte('f')
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		_, _ = buf.WriteString(strconv.FormatInt(v.Int(), 10))
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		_, _ = buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+
+	case reflect.Float32:
+		_, _ = buf.WriteString(strconv.FormatFloat(v.Float(), 'f', -1, 32))
+
+	case reflect.Float64:
+		_, _ = buf.WriteString(strconv.FormatFloat(v.Float(), 'f', -1, 64))
+
+	case reflect.String:
+		quoteElem(buf, v.String())
+
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Kind() == reflect.Slice && v.IsNil() {
+				_, _ = buf.WriteString("NULL")
+				return nil
+			}
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			quoteElem(buf, `\x`+hex.EncodeToString(b))
+			return nil
+		}
+		return encodeArray(buf, v)
+
+	default:
+		return fmt.Errorf("%w: unsupported element type %s", ErrInvalidArray, v.Type())
+	}
+
+	return nil
+}
+
+func quoteElem(buf *bytes.Buffer, s string) {
+	_ = buf.WriteByte('"')
+	for x := 0; x < len(s); x++ {
+		if s[x] == '"' || s[x] == '\\' {
+			_ = buf.WriteByte('\\')
+		}
+		_ = buf.WriteByte(s[x])
+	}
+	_ = buf.WriteByte('"')
+}
+
+// arrayParser decodes the Postgres array text encoding.
+type arrayParser struct {
+	text string
+	pos  int
+}
+
+func (p *arrayParser) errorf(msg string) error {
+	return fmt.Errorf("%w: %s at position %d: %q", ErrInvalidArray, msg, p.pos, p.text)
+}
+
+// parse parses an array at the current position into the given slice.
+func (p *arrayParser) parse(slice reflect.Value) (err error) {
+	if p.pos >= len(p.text) || p.text[p.pos] != '{' {
+		return p.errorf("expected {")
+	}
+	p.pos++
+
+	slice.Set(reflect.MakeSlice(slice.Type(), 0, 0))
+	elemType := slice.Type().Elem()
+
+	if p.pos < len(p.text) && p.text[p.pos] == '}' {
+		p.pos++
+		return nil
+	}
+
+	for {
+		elem := reflect.New(elemType).Elem()
+
+		if p.pos < len(p.text) && p.text[p.pos] == '{' {
+			if elemType.Kind() != reflect.Slice {
+				return p.errorf("unexpected nested array")
+			}
+			if err = p.parse(elem); err != nil {
+				return err
+			}
+		} else {
+			value, null, err := p.elem()
+			if err != nil {
+				return err
+			}
+
+			if err = setElem(elem, value, null); err != nil {
+				return err
+			}
+		}
+
+		slice.Set(reflect.Append(slice, elem))
+
+		if p.pos >= len(p.text) {
+			return p.errorf("unterminated array")
+		}
+
+		switch p.text[p.pos] {
+		case ',':
+			p.pos++
+		case '}':
+			p.pos++
+			return nil
+		default:
+			return p.errorf("expected , or }")
+		}
+	}
+}
+
+// elem parses a quoted or unquoted element at the current position.
+func (p *arrayParser) elem() (value string, null bool, err error) {
+	if p.pos < len(p.text) && p.text[p.pos] == '"' {
+		var b strings.Builder
+		for p.pos++; p.pos < len(p.text); p.pos++ {
+			switch c := p.text[p.pos]; c {
+			case '\\':
+				p.pos++
+				if p.pos < len(p.text) {
+					_ = b.WriteByte(p.text[p.pos])
+				}
+			case '"':
+				p.pos++
+				return b.String(), false, nil
+			default:
+				_ = b.WriteByte(c)
+			}
+		}
+		return "", false, p.errorf("unterminated quoted element")
+	}
+
+	start := p.pos
+	for p.pos < len(p.text) && p.text[p.pos] != ',' && p.text[p.pos] != '}' {
+		p.pos++
+	}
+
+	value = p.text[start:p.pos]
+	if value == "" {
+		return "", false, p.errorf("empty element")
+	}
+
+	return value, strings.EqualFold(value, "NULL"), nil
+}
+
+// setElem sets the element to the given decoded value.
+func setElem(elem reflect.Value, value string, null bool) (err error) {
+	if scanner, ok := elem.Addr().Interface().(sql.Scanner); ok {
+		if null {
+			return scanner.Scan(nil)
+		}
+		return scanner.Scan(value)
+	}
+
+	if null {
+		switch elem.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice:
+			elem.Set(reflect.Zero(elem.Type()))
+			return nil
+		}
+		return fmt.Errorf("%w: cannot scan NULL element into %s", ErrInvalidArray, elem.Type())
+	}
+
+	switch elem.Kind() {
+	case reflect.Ptr:
+		elem.Set(reflect.New(elem.Type().Elem()))
+		return setElem(elem.Elem(), value, false)
+
+	case reflect.Interface:
+		elem.Set(reflect.ValueOf(value))
+
+	case reflect.Bool:
+		switch value {
+		case "t", "true":
+			elem.SetBool(true)
+		case "f", "false":
+			elem.SetBool(false)
+		default:
+			return fmt.Errorf("%w: invalid boolean element: %q", ErrInvalidArray, value)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, elem.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidArray, err)
+		}
+		elem.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, elem.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidArray, err)
+		}
+		elem.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, elem.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidArray, err)
+		}
+		elem.SetFloat(n)
+
+	case reflect.String:
+		elem.SetString(value)
+
+	case reflect.Slice:
+		if elem.Type().Elem().Kind() != reflect.Uint8 || !strings.HasPrefix(value, `\x`) {
+			return fmt.Errorf("%w: cannot scan %q into %s", ErrInvalidArray, value, elem.Type())
+		}
+		b, err := hex.DecodeString(value[2:])
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrInvalidArray, err)
+		}
+		elem.SetBytes(b)
+
+	default:
+		return fmt.Errorf("%w: unsupported element type %s", ErrInvalidArray, elem.Type())
+	}
+
+	return nil
+}
+
+// JSON binds and scans values as JSON encoded columns, like Postgres json and jsonb columns.
+type JSON struct {
+	V interface{}
+}
+
+// Value implements the driver.Valuer interface.
+func (j JSON) Value() (v driver.Value, err error) {
+	b, err := json.Marshal(j.V)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements the sql.Scanner interface, V must be a pointer.
+// NULL values set V to its zero value.
+func (j JSON) Scan(src interface{}) (err error) {
+	switch src := src.(type) {
+	case nil:
+		rv := reflect.ValueOf(j.V)
+		if rv.Kind() != reflect.Ptr || rv.IsNil() {
+			return ErrInvalidType
+		}
+		rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+		return nil
+	case []byte:
+		return json.Unmarshal(src, j.V)
+	case string:
+		return json.Unmarshal([]byte(src), j.V)
+	}
+
+	return fmt.Errorf("statement: cannot scan %T into JSON", src)
+}
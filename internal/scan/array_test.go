@@ -0,0 +1,137 @@
+package scan
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestArrayValue(t *testing.T) {
+	s := "b"
+	cases := []struct {
+		name   string
+		value  interface{}
+		expect interface{}
+	}{
+		{name: "ints", value: []int{1, -2, 3}, expect: `{1,-2,3}`},
+		{name: "floats", value: []float64{1.5, 2}, expect: `{1.5,2}`},
+		{name: "bools", value: []bool{true, false}, expect: `{t,f}`},
+		{name: "strings", value: []string{"a", `b"c`, `d\e`, "f,g", ""}, expect: `{"a","b\"c","d\\e","f,g",""}`},
+		{name: "bytes", value: [][]byte{{0x01, 0x02}, nil}, expect: `{"\\x0102",NULL}`},
+		{name: "nullable", value: []*string{nil, &s}, expect: `{NULL,"b"}`},
+		{name: "valuers", value: []sql.NullInt64{{Int64: 1, Valid: true}, {}}, expect: `{1,NULL}`},
+		{name: "nested", value: [][]int{{1, 2}, {3, 4}}, expect: `{{1,2},{3,4}}`},
+		{name: "empty", value: []int{}, expect: `{}`},
+		{name: "nil", value: []int(nil), expect: nil},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := Array{V: tt.value}.Value()
+			if err != nil {
+				t.Fatalf("error encoding array: %s", err)
+			}
+
+			if !reflect.DeepEqual(tt.expect, v) {
+				t.Fatalf("expected: %#v, got: %#v", tt.expect, v)
+			}
+		})
+	}
+
+	if _, err := (Array{V: 1}).Value(); !errors.Is(err, ErrInvalidArray) {
+		t.Fatalf("expected ErrInvalidArray for non slice, got: %v", err)
+	}
+}
+
+func TestArrayScan(t *testing.T) {
+	s := "b"
+	cases := []struct {
+		name    string
+		src     interface{}
+		dst     interface{}
+		expect  interface{}
+		wantErr bool
+	}{
+		{name: "ints", src: []byte(`{1,-2,3}`), dst: &[]int64{}, expect: &[]int64{1, -2, 3}},
+		{name: "floats", src: `{1.5,2}`, dst: &[]float64{}, expect: &[]float64{1.5, 2}},
+		{name: "bools", src: `{t,f}`, dst: &[]bool{}, expect: &[]bool{true, false}},
+		{name: "strings", src: `{a,"b\"c","d\\e","f,g",""}`, dst: &[]string{}, expect: &[]string{"a", `b"c`, `d\e`, "f,g", ""}},
+		{name: "bytes", src: `{"\\x0102"}`, dst: &[][]byte{}, expect: &[][]byte{{0x01, 0x02}}},
+		{name: "nullable", src: `{NULL,b}`, dst: &[]*string{}, expect: &[]*string{nil, &s}},
+		{name: "valuers", src: `{1,NULL}`, dst: &[]sql.NullInt64{}, expect: &[]sql.NullInt64{{Int64: 1, Valid: true}, {}}},
+		{name: "nested", src: `{{1,2},{3,4}}`, dst: &[][]int{}, expect: &[][]int{{1, 2}, {3, 4}}},
+		{name: "empty", src: `{}`, dst: &[]int{1}, expect: &[]int{}},
+		{name: "null", src: nil, dst: &[]int{1}, expect: new([]int)},
+		{name: "null_element", src: `{1,NULL}`, dst: &[]int{}, wantErr: true},
+		{name: "invalid", src: `{1,2`, dst: &[]int{}, wantErr: true},
+		{name: "trailing", src: `{1}x`, dst: &[]int{}, wantErr: true},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Array{V: tt.dst}.Scan(tt.src)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidArray) {
+					t.Fatalf("expected ErrInvalidArray, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error decoding array: %s", err)
+			}
+
+			if !reflect.DeepEqual(tt.expect, tt.dst) {
+				t.Fatalf("expected: %#v, got: %#v", tt.expect, tt.dst)
+			}
+		})
+	}
+}
+
+func TestLoadArrayJSON(t *testing.T) {
+	type settings struct {
+		Theme string `json:"theme"`
+		Size  int    `json:"size"`
+	}
+
+	type user struct {
+		ID       int
+		Tags     []string
+		Scores   []int
+		Settings settings  `db:"settings,json"`
+		Extra    *settings `db:",json"`
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "tags", "scores", "settings", "extra"}).
+			AddRow(1, []byte(`{"admin","ops"}`), `{10,20}`, []byte(`{"theme":"dark","size":12}`), nil).
+			AddRow(2, nil, `{}`, `{"theme":"light"}`, `{"size":1}`))
+
+	rows, err := db.Query("SELECT id,tags,scores,settings,extra FROM users")
+	if err != nil {
+		t.Fatalf("error querying mock database: %s", err)
+	}
+
+	var users []user
+	if _, err = (&Scanner{Strict: true}).Load(rows, &users); err != nil {
+		t.Fatalf("error loading rows: %s", err)
+	}
+
+	expect := []user{
+		{ID: 1, Tags: []string{"admin", "ops"}, Scores: []int{10, 20}, Settings: settings{Theme: "dark", Size: 12}},
+		{ID: 2, Scores: []int{}, Settings: settings{Theme: "light"}, Extra: &settings{Size: 1}},
+	}
+
+	if !reflect.DeepEqual(expect, users) {
+		t.Fatalf("expected: %#v, got: %#v", expect, users)
+	}
+}
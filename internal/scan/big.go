@@ -0,0 +1,127 @@
+package scan
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"strconv"
+)
+
+var (
+	typeBigInt   = reflect.TypeOf(big.Int{})
+	typeBigFloat = reflect.TypeOf(big.Float{})
+	typeBigRat   = reflect.TypeOf(big.Rat{})
+)
+
+// isBig returns true if the type is a math/big number, which is scanned from a single column.
+func isBig(t reflect.Type) bool {
+	return t == typeBigInt || t == typeBigFloat || t == typeBigRat
+}
+
+// bigScanner scans NUMERIC and DECIMAL columns into *big.Int, *big.Float and *big.Rat destinations, or pointers to them,
+// from their text representation as returned by most drivers, without the precision loss of float64.
+type bigScanner struct {
+	dst        interface{}
+	nullAsZero bool
+}
+
+// bigDest returns the given destination wrapped for scanning if it is a math/big number.
+func bigDest(ptr interface{}, nullAsZero bool) (dst interface{}, ok bool) {
+	switch ptr.(type) {
+	case *big.Int, *big.Float, *big.Rat, **big.Int, **big.Float, **big.Rat:
+		return &bigScanner{dst: ptr, nullAsZero: nullAsZero}, true
+	}
+	return ptr, false
+}
+
+// Scan implements the sql.Scanner interface.
+func (b *bigScanner) Scan(src interface{}) (err error) {
+	var text string
+	switch v := src.(type) {
+	case nil:
+		return b.null()
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Errorf("%w: can't scan %T into %T", ErrInvalidType, src, b.dst)
+	}
+
+	var ok bool
+	switch dst := b.dst.(type) {
+	case *big.Int:
+		_, ok = dst.SetString(text, 10)
+	case **big.Int:
+		*dst, ok = new(big.Int).SetString(text, 10)
+	case *big.Float:
+		ok = parseBigFloat(dst, text)
+	case **big.Float:
+		*dst = new(big.Float)
+		ok = parseBigFloat(*dst, text)
+	case *big.Rat:
+		_, ok = dst.SetString(text)
+	case **big.Rat:
+		*dst, ok = new(big.Rat).SetString(text)
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: can't scan %q into %T", ErrInvalidType, text, b.dst)
+	}
+
+	return nil
+}
+
+// null sets pointer destinations to nil, and values to zero if nullAsZero is set.
+func (b *bigScanner) null() (err error) {
+	switch dst := b.dst.(type) {
+	case **big.Int:
+		*dst = nil
+	case **big.Float:
+		*dst = nil
+	case **big.Rat:
+		*dst = nil
+	default:
+		if !b.nullAsZero {
+			return fmt.Errorf("%w: can't scan NULL into %T", ErrInvalidType, b.dst)
+		}
+
+		switch dst := dst.(type) {
+		case *big.Int:
+			dst.SetInt64(0)
+		case *big.Float:
+			dst.SetInt64(0)
+		case *big.Rat:
+			dst.SetInt64(0)
+		}
+	}
+
+	return nil
+}
+
+// parseBigFloat parses the decimal text exactly as a big.Rat and rounds it once into the given float, to
+// the nearest even value at the float precision, or at 4 bits per character of the text if not set, which
+// is enough for the float to format back to all of its significant decimal digits with Text('f', -1).
+// Parsing it with big.Float.SetString instead rounds at each step, so the result may not be the nearest value.
+func parseBigFloat(f *big.Float, text string) (ok bool) {
+	r, ok := new(big.Rat).SetString(text)
+	if !ok {
+		return false
+	}
+
+	if f.Prec() == 0 {
+		prec := uint(len(text)) * 4
+		if prec < 64 {
+			prec = 64
+		}
+		f.SetPrec(prec)
+	}
+
+	f.SetMode(big.ToNearestEven)
+	f.SetRat(r)
+	return true
+}
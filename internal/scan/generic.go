@@ -0,0 +1,39 @@
+package scan
+
+import (
+	"database/sql"
+)
+
+// One loads the first row from sql.Rows into a value of type T, ignoring any remaining rows.
+// It returns sql.ErrNoRows if there are no rows.
+func One[T any](rows *sql.Rows) (v T, err error) {
+	return OneWith[T](defaultScanner, rows)
+}
+
+// All loads all rows from sql.Rows into a slice of T.
+func All[T any](rows *sql.Rows) (v []T, err error) {
+	return AllWith[T](defaultScanner, rows)
+}
+
+// OneWith is like One but loads the row with the given Scanner.
+func OneWith[T any](s *Scanner, rows *sql.Rows) (v T, err error) {
+	n, err := s.LoadRow(rows, &v)
+	if err != nil {
+		return v, err
+	}
+
+	if n == 0 {
+		return v, sql.ErrNoRows
+	}
+
+	return v, nil
+}
+
+// AllWith is like All but loads the rows with the given Scanner.
+func AllWith[T any](s *Scanner, rows *sql.Rows) (v []T, err error) {
+	if _, err = s.Load(rows, &v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
@@ -0,0 +1,99 @@
+package scan
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestOne(t *testing.T) {
+	t.Run("struct", func(t *testing.T) {
+		rows, done := mockRows(t, 2)
+		defer done()
+
+		r, err := One[row](rows)
+		if err != nil {
+			t.Fatalf("error loading row: %s", err)
+		}
+
+		if !reflect.DeepEqual(row{ID: 0, Name: "name0"}, r) {
+			t.Fatalf("unexpected row: %#v", r)
+		}
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		rows, done := mockRows(t, 1)
+		defer done()
+
+		r, err := One[*row](rows)
+		if err != nil {
+			t.Fatalf("error loading row: %s", err)
+		}
+
+		if !reflect.DeepEqual(&row{ID: 0, Name: "name0"}, r) {
+			t.Fatalf("unexpected row: %#v", r)
+		}
+	})
+
+	t.Run("no rows", func(t *testing.T) {
+		rows, done := mockRows(t, 0)
+		defer done()
+
+		if _, err := One[row](rows); !errors.Is(err, sql.ErrNoRows) {
+			t.Fatalf("expected sql.ErrNoRows, got: %v", err)
+		}
+	})
+
+	t.Run("invalid type", func(t *testing.T) {
+		rows, done := mockRows(t, 1)
+		defer done()
+
+		if _, err := One[[]row](rows); !errors.Is(err, ErrInvalidType) {
+			t.Fatalf("expected ErrInvalidType, got: %v", err)
+		}
+	})
+}
+
+func TestAll(t *testing.T) {
+	t.Run("structs", func(t *testing.T) {
+		rows, done := mockRows(t, 2)
+		defer done()
+
+		r, err := All[row](rows)
+		if err != nil {
+			t.Fatalf("error loading rows: %s", err)
+		}
+
+		expected := []row{{ID: 0, Name: "name0"}, {ID: 1, Name: "name1"}}
+		if !reflect.DeepEqual(expected, r) {
+			t.Fatalf("unexpected rows: %#v", r)
+		}
+	})
+
+	t.Run("scalars", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("error opening mock database: %s", err)
+		}
+		defer db.Close()
+
+		mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("john").AddRow("jane"))
+
+		rows, err := db.Query("SELECT name FROM users")
+		if err != nil {
+			t.Fatalf("error querying mock database: %s", err)
+		}
+
+		names, err := All[string](rows)
+		if err != nil {
+			t.Fatalf("error loading rows: %s", err)
+		}
+
+		if !reflect.DeepEqual([]string{"john", "jane"}, names) {
+			t.Fatalf("unexpected rows: %#v", names)
+		}
+	})
+}
@@ -5,8 +5,10 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 	"unicode"
 )
 
@@ -14,6 +16,13 @@ var (
 	ErrInvalidType = fmt.Errorf("statement: invalid type for scan")
 	typeValuer     = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
 	structMapCache = sync.Map{} // reflect.Type / map[string][]int
+
+	// ErrColumnMismatch is returned in strict mode when the columns do not match the destination struct fields,
+	// and when scanning more than one column into a scalar destination, like []int64.
+	ErrColumnMismatch = fmt.Errorf("statement: columns do not match destination fields")
+
+	// ErrMaxRows is returned when loading more rows than the maximum set with Scanner.MaxRows or LoadLimit.
+	ErrMaxRows = fmt.Errorf("statement: query returned more than the maximum number of rows")
 )
 
 // IsSlice return true if the given interface{} holds a slice type
@@ -28,9 +37,93 @@ func IsSlice(v interface{}) bool {
 
 // Scan code adapted from https://github.com/mailru/dbr/blob/master/load.go
 
+// Scanner loads values from sql.Rows according to its configuration.
+// The zero value is ready to use. NUMERIC and DECIMAL columns are scanned exactly into
+// big.Int, big.Float and big.Rat destinations from their text representation.
+type Scanner struct {
+	// BytesAsString stores []byte column values as string when loading into maps.
+	BytesAsString bool
+
+	// NullAsZero sets destinations that can't hold NULL values, like string or int64 struct fields,
+	// to their zero value when scanning NULL columns. If false scanning NULL into them returns an error.
+	// Pointers and sql.Scanner destinations like sql.NullString are not affected.
+	NullAsZero bool
+
+	// Strict validates that every column is mapped to a field of struct destinations and that every
+	// field is mapped to a column before scanning, returning ErrColumnMismatch otherwise.
+	// Nested struct fields are required individually, while the nested struct itself is not.
+	Strict bool
+
+	// Positional maps the columns to the fields of struct destinations by position, in declaration order
+	// regardless of their names, for query specific structs with computed columns. Nested struct fields
+	// are mapped individually, and the number of columns must match the number of fields,
+	// returning ErrColumnMismatch otherwise.
+	Positional bool
+
+	// Mapper maps struct field names to column names for fields without a `db` tag.
+	// If nil, field names are converted from CamelCase to snake_case.
+	Mapper func(field string) string
+
+	// MaxRows if greater than 0 is the maximum number of rows loaded into slices by Load and LoadSet,
+	// which return ErrMaxRows after loading MaxRows rows if the result set has more rows, guarding against
+	// unbounded result sets being loaded into memory. It can be overridden per load with LoadLimit.
+	MaxRows int
+
+	// Location if not nil is the location of scanned time.Time values, including sql.NullTime
+	// and values loaded into maps. Times in UTC, as returned by most drivers for timestamps without
+	// a time zone, are interpreted as wall clock times in Location, and other times are converted to it.
+	Location *time.Location
+
+	structMaps sync.Map // reflect.Type / map[string][]int, used when Mapper is set
+}
+
+var defaultScanner = &Scanner{}
+
 // Load loads any value from sql.Rows
 func Load(rows *sql.Rows, value interface{}) (int, error) {
+	return defaultScanner.Load(rows, value)
+}
+
+// LoadRow loads the first row from sql.Rows into value, which must not be a slice.
+// It returns the number of rows read, stopping at 2 after checking for rows after the first.
+func LoadRow(rows *sql.Rows, value interface{}) (int, error) {
+	return defaultScanner.LoadRow(rows, value)
+}
+
+// FindExtractor returns a PointersExtractor for the given type
+func FindExtractor(t reflect.Type) (PointersExtractor, error) {
+	return defaultScanner.FindExtractor(t)
+}
+
+// Load loads any value from sql.Rows.
+// Rows loaded into a slice are appended to it, reusing its backing array while it has capacity,
+// so a slice can be reused across loads by truncating it with s[:0]. For slices of pointers
+// like []*T a new T is allocated for each row.
+func (s *Scanner) Load(rows *sql.Rows, value interface{}) (int, error) {
 	defer rows.Close()
+	return s.LoadSet(rows, value)
+}
+
+// LoadLimit loads any value from sql.Rows as Load, with the given maximum number of rows instead of MaxRows.
+// If max is 0 the number of rows is not limited.
+func (s *Scanner) LoadLimit(rows *sql.Rows, value interface{}, max int) (int, error) {
+	defer rows.Close()
+	return s.loadSet(rows, value, max)
+}
+
+// LoadSet loads the current result set from sql.Rows into value as Load, but without closing the rows,
+// so that the next result set can be loaded after advancing the rows with NextResultSet.
+func (s *Scanner) LoadSet(rows *sql.Rows, value interface{}) (int, error) {
+	return s.loadSet(rows, value, s.MaxRows)
+}
+
+// LoadSetLimit loads the current result set from sql.Rows as LoadSet, with the given maximum number
+// of rows instead of MaxRows. If max is 0 the number of rows is not limited.
+func (s *Scanner) LoadSetLimit(rows *sql.Rows, value interface{}, max int) (int, error) {
+	return s.loadSet(rows, value, max)
+}
+
+func (s *Scanner) loadSet(rows *sql.Rows, value interface{}, max int) (int, error) {
 	var count int
 
 	column, err := rows.Columns()
@@ -53,31 +146,47 @@ func Load(rows *sql.Rows, value interface{}) (int, error) {
 		elemType = v.Type()
 	}
 
-	extractor, err := FindExtractor(elemType)
+	extractor, err := s.FindExtractor(elemType)
 	if err != nil {
 		return count, err
 	}
 
+	if err = s.Check(column, elemType); err != nil {
+		return count, err
+	}
+
 	for rows.Next() {
-		var elem reflect.Value
+		elem, n := v, 0
+
+		if isSlice && max > 0 && count == max {
+			return count, fmt.Errorf("%w: %d", ErrMaxRows, max)
+		}
 
 		if isSlice {
-			elem = reflect.New(elemType).Elem()
-		} else {
-			elem = v
+			n = v.Len()
+
+			// scan rows in place, growing the slice only when it is out of capacity
+			if n < v.Cap() {
+				v.SetLen(n + 1)
+				v.Index(n).Set(reflect.Zero(elemType))
+			} else {
+				v.Set(reflect.Append(v, reflect.Zero(elemType)))
+			}
+			elem = v.Index(n)
 		}
 
 		ptr := extractor(column, elem)
 
-		err = rows.Scan(ptr...)
+		err = s.Scan(rows, ptr...)
 		if err != nil {
+			if isSlice {
+				v.SetLen(n)
+			}
 			return count, err
 		}
 		count++
 
-		if isSlice {
-			v.Set(reflect.Append(v, elem))
-		} else {
+		if !isSlice {
 			break
 		}
 	}
@@ -85,6 +194,214 @@ func Load(rows *sql.Rows, value interface{}) (int, error) {
 	return count, rows.Err()
 }
 
+// LoadRow loads the first row from sql.Rows into value, which must not be a slice.
+// It returns the number of rows read, stopping at 2 after checking for rows after the first.
+func (s *Scanner) LoadRow(rows *sql.Rows, value interface{}) (int, error) {
+	defer rows.Close()
+
+	column, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return 0, ErrInvalidType
+	}
+
+	v = v.Elem()
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 {
+		return 0, ErrInvalidType
+	}
+
+	extractor, err := s.FindExtractor(v.Type())
+	if err != nil {
+		return 0, err
+	}
+
+	if err = s.Check(column, v.Type()); err != nil {
+		return 0, err
+	}
+
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+
+	if err = s.Scan(rows, extractor(column, v)...); err != nil {
+		return 0, err
+	}
+
+	if rows.Next() {
+		return 2, nil
+	}
+
+	return 1, rows.Err()
+}
+
+// Check returns ErrColumnMismatch if the columns can't be scanned into the given type, as multiple
+// columns into a scalar, a column count not matching the struct fields when mapping by position, or
+// columns not matching the struct fields in strict mode.
+func (s *Scanner) Check(columns []string, t reflect.Type) (err error) {
+	if err = checkScalar(columns, t); err != nil {
+		return err
+	}
+
+	if err = s.checkPositional(columns, t); err != nil {
+		return err
+	}
+
+	if s.Strict {
+		return s.Validate(columns, t)
+	}
+
+	return nil
+}
+
+// Validate checks that the given columns match the fields of the given struct type, or pointer to it,
+// returning ErrColumnMismatch with a description of the columns with no matching field and the fields
+// with no matching column otherwise. Other types are not validated.
+func (s *Scanner) Validate(columns []string, t reflect.Type) (err error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || isBig(t) || reflect.PtrTo(t).Implements(typeScanner) {
+		return nil
+	}
+
+	mapping := s.StructMap(t)
+	found := make(map[string]bool, len(columns))
+
+	var unmapped, missing []string
+	for x, index := range s.resolve(columns, t) {
+		if index == nil {
+			unmapped = append(unmapped, columns[x])
+			continue
+		}
+		found[fmt.Sprint(index)] = true
+	}
+
+	for name, index := range mapping {
+		if !found[fmt.Sprint(index)] && !IsParent(mapping, index) {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(unmapped) == 0 && len(missing) == 0 {
+		return nil
+	}
+
+	sort.Strings(missing)
+	return fmt.Errorf("%w: %s, columns without fields: %v, fields without columns: %v",
+		ErrColumnMismatch, t, unmapped, missing)
+}
+
+// IsParent returns true if the field with the given index in the StructMap mapping holds other mapped fields,
+// like embedded structs, which are scanned and written through their own fields.
+func IsParent(mapping map[string][]int, index []int) bool {
+	for _, other := range mapping {
+		if len(other) > len(index) && reflect.DeepEqual(other[:len(index)], index) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scan copies the columns of the current row into the values pointed at by ptr, as sql.Rows.Scan,
+// handling NULL values according to the Scanner configuration.
+func (s *Scanner) Scan(rows *sql.Rows, ptr ...interface{}) (err error) {
+	if s.Location == nil {
+		return s.scan(rows, ptr...)
+	}
+
+	// keep the destinations before they are replaced for NULL handling
+	dst := append([]interface{}(nil), ptr...)
+	if err = s.scan(rows, ptr...); err != nil {
+		return err
+	}
+
+	for x := 0; x < len(dst); x++ {
+		switch v := dst[x].(type) {
+		case *time.Time:
+			*v = s.localTime(*v)
+		case **time.Time:
+			if *v != nil {
+				t := s.localTime(**v)
+				*v = &t
+			}
+		case *sql.NullTime:
+			if v.Valid {
+				v.Time = s.localTime(v.Time)
+			}
+		case *kvScanner:
+			if t, ok := v.m[v.column].(time.Time); ok {
+				v.m[v.column] = s.localTime(t)
+			}
+		}
+	}
+
+	return nil
+}
+
+// localTime returns the given time in the scanner location, interpreting times in UTC as wall clock times.
+func (s *Scanner) localTime(t time.Time) time.Time {
+	switch {
+	case t.IsZero():
+		return t
+	case t.Location() == time.UTC:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), s.Location)
+	default:
+		return t.In(s.Location)
+	}
+}
+
+func (s *Scanner) scan(rows *sql.Rows, ptr ...interface{}) (err error) {
+	for x := 0; x < len(ptr); x++ {
+		ptr[x], _ = bigDest(ptr[x], s.NullAsZero)
+	}
+
+	if !s.NullAsZero {
+		return rows.Scan(ptr...)
+	}
+
+	type nullable struct {
+		dst reflect.Value
+		tmp reflect.Value
+	}
+
+	var fields []nullable
+	for x := 0; x < len(ptr); x++ {
+		t := reflect.TypeOf(ptr[x])
+		if t.Kind() != reflect.Ptr || t.Implements(typeScanner) {
+			continue
+		}
+
+		switch t.Elem().Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice:
+			continue // already hold NULL values as nil
+		}
+
+		// scan into a **T which is nil for NULL values
+		f := nullable{dst: reflect.ValueOf(ptr[x]).Elem(), tmp: reflect.New(t)}
+		fields = append(fields, f)
+		ptr[x] = f.tmp.Interface()
+	}
+
+	if err = rows.Scan(ptr...); err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if f.tmp.Elem().IsNil() {
+			f.dst.Set(reflect.Zero(f.dst.Type()))
+		} else {
+			f.dst.Set(f.tmp.Elem().Elem())
+		}
+	}
+
+	return nil
+}
+
 type dummyScanner struct{}
 
 func (dummyScanner) Scan(interface{}) error {
@@ -94,22 +411,56 @@ func (dummyScanner) Scan(interface{}) error {
 type keyValueMap map[string]interface{}
 
 type kvScanner struct {
-	column string
-	m      keyValueMap
+	column        string
+	bytesAsString bool
+	m             keyValueMap
 }
 
 func (kv *kvScanner) Scan(v interface{}) error {
 	if b, ok := v.([]byte); ok {
+		if kv.bytesAsString {
+			kv.m[kv.column] = string(b)
+			return nil
+		}
+
 		tmp := make([]byte, len(b))
 		copy(tmp, b)
 		kv.m[kv.column] = tmp
 	} else {
-		// int64, float64, bool, string, time.Time, nil
+		// int64, float64, bool, string, time.Time, nil (for NULL values)
 		kv.m[kv.column] = v
 	}
 	return nil
 }
 
+// isScalar returns true if the type, or the type it points to, is scanned from a single column.
+func isScalar(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t == typeTime || isBig(t) || reflect.PtrTo(t).Implements(typeScanner) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Ptr:
+		return false
+	}
+
+	return true
+}
+
+// checkScalar returns ErrColumnMismatch if the columns can't be scanned into the given type
+// because it is a scalar and there is more than one column.
+func checkScalar(columns []string, t reflect.Type) (err error) {
+	if len(columns) == 1 || !isScalar(t) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %d columns %v for %s, expected a single column", ErrColumnMismatch, len(columns), columns, t)
+}
+
 // PointersExtractor function type
 type PointersExtractor func(columns []string, value reflect.Value) []interface{}
 
@@ -117,23 +468,189 @@ var (
 	dummyDest       sql.Scanner = dummyScanner{}
 	typeScanner                 = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
 	typeKeyValueMap             = reflect.TypeOf(keyValueMap(nil))
+	typeTime                    = reflect.TypeOf(time.Time{})
 )
 
-func getStructFieldsExtractor(t reflect.Type) PointersExtractor {
-	mapping := StructMap(t)
+// positionalFields returns the index of the scanned fields of the given struct type in declaration order,
+// excluding the structs holding other mapped fields.
+func positionalFields(t reflect.Type) (fields [][]int) {
+	structTraverse(t, nil, "", camelCaseToSnakeCase, func(prefix, name string, index []int) {
+		// the enclosing struct is traversed before its fields
+		if n := len(fields); n > 0 && len(fields[n-1]) < len(index) && reflect.DeepEqual(fields[n-1], index[:len(fields[n-1])]) {
+			fields = fields[:n-1]
+		}
+		fields = append(fields, index)
+	})
+	return fields
+}
+
+// checkPositional returns ErrColumnMismatch if the number of columns does not match the number of fields
+// of the given struct type, or pointer to it, when mapping columns by position.
+func (s *Scanner) checkPositional(columns []string, t reflect.Type) (err error) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if !s.Positional || t.Kind() != reflect.Struct || isScalar(t) {
+		return nil
+	}
+
+	if n := len(positionalFields(t)); n != len(columns) {
+		return fmt.Errorf("%w: %s has %d fields, got %d columns: %v", ErrColumnMismatch, t, n, len(columns), columns)
+	}
+
+	return nil
+}
+
+// resolve returns the index of the struct field for each of the given columns, or nil for unmapped columns.
+// Columns are matched by name, and repeated or unmatched unqualified columns are matched by position
+// with the fields of the same name in declaration order, regardless of their prefix, so the nth `id`
+// column of a join is scanned into the nth `id` field.
+func (s *Scanner) resolve(columns []string, t reflect.Type) (fields [][]int) {
+	if s.Positional {
+		fields = make([][]int, len(columns))
+		copy(fields, positionalFields(t))
+		return fields
+	}
+
+	mapping := s.StructMap(t)
+	positions := map[string][][]int{}
+	structTraverse(t, nil, "", s.mapper(), func(prefix, name string, index []int) {
+		positions[name] = append(positions[name], index)
+	})
+
+	fields = make([][]int, len(columns))
+	seen := make(map[string]int, len(columns))
+
+	for x, c := range columns {
+		n := seen[c]
+		seen[c]++
+
+		if index, ok := mapping[c]; ok && n == 0 {
+			fields[x] = index
+			continue
+		}
+
+		if strings.IndexByte(c, '.') == -1 && n < len(positions[c]) {
+			fields[x] = positions[c][n]
+		}
+	}
+
+	return fields
+}
+
+func (s *Scanner) getStructFieldsExtractor(t reflect.Type) PointersExtractor {
+	mapping := s.StructMap(t)
+
+	// fields tagged as json and slices scanned as arrays are wrapped for scanning
+	wrap := map[string]func(ptr interface{}) interface{}{}
+	for key, index := range mapping {
+		field := t.FieldByIndex(index)
+		switch {
+		case IsJSON(field):
+			wrap[key] = func(ptr interface{}) interface{} { return JSON{V: ptr} }
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() != reflect.Uint8 &&
+			!reflect.PtrTo(field.Type).Implements(typeScanner):
+			wrap[key] = func(ptr interface{}) interface{} { return Array{V: ptr} }
+		}
+	}
+
+	// wrappers are looked up by field index, as columns resolved by position don't match a mapping key
+	wrapIndex := make(map[string]func(ptr interface{}) interface{}, len(wrap))
+	for key, w := range wrap {
+		wrapIndex[fmt.Sprint(mapping[key])] = w
+	}
+
+	// the columns are the same for every row of a result set, so they are resolved once
+	var last []string
+	var fields [][]int
+
 	return func(columns []string, value reflect.Value) []interface{} {
+		if !equalColumns(last, columns) {
+			last, fields = columns, s.resolve(columns, t)
+		}
+
 		var ptr []interface{}
-		for _, key := range columns {
-			if index, ok := mapping[key]; ok {
-				ptr = append(ptr, value.FieldByIndex(index).Addr().Interface())
-			} else {
+		for _, index := range fields {
+			if index == nil {
 				ptr = append(ptr, dummyDest)
+				continue
 			}
+
+			p := value.FieldByIndex(index).Addr().Interface()
+			if w, ok := wrapIndex[fmt.Sprint(index)]; ok {
+				p = w(p)
+			}
+			ptr = append(ptr, p)
 		}
 		return ptr
 	}
 }
 
+func equalColumns(a, b []string) bool {
+	if a == nil || len(a) != len(b) {
+		return false
+	}
+	for x := range a {
+		if a[x] != b[x] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsJSON returns true if the given struct field is tagged as a JSON column with `db:"column,json"`.
+func IsJSON(field reflect.StructField) bool {
+	return hasTagOption(field, "json")
+}
+
+// IsReadOnly returns true if the given struct field is tagged as a read only column with `db:"column,readonly"`,
+// like columns generated by the database, which are scanned but not written from records.
+func IsReadOnly(field reflect.StructField) bool {
+	return hasTagOption(field, "readonly")
+}
+
+// IsAuto returns true if the given struct field is tagged as a column generated on insert with `db:"column,auto"`,
+// like a serial primary key, which is scanned but not inserted from records.
+func IsAuto(field reflect.StructField) bool {
+	return hasTagOption(field, "auto")
+}
+
+// IsKey returns true if the given struct field is tagged as a key column with `db:"column,key"`,
+// like the columns of a composite primary key.
+func IsKey(field reflect.StructField) bool {
+	return hasTagOption(field, "key")
+}
+
+// KeyFields returns the columns and field indexes of the fields of the given struct type tagged as key
+// columns with `db:"column,key"`, in declaration order.
+func KeyFields(t reflect.Type) (columns []string, fields [][]int) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	structTraverse(t, nil, "", camelCaseToSnakeCase, func(prefix, name string, index []int) {
+		if IsKey(t.FieldByIndex(index)) {
+			columns = append(columns, prefix+name)
+			fields = append(fields, index)
+		}
+	})
+	return columns, fields
+}
+
+// hasTagOption returns true if the db tag of the given struct field has the option after its name.
+func hasTagOption(field reflect.StructField, option string) bool {
+	tag := field.Tag.Get("db")
+	if idx := strings.IndexByte(tag, ','); idx != -1 {
+		for _, opt := range strings.Split(tag[idx+1:], ",") {
+			if opt == option {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func getIndirectExtractor(extractor PointersExtractor) PointersExtractor {
 	return func(columns []string, value reflect.Value) []interface{} {
 		if value.IsNil() {
@@ -143,16 +660,18 @@ func getIndirectExtractor(extractor PointersExtractor) PointersExtractor {
 	}
 }
 
-func mapExtractor(columns []string, value reflect.Value) []interface{} {
-	if value.IsNil() {
-		value.Set(reflect.MakeMap(value.Type()))
-	}
-	m := value.Convert(typeKeyValueMap).Interface().(keyValueMap)
-	var ptr = make([]interface{}, 0, len(columns))
-	for _, c := range columns {
-		ptr = append(ptr, &kvScanner{column: c, m: m})
+func getMapExtractor(bytesAsString bool) PointersExtractor {
+	return func(columns []string, value reflect.Value) []interface{} {
+		if value.IsNil() {
+			value.Set(reflect.MakeMap(value.Type()))
+		}
+		m := value.Convert(typeKeyValueMap).Interface().(keyValueMap)
+		var ptr = make([]interface{}, 0, len(columns))
+		for _, c := range columns {
+			ptr = append(ptr, &kvScanner{column: c, bytesAsString: bytesAsString, m: m})
+		}
+		return ptr
 	}
-	return ptr
 }
 
 func dummyExtractor(columns []string, value reflect.Value) []interface{} {
@@ -160,8 +679,9 @@ func dummyExtractor(columns []string, value reflect.Value) []interface{} {
 }
 
 // FindExtractor returns a PointersExtractor for the given type
-func FindExtractor(t reflect.Type) (PointersExtractor, error) {
-	if reflect.PtrTo(t).Implements(typeScanner) {
+func (s *Scanner) FindExtractor(t reflect.Type) (PointersExtractor, error) {
+	// scalars and pointers to scalars are scanned directly, with pointers being nil for NULL values
+	if isScalar(t) {
 		return dummyExtractor, nil
 	}
 
@@ -170,15 +690,15 @@ func FindExtractor(t reflect.Type) (PointersExtractor, error) {
 		if !t.ConvertibleTo(typeKeyValueMap) {
 			return nil, fmt.Errorf("statement: expected %v, got %v", typeKeyValueMap, t)
 		}
-		return mapExtractor, nil
+		return getMapExtractor(s.BytesAsString), nil
 	case reflect.Ptr:
-		inner, err := FindExtractor(t.Elem())
+		inner, err := s.FindExtractor(t.Elem())
 		if err != nil {
 			return nil, err
 		}
 		return getIndirectExtractor(inner), nil
 	case reflect.Struct:
-		return getStructFieldsExtractor(t), nil
+		return s.getStructFieldsExtractor(t), nil
 	}
 
 	return dummyExtractor, nil
@@ -186,22 +706,56 @@ func FindExtractor(t reflect.Type) (PointersExtractor, error) {
 
 // StructMap builds index to fast lookup fields in struct
 func StructMap(t reflect.Type) map[string][]int {
-	if m, _ := structMapCache.Load(t); m != nil {
+	return defaultScanner.StructMap(t)
+}
+
+// StructMap builds index to fast lookup fields in struct.
+// Fields tagged with `db:"name"` are mapped to the tag name, and fields tagged with `db:"-"` are skipped.
+// Tag options after the name, like `db:"name,json"`, are not part of the name.
+// Other fields are mapped according to the Scanner Mapper. Fields of nested structs are also mapped,
+// with the first field found in declaration order taking precedence.
+// Nested struct fields tagged with a prefix ending in a dot, like `db:"a."`, are not mapped themselves
+// and their fields are mapped with the prefix, as `a.id`, to disambiguate the columns of joined tables.
+func (s *Scanner) StructMap(t reflect.Type) map[string][]int {
+	cache := &structMapCache
+	if s.Mapper != nil {
+		cache = &s.structMaps
+	}
+
+	if m, _ := cache.Load(t); m != nil {
 		return m.(map[string][]int)
 	}
 
 	m := make(map[string][]int)
-	structTraverse(m, t, nil)
+	structTraverse(t, nil, "", s.mapper(), func(prefix, name string, index []int) {
+		if _, ok := m[prefix+name]; !ok {
+			m[prefix+name] = index
+		}
+	})
+	cache.Store(t, m)
 	return m
 }
 
-func structTraverse(m map[string][]int, t reflect.Type, head []int) {
-	if t.Implements(typeValuer) {
+func (s *Scanner) mapper() func(string) string {
+	if s.Mapper != nil {
+		return s.Mapper
+	}
+	return camelCaseToSnakeCase
+}
+
+// structTraverse calls fn for each mapped field of the given type in declaration order,
+// with the prefix of the enclosing prefixed struct fields, the field name and its index.
+func structTraverse(t reflect.Type, head []int, prefix string, mapper func(string) string,
+	fn func(prefix, name string, index []int)) {
+	// values implementing sql.Scanner or driver.Valuer are scanned as a whole, including
+	// when implemented with pointer receivers, so their fields are not mapped
+	if t.Implements(typeValuer) || reflect.PtrTo(t).Implements(typeValuer) ||
+		reflect.PtrTo(t).Implements(typeScanner) {
 		return
 	}
 	switch t.Kind() {
 	case reflect.Ptr:
-		structTraverse(m, t.Elem(), head)
+		structTraverse(t.Elem(), head, prefix, mapper, fn)
 	case reflect.Struct:
 		for i := 0; i < t.NumField(); i++ {
 			field := t.Field(i)
@@ -209,17 +763,34 @@ func structTraverse(m map[string][]int, t reflect.Type, head []int) {
 				continue // not exported
 			}
 			tag := field.Tag.Get("db")
+			if idx := strings.IndexByte(tag, ','); idx != -1 {
+				tag = tag[:idx] // strip options like json
+			}
 			if tag == "-" {
 				continue // ignore
 			}
 			if tag == "" {
 				// no tag, but we can record the field name
-				tag = camelCaseToSnakeCase(field.Name)
+				tag = mapper(field.Name)
 			}
-			if _, ok := m[tag]; !ok {
-				m[tag] = append(head, i)
+
+			// copy the index to avoid sharing the head backing array between fields
+			index := make([]int, len(head)+1)
+			copy(index, head)
+			index[len(head)] = i
+
+			// prefixed struct fields only map their fields
+			if strings.HasSuffix(tag, ".") {
+				structTraverse(field.Type, index, prefix+tag, mapper, fn)
+				continue
+			}
+
+			fn(prefix, tag, index)
+
+			// json fields are scanned as a whole
+			if !IsJSON(field) {
+				structTraverse(field.Type, index, prefix, mapper, fn)
 			}
-			structTraverse(m, field.Type, append(head, i))
 		}
 	}
 }
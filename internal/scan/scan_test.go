@@ -0,0 +1,740 @@
+package scan
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+)
+
+type base struct {
+	ID        string
+	CreatedAt string `db:"created"`
+}
+
+type audit struct {
+	UpdatedBy string
+	Nested    struct {
+		Deep string
+	}
+}
+
+type user struct {
+	base
+	Audit    audit
+	Name     string `db:"user_name"`
+	Email    string
+	Password string `db:"-"`
+	internal string
+}
+
+func TestStructMap(t *testing.T) {
+	cases := []struct {
+		name   string
+		mapper func(string) string
+		expect map[string][]int
+	}{
+		{
+			name: "default",
+			expect: map[string][]int{
+				"base":       {0},
+				"id":         {0, 0},
+				"created":    {0, 1},
+				"audit":      {1},
+				"updated_by": {1, 0},
+				"nested":     {1, 1},
+				"deep":       {1, 1, 0},
+				"user_name":  {2},
+				"email":      {3},
+			},
+		},
+		{
+			name:   "mapper",
+			mapper: strings.ToUpper,
+			expect: map[string][]int{
+				"BASE":      {0},
+				"ID":        {0, 0},
+				"created":   {0, 1},
+				"AUDIT":     {1},
+				"UPDATEDBY": {1, 0},
+				"NESTED":    {1, 1},
+				"DEEP":      {1, 1, 0},
+				"user_name": {2},
+				"EMAIL":     {3},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Scanner{Mapper: tt.mapper}
+			m := s.StructMap(reflect.TypeOf(user{}))
+
+			if !reflect.DeepEqual(tt.expect, m) {
+				t.Fatalf("expected: %#v, got: %#v", tt.expect, m)
+			}
+		})
+	}
+}
+
+func TestCamelCaseToSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"ID":         "id",
+		"UserID":     "user_id",
+		"CreatedAt":  "created_at",
+		"HTTPServer": "http_server",
+		"Name":       "name",
+	}
+
+	for name, expect := range cases {
+		if got := camelCaseToSnakeCase(name); got != expect {
+			t.Errorf("expected: %s, got: %s", expect, got)
+		}
+	}
+}
+
+type row struct {
+	ID   int
+	Name string
+}
+
+// mockRows returns sql.Rows with n rows of id and name columns.
+func mockRows(tb testing.TB, n int) (rows *sql.Rows, done func()) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		tb.Fatalf("error opening mock database: %s", err)
+	}
+
+	r := sqlmock.NewRows([]string{"id", "name"})
+	for x := 0; x < n; x++ {
+		r.AddRow(x, "name"+strconv.Itoa(x))
+	}
+	mock.ExpectQuery("SELECT").WillReturnRows(r)
+
+	if rows, err = db.Query("SELECT id,name FROM rows"); err != nil {
+		tb.Fatalf("error querying mock database: %s", err)
+	}
+
+	return rows, func() { _ = db.Close() }
+}
+
+func TestLoadSlice(t *testing.T) {
+	t.Run("pointers", func(t *testing.T) {
+		rows, done := mockRows(t, 2)
+		defer done()
+
+		var dst []*row
+		n, err := Load(rows, &dst)
+		if err != nil {
+			t.Fatalf("error loading rows: %s", err)
+		}
+
+		expect := []*row{{ID: 0, Name: "name0"}, {ID: 1, Name: "name1"}}
+		if n != 2 || !reflect.DeepEqual(expect, dst) {
+			t.Fatalf("expected: %#v, got: %d, %#v", expect, n, dst)
+		}
+	})
+
+	t.Run("reuse", func(t *testing.T) {
+		rows, done := mockRows(t, 2)
+		defer done()
+
+		dst := make([]row, 1, 4)
+		dst[0] = row{ID: 10, Name: "existing"}
+		backing := &dst[:cap(dst)][0]
+
+		n, err := Load(rows, &dst)
+		if err != nil {
+			t.Fatalf("error loading rows: %s", err)
+		}
+
+		expect := []row{{ID: 10, Name: "existing"}, {ID: 0, Name: "name0"}, {ID: 1, Name: "name1"}}
+		if n != 2 || !reflect.DeepEqual(expect, dst) {
+			t.Fatalf("expected: %#v, got: %d, %#v", expect, n, dst)
+		}
+
+		if &dst[0] != backing {
+			t.Fatalf("expected the slice backing array to be reused")
+		}
+	})
+}
+
+func TestLoadScalarSlice(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer db.Close()
+
+	query := func(columns []string, values ...driver.Value) *sql.Rows {
+		r := sqlmock.NewRows(columns)
+		for _, v := range values {
+			r.AddRow(v)
+		}
+		mock.ExpectQuery("SELECT").WillReturnRows(r)
+
+		rows, err := db.Query("SELECT")
+		if err != nil {
+			t.Fatalf("error querying mock database: %s", err)
+		}
+		return rows
+	}
+
+	t.Run("int64", func(t *testing.T) {
+		var dst []int64
+		if _, err := Load(query([]string{"id"}, 1, 2, 3), &dst); err != nil {
+			t.Fatalf("error loading rows: %s", err)
+		}
+
+		if expect := []int64{1, 2, 3}; !reflect.DeepEqual(expect, dst) {
+			t.Fatalf("expected: %#v, got: %#v", expect, dst)
+		}
+	})
+
+	t.Run("string", func(t *testing.T) {
+		var dst []string
+		if _, err := Load(query([]string{"name"}, "a", "b"), &dst); err != nil {
+			t.Fatalf("error loading rows: %s", err)
+		}
+
+		if expect := []string{"a", "b"}; !reflect.DeepEqual(expect, dst) {
+			t.Fatalf("expected: %#v, got: %#v", expect, dst)
+		}
+	})
+
+	t.Run("string_pointers", func(t *testing.T) {
+		var dst []*string
+		if _, err := Load(query([]string{"name"}, "a", nil, "c"), &dst); err != nil {
+			t.Fatalf("error loading rows: %s", err)
+		}
+
+		a, c := "a", "c"
+		if expect := []*string{&a, nil, &c}; !reflect.DeepEqual(expect, dst) {
+			t.Fatalf("expected: %#v, got: %#v", expect, dst)
+		}
+	})
+
+	t.Run("time", func(t *testing.T) {
+		now := time.Now().UTC()
+
+		var dst []time.Time
+		if _, err := Load(query([]string{"created"}, now), &dst); err != nil {
+			t.Fatalf("error loading rows: %s", err)
+		}
+
+		if expect := []time.Time{now}; !reflect.DeepEqual(expect, dst) {
+			t.Fatalf("expected: %#v, got: %#v", expect, dst)
+		}
+	})
+
+	t.Run("multiple_columns", func(t *testing.T) {
+		rows, done := mockRows(t, 2)
+		defer done()
+
+		var dst []int64
+		if _, err := Load(rows, &dst); !errors.Is(err, ErrColumnMismatch) {
+			t.Fatalf("expected ErrColumnMismatch, got: %v", err)
+		}
+	})
+}
+
+func benchmarkLoad(b *testing.B, reuse bool) {
+	var dst []row
+	if reuse {
+		dst = make([]row, 0, 10000)
+	}
+
+	for x := 0; x < b.N; x++ {
+		b.StopTimer()
+		rows, done := mockRows(b, 10000)
+		b.StartTimer()
+
+		if reuse {
+			dst = dst[:0]
+		} else {
+			dst = nil
+		}
+
+		if _, err := Load(rows, &dst); err != nil {
+			b.Fatalf("error loading rows: %s", err)
+		}
+
+		b.StopTimer()
+		done()
+		b.StartTimer()
+	}
+}
+
+func BenchmarkLoadFreshSlice(b *testing.B) {
+	b.ReportAllocs()
+	benchmarkLoad(b, false)
+}
+
+func BenchmarkLoadReusedSlice(b *testing.B) {
+	b.ReportAllocs()
+	benchmarkLoad(b, true)
+}
+
+// profile implements sql.Scanner and driver.Valuer as a JSON column
+type profile struct {
+	Name string
+	Age  int
+}
+
+func (p *profile) Scan(src interface{}) error {
+	b, ok := src.([]byte)
+	if !ok {
+		b = []byte(src.(string))
+	}
+	return json.Unmarshal(b, p)
+}
+
+func (p *profile) Value() (driver.Value, error) {
+	return json.Marshal(p)
+}
+
+type account struct {
+	ID      int
+	Name    string
+	Profile profile
+}
+
+func TestLoadScanner(t *testing.T) {
+	m := StructMap(reflect.TypeOf(account{}))
+	expect := map[string][]int{"id": {0}, "name": {1}, "profile": {2}}
+	if !reflect.DeepEqual(expect, m) {
+		t.Fatalf("expected: %#v, got: %#v", expect, m)
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "name", "profile"}).AddRow(1, "john", `{"Name":"John Doe","Age":42}`))
+
+	rows, err := db.Query("SELECT id,name,profile FROM accounts")
+	if err != nil {
+		t.Fatalf("error querying mock database: %s", err)
+	}
+
+	var dst account
+	if _, err = Load(rows, &dst); err != nil {
+		t.Fatalf("error loading rows: %s", err)
+	}
+
+	if e := (account{ID: 1, Name: "john", Profile: profile{Name: "John Doe", Age: 42}}); !reflect.DeepEqual(e, dst) {
+		t.Fatalf("expected: %#v, got: %#v", e, dst)
+	}
+}
+
+func TestLoadBig(t *testing.T) {
+	type entry struct {
+		ID     int64
+		Amount big.Rat
+		Total  *big.Int
+		Rate   big.Float
+		Fee    *big.Rat
+	}
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT").WillReturnRows(
+		sqlmock.NewRows([]string{"id", "amount", "total", "rate", "fee"}).
+			AddRow(1, []byte("12345678901234567890.123456789"), "98765432109876543210", []byte("0.1000000000000000000001"), nil).
+			AddRow(2, int64(-7), int64(3), 0.5, "0.25"))
+
+	rows, err := db.Query("SELECT id,amount,total,rate,fee FROM ledger")
+	if err != nil {
+		t.Fatalf("error querying mock database: %s", err)
+	}
+
+	var dst []entry
+	if _, err = (&Scanner{Strict: true}).Load(rows, &dst); err != nil {
+		t.Fatalf("error loading rows: %s", err)
+	}
+
+	rat := func(s string) *big.Rat { r, _ := new(big.Rat).SetString(s); return r }
+	expect := []struct {
+		amount, fee *big.Rat
+		total, rate string
+	}{
+		{amount: rat("12345678901234567890.123456789"), total: "98765432109876543210", rate: "0.1000000000000000000001"},
+		{amount: rat("-7"), total: "3", rate: "0.5", fee: rat("0.25")},
+	}
+
+	if len(dst) != len(expect) {
+		t.Fatalf("expected %d rows, got: %d", len(expect), len(dst))
+	}
+
+	for x, e := range expect {
+		d := dst[x]
+		if d.Amount.Cmp(e.amount) != 0 || d.Total.String() != e.total || d.Rate.Text('f', -1) != e.rate ||
+			(e.fee == nil) != (d.Fee == nil) || (e.fee != nil && d.Fee.Cmp(e.fee) != 0) {
+			t.Fatalf("expected: %s %s %s %v, got: %s %s %s %v", e.amount.FloatString(9), e.total,
+				e.rate, e.fee, d.Amount.FloatString(9), d.Total, d.Rate.Text('f', -1), d.Fee)
+		}
+	}
+
+	// long fractional NUMERIC values are rounded once from their exact value
+	const pi = "3.14159265358979323846264338327950288419716939937510582097494459230781640628620899862803482534211706"
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"rate"}).AddRow([]byte(pi)))
+
+	if rows, err = db.Query("SELECT rate FROM ledger"); err != nil {
+		t.Fatalf("error querying mock database: %s", err)
+	}
+
+	var rate *big.Float
+	if _, err = LoadRow(rows, &rate); err != nil {
+		t.Fatalf("error loading row: %s", err)
+	}
+
+	exact := new(big.Float).SetPrec(rate.Prec()).SetRat(rat(pi))
+	if rate.Cmp(exact) != 0 || rate.Text('f', -1) != pi {
+		t.Fatalf("expected: %s, got: %s", pi, rate.Text('f', -1))
+	}
+
+	// NULL into a value
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"amount"}).AddRow(nil))
+
+	if rows, err = db.Query("SELECT amount FROM ledger"); err != nil {
+		t.Fatalf("error querying mock database: %s", err)
+	}
+
+	var amount big.Rat
+	if _, err = LoadRow(rows, &amount); !errors.Is(err, ErrInvalidType) {
+		t.Fatalf("expected ErrInvalidType, got: %v", err)
+	}
+}
+
+func TestLoadMaxRows(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("error opening mock database: %s", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2).AddRow(3))
+	mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1).AddRow(2))
+
+	rows, err := db.Query("SELECT id FROM events")
+	if err != nil {
+		t.Fatalf("error querying mock database: %s", err)
+	}
+
+	var ids []int64
+	s := &Scanner{MaxRows: 2}
+	if n, err := s.Load(rows, &ids); !errors.Is(err, ErrMaxRows) || n != 2 || len(ids) != 2 {
+		t.Fatalf("expected ErrMaxRows after 2 rows, got: %d rows, %v", n, err)
+	}
+
+	// at the limit
+	if rows, err = db.Query("SELECT id FROM events"); err != nil {
+		t.Fatalf("error querying mock database: %s", err)
+	}
+
+	ids = ids[:0]
+	if n, err := s.Load(rows, &ids); err != nil || n != 2 {
+		t.Fatalf("expected 2 rows, got: %d rows, %v", n, err)
+	}
+}
+
+type nullable struct {
+	Name     string
+	Age      int
+	Nickname *string
+	Email    sql.NullString
+}
+
+func TestLoadNullAsZero(t *testing.T) {
+	cases := []struct {
+		name    string
+		scanner *Scanner
+		wantErr bool
+	}{
+		{
+			name:    "null_as_zero",
+			scanner: &Scanner{NullAsZero: true},
+		},
+		{
+			name:    "null_error",
+			scanner: &Scanner{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("error opening mock database: %s", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery("SELECT").WillReturnRows(
+				sqlmock.NewRows([]string{"name", "age", "nickname", "email"}).
+					AddRow(nil, nil, nil, nil).
+					AddRow("john", 42, "johnny", "john@email.com"))
+
+			rows, err := db.Query("SELECT name,age,nickname,email FROM users")
+			if err != nil {
+				t.Fatalf("error querying mock database: %s", err)
+			}
+
+			dst := []nullable{{Name: "stale", Age: 1}}[:0]
+			_, err = tt.scanner.Load(rows, &dst)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error scanning NULL into string")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error loading rows: %s", err)
+			}
+
+			nickname := "johnny"
+			expect := []nullable{
+				{},
+				{Name: "john", Age: 42, Nickname: &nickname, Email: sql.NullString{String: "john@email.com", Valid: true}},
+			}
+
+			if !reflect.DeepEqual(expect, dst) {
+				t.Fatalf("expected: %#v, got: %#v", expect, dst)
+			}
+		})
+	}
+}
+
+func TestLoadStrict(t *testing.T) {
+	type user struct {
+		base
+		Name    string
+		Email   string
+		Profile profile
+		Ignored string `db:"-"`
+	}
+
+	cases := []struct {
+		name    string
+		columns []string
+		wantErr string
+	}{
+		{
+			name:    "match",
+			columns: []string{"id", "created", "name", "email", "profile"},
+		},
+		{
+			name:    "unmapped_column",
+			columns: []string{"id", "created", "name", "emial", "profile"},
+			wantErr: "columns without fields: [emial], fields without columns: [email]",
+		},
+		{
+			name:    "missing_columns",
+			columns: []string{"name"},
+			wantErr: "columns without fields: [], fields without columns: [created email id profile]",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("error opening mock database: %s", err)
+			}
+			defer db.Close()
+
+			values := make([]driver.Value, len(tt.columns))
+			for x := range values {
+				values[x] = "{}"
+			}
+			mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(tt.columns).AddRow(values...))
+
+			rows, err := db.Query("SELECT")
+			if err != nil {
+				t.Fatalf("error querying mock database: %s", err)
+			}
+
+			var dst []user
+			_, err = (&Scanner{Strict: true}).Load(rows, &dst)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("error loading rows: %s", err)
+				}
+				return
+			}
+
+			if !errors.Is(err, ErrColumnMismatch) || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected ErrColumnMismatch with %q, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadJoin(t *testing.T) {
+	type order struct {
+		ID   int
+		Name string
+	}
+
+	type customer struct {
+		ID    int
+		Total int
+	}
+
+	type prefixed struct {
+		order    `db:"a."`
+		Customer customer `db:"b."`
+	}
+
+	type positional struct {
+		order
+		Customer customer
+	}
+
+	cases := []struct {
+		name     string
+		columns  []string
+		row      []driver.Value
+		dst      interface{}
+		expected interface{}
+	}{
+		{
+			name:     "prefixed_columns",
+			columns:  []string{"b.total", "a.id", "a.name", "b.id"},
+			row:      []driver.Value{42, 1, "order", 2},
+			dst:      &[]prefixed{},
+			expected: &[]prefixed{{order{1, "order"}, customer{2, 42}}},
+		},
+		{
+			name:     "prefixed_positional",
+			columns:  []string{"id", "name", "id", "total"},
+			row:      []driver.Value{1, "order", 2, 42},
+			dst:      &[]prefixed{},
+			expected: &[]prefixed{{order{1, "order"}, customer{2, 42}}},
+		},
+		{
+			name:     "positional",
+			columns:  []string{"id", "name", "id", "total"},
+			row:      []driver.Value{1, "order", 2, 42},
+			dst:      &[]positional{},
+			expected: &[]positional{{order{1, "order"}, customer{2, 42}}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("error opening mock database: %s", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(tt.columns).AddRow(tt.row...))
+
+			rows, err := db.Query("SELECT")
+			if err != nil {
+				t.Fatalf("error querying mock database: %s", err)
+			}
+
+			if _, err = (&Scanner{Strict: true}).Load(rows, tt.dst); err != nil {
+				t.Fatalf("error loading rows: %s", err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, tt.dst) {
+				t.Fatalf("expected %#v, got: %#v", tt.expected, tt.dst)
+			}
+		})
+	}
+}
+
+func TestLoadPositional(t *testing.T) {
+	type amount struct {
+		Currency string
+		Value    float64
+	}
+
+	type line struct {
+		Product string `db:"name"`
+		Qty     int64
+		Total   amount
+		ignored bool
+		Skipped string `db:"-"`
+	}
+
+	cases := []struct {
+		name     string
+		columns  []string
+		dst      interface{}
+		expected interface{}
+		err      error
+	}{
+		{
+			name:     "computed",
+			columns:  []string{"product", "qty", "currency", "price * qty AS total"},
+			dst:      &[]line{},
+			expected: &[]line{{Product: "widget", Qty: 3, Total: amount{"EUR", 29.97}}},
+		},
+		{
+			name:     "row",
+			columns:  []string{"product", "qty", "currency", "price * qty AS total"},
+			dst:      &line{},
+			expected: &line{Product: "widget", Qty: 3, Total: amount{"EUR", 29.97}},
+		},
+		{
+			name:    "mismatch",
+			columns: []string{"product", "qty", "price * qty AS total"},
+			dst:     &[]line{},
+			err:     ErrColumnMismatch,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("error opening mock database: %s", err)
+			}
+			defer db.Close()
+
+			row := []driver.Value{"widget", 3, "EUR", 29.97}[:len(tt.columns)]
+			mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(tt.columns).AddRow(row...))
+
+			rows, err := db.Query("SELECT")
+			if err != nil {
+				t.Fatalf("error querying mock database: %s", err)
+			}
+
+			_, err = (&Scanner{Positional: true, Strict: true}).Load(rows, tt.dst)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected %v, got: %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error loading rows: %s", err)
+			}
+
+			if !reflect.DeepEqual(tt.expected, tt.dst) {
+				t.Fatalf("expected %#v, got: %#v", tt.expected, tt.dst)
+			}
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package statement
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brunotm/norm/internal/buffer"
+)
+
+// Count returns a `COUNT(expr)` aggregate expression for use in columns and conditions.
+func Count(expr string) string {
+	return "COUNT(" + expr + ")"
+}
+
+// CountDistinct returns a `COUNT(DISTINCT expr)` aggregate expression for use in columns and conditions.
+func CountDistinct(expr string) string {
+	return "COUNT(DISTINCT " + expr + ")"
+}
+
+// Sum returns a `SUM(expr)` aggregate expression for use in columns and conditions.
+func Sum(expr string) string {
+	return "SUM(" + expr + ")"
+}
+
+// Avg returns a `AVG(expr)` aggregate expression for use in columns and conditions.
+func Avg(expr string) string {
+	return "AVG(" + expr + ")"
+}
+
+// Min returns a `MIN(expr)` aggregate expression for use in columns and conditions.
+func Min(expr string) string {
+	return "MIN(" + expr + ")"
+}
+
+// Max returns a `MAX(expr)` aggregate expression for use in columns and conditions.
+func Max(expr string) string {
+	return "MAX(" + expr + ")"
+}
+
+// As returns a `expr AS alias` column for use with Columns, where expr can be a column, an expression like
+// Count("*") or Window(...), or a Statement with bound arguments, as a Case, Cond or *SelectStatement subquery.
+// The alias is quoted as an identifier, with WithQuoting or if marked with Quote, and can be referenced by
+// OrderAsc, OrderDesc and GroupBy, though grouping by an alias returns ErrUnsupported on Oracle and SQLServer.
+func As(expr interface{}, alias string) Statement {
+	return &aliasExpr{expr: expr, alias: alias}
+}
+
+// aliasExpr is an aliased column expression.
+type aliasExpr struct {
+	expr  interface{}
+	alias string
+}
+
+// String builds the aliased expression and returns the resulting query.
+func (a *aliasExpr) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = a.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// Build builds the aliased expression into the given buffer.
+func (a *aliasExpr) Build(buf Buffer) (err error) {
+	switch e := a.expr.(type) {
+	case *SelectStatement, *CompoundStatement:
+		_, _ = buf.WriteString("(")
+		if err = e.(Statement).Build(buf); err != nil {
+			return err
+		}
+		_, _ = buf.WriteString(")")
+	case Statement:
+		if err = e.Build(buf); err != nil {
+			return err
+		}
+	case string:
+		writeIdent(buf, e)
+	default:
+		return fmt.Errorf("statement: invalid alias expression type: %T, alias: %s", a.expr, a.alias)
+	}
+
+	_, _ = buf.WriteString(" AS ")
+	writeIdent(buf, a.alias)
+	return nil
+}
+
+// AggregateExpr is an aggregate function expression with an optional `FILTER (WHERE cond)` clause,
+// created with Aggregate.
+type AggregateExpr struct {
+	fn     string
+	expr   string
+	filter []Statement
+}
+
+// Aggregate creates a `fn(expr)` aggregate expression for use in columns and conditions, as Aggregate("COUNT", "*"),
+// Aggregate("SUM", "total") or Aggregate("COUNT", "DISTINCT user_id"), whose rows can be filtered with Filter.
+func Aggregate(fn, expr string) *AggregateExpr {
+	return &AggregateExpr{fn: fn, expr: expr}
+}
+
+// Filter adds a condition to the aggregate `FILTER (WHERE cond)` clause, multiple conditions are combined with `AND`.
+// The clause is built natively on the Postgres and SQLite dialects, and as `fn(CASE WHEN cond THEN expr END)`
+// on the others, which is equivalent as aggregates ignore NULL values, or `CASE WHEN cond THEN 1 END` for `*`.
+func (a *AggregateExpr) Filter(q string, values ...interface{}) *AggregateExpr {
+	a.filter = append(a.filter, &Part{Query: q, Values: values})
+	return a
+}
+
+// FilterCond adds a condition created with Cond, And, Or, In and the other condition helpers to the aggregate
+// `FILTER (WHERE cond)` clause.
+func (a *AggregateExpr) FilterCond(cond Statement) *AggregateExpr {
+	a.filter = append(a.filter, cond)
+	return a
+}
+
+// String builds the aggregate expression and returns the resulting query.
+func (a *AggregateExpr) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = a.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// Build builds the aggregate expression into the given buffer.
+func (a *AggregateExpr) Build(buf Buffer) (err error) {
+	_, _ = buf.WriteString(a.fn)
+	_, _ = buf.WriteString("(")
+
+	if len(a.filter) == 0 {
+		_, _ = buf.WriteString(a.expr)
+		_, _ = buf.WriteString(")")
+		return nil
+	}
+
+	switch dialectOf(buf) {
+	case Postgres, SQLite:
+		_, _ = buf.WriteString(a.expr)
+		_, _ = buf.WriteString(") FILTER (WHERE ")
+		if err = a.buildFilter(buf); err != nil {
+			return err
+		}
+		_, _ = buf.WriteString(")")
+
+	default:
+		expr := a.expr
+		if f := strings.Fields(expr); len(f) > 1 && strings.EqualFold(f[0], "DISTINCT") {
+			_, _ = buf.WriteString("DISTINCT ")
+			expr = strings.TrimSpace(expr[len(f[0]):])
+		}
+
+		if expr == "*" {
+			expr = "1"
+		}
+
+		_, _ = buf.WriteString("CASE WHEN ")
+		if err = a.buildFilter(buf); err != nil {
+			return err
+		}
+		_, _ = buf.WriteString(" THEN ")
+		_, _ = buf.WriteString(expr)
+		_, _ = buf.WriteString(" END)")
+	}
+
+	return nil
+}
+
+// buildFilter builds the filter conditions combined with `AND`.
+func (a *AggregateExpr) buildFilter(buf Buffer) (err error) {
+	for x := 0; x < len(a.filter); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(" AND ")
+		}
+
+		if err = a.filter[x].Build(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
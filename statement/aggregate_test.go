@@ -0,0 +1,207 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAggregate(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		args    []interface{}
+	}{
+		{
+			name:    "count_having",
+			dialect: Postgres,
+			stmt: Select().Columns("dept", As(Count("*"), "total")).From("employees").
+				Where("active = ?", true).GroupBy("dept").HavingCond(Cond(Count("*")+" > ?", 5)),
+			expect: `SELECT dept,COUNT(*) AS total FROM employees WHERE active = $1 GROUP BY dept HAVING COUNT(*) > $2`,
+			args:   []interface{}{true, 5},
+		},
+		{
+			name:    "aggregates",
+			dialect: MySQL,
+			stmt: Select().Columns("dept", "role", CountDistinct("manager_id"), Sum("salary"), Avg("salary"), Min("age"), Max("age")).
+				From("employees").GroupBy("dept", "role").
+				Having(Sum("salary")+" > ?", 1000).
+				HavingCond(Or(Cond(Avg("age")+" < ?", 30), Cond(Max("age")+" > ?", 60))),
+			expect: `SELECT dept,role,COUNT(DISTINCT manager_id),SUM(salary),AVG(salary),MIN(age),MAX(age) FROM employees GROUP BY dept,role HAVING SUM(salary) > ? AND (AVG(age) < ? OR MAX(age) > ?)`,
+			args:   []interface{}{1000, 30, 60},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestAs(t *testing.T) {
+	totals := Select().Columns("dept", As(Count("*"), "employeeCount"), As(Sum("salary"), "order")).
+		From("employees").Where("active = ?", true).GroupBy("dept").OrderDesc("employeeCount")
+
+	cases := []struct {
+		name    string
+		opts    []Option
+		stmt    Parameterized
+		expect  string
+		args    []interface{}
+		wantErr error
+	}{
+		{
+			name:   "unquoted",
+			opts:   []Option{WithDialect(Postgres)},
+			stmt:   totals,
+			expect: `SELECT dept,COUNT(*) AS employeeCount,SUM(salary) AS order FROM employees WHERE active = $1 GROUP BY dept ORDER BY employeeCount DESC`,
+			args:   []interface{}{true},
+		},
+		{
+			name:   "quoted",
+			opts:   []Option{WithDialect(Postgres), WithQuoting()},
+			stmt:   totals,
+			expect: `SELECT dept,COUNT(*) AS "employeeCount",SUM(salary) AS "order" FROM employees WHERE active = $1 GROUP BY dept ORDER BY "employeeCount" DESC`,
+			args:   []interface{}{true},
+		},
+		{
+			name:   "quoted_mysql",
+			opts:   []Option{WithDialect(MySQL), WithQuoting()},
+			stmt:   totals,
+			expect: "SELECT dept,COUNT(*) AS `employeeCount`,SUM(salary) AS `order` FROM employees WHERE active = ? GROUP BY dept ORDER BY `employeeCount` DESC",
+			args:   []interface{}{true},
+		},
+		{
+			name: "expressions",
+			opts: []Option{WithDialect(Postgres)},
+			stmt: Select().Columns(
+				"id",
+				As(Case().When(Cond("total > ?", 100), "high").Else("low"), "tier"),
+				As(Select().Columns(Count("*")).From("items").Where("items.order_id = orders.id AND qty > ?", 1), "items"),
+				As(Cond("total * ?", 1.2), "gross"),
+				As("customer_id", "customer"),
+			).From("orders").Where("status = ?", "paid").OrderAsc("tier"),
+			expect: `SELECT id,CASE WHEN total > $1 THEN $2 ELSE $3 END AS tier,(SELECT COUNT(*) FROM items WHERE items.order_id = orders.id AND qty > $4) AS items,total * $5 AS gross,customer_id AS customer FROM orders WHERE status = $6 ORDER BY tier ASC`,
+			args:   []interface{}{100, "high", "low", 1, 1.2, "paid"},
+		},
+		{
+			name:   "group_by_alias",
+			opts:   []Option{WithDialect(MySQL)},
+			stmt:   Select().Columns(As("YEAR(created)", "year"), As(Count("*"), "n")).From("orders").GroupBy("year").OrderAsc("year"),
+			expect: `SELECT YEAR(created) AS year,COUNT(*) AS n FROM orders GROUP BY year ORDER BY year ASC`,
+		},
+		{
+			name:    "group_by_alias_sqlserver",
+			opts:    []Option{WithDialect(SQLServer)},
+			stmt:    Select().Columns(As("YEAR(created)", "year"), As(Count("*"), "n")).From("orders").GroupBy("year").OrderAsc("year"),
+			wantErr: ErrUnsupported,
+		},
+		{
+			name:    "strict_expression",
+			opts:    []Option{WithStrictIdents()},
+			stmt:    Select().Columns(As(Count("*"), "n")).From("orders"),
+			wantErr: ErrInvalidIdent,
+		},
+		{
+			name:   "strict_column",
+			opts:   []Option{WithStrictIdents()},
+			stmt:   Select().Columns(As("o.id", "order_id"), As(Cond(Count("*")), "n")).From("orders o"),
+			expect: `SELECT o.id AS order_id,COUNT(*) AS n FROM orders o`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(tt.opts...)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestAggregateFilter(t *testing.T) {
+	stmt := Select().Columns(
+		"customer_id",
+		As(Aggregate("COUNT", "*").Filter("status = ?", "paid"), "paid"),
+		As(Aggregate("SUM", "total").Filter("status = ?", "refunded").FilterCond(Cond("total > ?", 100)), "refunded"),
+		As(Aggregate("COUNT", "DISTINCT product_id").FilterCond(In("region", "eu", "us")), "products"),
+		As(Aggregate("MAX", "total"), "largest"),
+	).From("orders").Where("created > ?", "2024-01-01").GroupBy("customer_id").
+		HavingCond(Cond(Sum("total")+" > ?", 1000))
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		expect  string
+	}{
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			expect:  `SELECT customer_id,COUNT(*) FILTER (WHERE status = $1) AS paid,SUM(total) FILTER (WHERE status = $2 AND total > $3) AS refunded,COUNT(DISTINCT product_id) FILTER (WHERE region IN ($4,$5)) AS products,MAX(total) AS largest FROM orders WHERE created > $6 GROUP BY customer_id HAVING SUM(total) > $7`,
+		},
+		{
+			name:    "sqlite",
+			dialect: SQLite,
+			expect:  `SELECT customer_id,COUNT(*) FILTER (WHERE status = ?) AS paid,SUM(total) FILTER (WHERE status = ? AND total > ?) AS refunded,COUNT(DISTINCT product_id) FILTER (WHERE region IN (?,?)) AS products,MAX(total) AS largest FROM orders WHERE created > ? GROUP BY customer_id HAVING SUM(total) > ?`,
+		},
+		{
+			name:    "mysql",
+			dialect: MySQL,
+			expect:  `SELECT customer_id,COUNT(CASE WHEN status = ? THEN 1 END) AS paid,SUM(CASE WHEN status = ? AND total > ? THEN total END) AS refunded,COUNT(DISTINCT CASE WHEN region IN (?,?) THEN product_id END) AS products,MAX(total) AS largest FROM orders WHERE created > ? GROUP BY customer_id HAVING SUM(total) > ?`,
+		},
+		{
+			name:    "sqlserver",
+			dialect: SQLServer,
+			expect:  `SELECT customer_id,COUNT(CASE WHEN status = @p1 THEN 1 END) AS paid,SUM(CASE WHEN status = @p2 AND total > @p3 THEN total END) AS refunded,COUNT(DISTINCT CASE WHEN region IN (@p4,@p5) THEN product_id END) AS products,MAX(total) AS largest FROM orders WHERE created > @p6 GROUP BY customer_id HAVING SUM(total) > @p7`,
+		},
+	}
+
+	args := []interface{}{"paid", "refunded", 100, "eu", "us", "2024-01-01", 1000}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, a, err := stmt.SQL(WithDialect(tt.dialect))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(args, a) {
+				t.Fatalf("expected args: %#v, got: %#v", args, a)
+			}
+		})
+	}
+}
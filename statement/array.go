@@ -0,0 +1,45 @@
+package statement
+
+import (
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/brunotm/norm/internal/scan"
+)
+
+// Valuer is a value that can be both bound as a statement argument and scanned from a column.
+type Valuer interface {
+	driver.Valuer
+	sql.Scanner
+}
+
+// Array wraps the given slice for binding as a Postgres array, like int[] or text[], using the
+// text encoding of github.com/lib/pq, where nested slices are bound as multidimensional arrays.
+// It can also be scanned into when wrapping a pointer to a slice.
+// Slice fields of records and of scanned structs, other than []byte, are handled as arrays. Records with
+// slice fields return ErrUnsupported on dialects other than Postgres and Default.
+func Array(slice interface{}) Valuer {
+	return scan.Array{V: slice}
+}
+
+// recordArray is a slice field of a record, bound as an Array on the Postgres and Default dialects.
+type recordArray struct {
+	scan.Array
+}
+
+func (a recordArray) build(buf Buffer, keyword bool) (err error) {
+	switch d := dialectOf(buf); d {
+	case Default, Postgres:
+	default:
+		return unsupported("array record field", d)
+	}
+
+	return writeValue(buf, a.Array, keyword)
+}
+
+// JSON wraps the given value for binding as a JSON encoded column, like Postgres json and jsonb columns.
+// It can also be scanned into when wrapping a pointer. Record and scanned struct fields tagged with
+// `db:"column,json"` are handled as JSON.
+func JSON(v interface{}) Valuer {
+	return scan.JSON{V: v}
+}
@@ -0,0 +1,130 @@
+package statement
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestArrayJSON(t *testing.T) {
+	type settings struct {
+		Theme string `json:"theme"`
+	}
+
+	type user struct {
+		ID       int
+		Roles    []string
+		Settings settings `db:"settings,json"`
+	}
+
+	cases := []struct {
+		name   string
+		stmt   Parameterized
+		expect string
+		query  string
+		args   []driver.Value
+	}{
+		{
+			name:   "array_arg",
+			stmt:   Select().Columns("id").From("users").Where("roles && ?", Array([]string{"admin", "ops"})),
+			expect: `SELECT id FROM users WHERE roles && '{"admin","ops"}'`,
+			query:  `SELECT id FROM users WHERE roles && $1`,
+			args:   []driver.Value{`{"admin","ops"}`},
+		},
+		{
+			name:   "json_arg",
+			stmt:   Update().Table("users").Set("settings", JSON(settings{Theme: "dark"})).Where("id = ?", 1),
+			expect: `UPDATE users SET settings = '{"theme":"dark"}' WHERE id = 1`,
+			query:  `UPDATE users SET settings = $1 WHERE id = $2`,
+			args:   []driver.Value{`{"theme":"dark"}`, int64(1)},
+		},
+		{
+			name:   "record",
+			stmt:   Insert().Into("users").Record(user{ID: 1, Roles: []string{"admin"}, Settings: settings{Theme: "dark"}}),
+			expect: `INSERT INTO users(id,roles,settings) VALUES (1,'{"admin"}','{"theme":"dark"}')`,
+			query:  `INSERT INTO users(id,roles,settings) VALUES ($1,$2,$3)`,
+			args:   []driver.Value{int64(1), `{"admin"}`, `{"theme":"dark"}`},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := tt.stmt.String()
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != s {
+				t.Fatalf("expected: %s, got: %s", tt.expect, s)
+			}
+
+			q, args, err := tt.stmt.SQL(WithDialect(Postgres))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.query != q {
+				t.Fatalf("expected: %s, got: %s", tt.query, q)
+			}
+
+			// arguments are bound as the driver values of the wrapped values
+			values := make([]driver.Value, len(args))
+			for x := range args {
+				if v, ok := args[x].(driver.Valuer); ok {
+					if values[x], err = v.Value(); err != nil {
+						t.Fatalf("error converting argument: %s", err)
+					}
+				} else {
+					values[x] = int64(args[x].(int))
+				}
+			}
+
+			if !reflect.DeepEqual(tt.args, values) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, values)
+			}
+		})
+	}
+}
+
+func TestArrayRecordDialects(t *testing.T) {
+	type user struct {
+		ID    int
+		Roles []string
+	}
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+	}{
+		{
+			name:    "mysql_record",
+			dialect: MySQL,
+			stmt:    Insert().Into("users").Record(user{ID: 1, Roles: []string{"admin"}}),
+		},
+		{
+			name:    "sqlite_set_struct",
+			dialect: SQLite,
+			stmt:    Update().Table("users").SetStruct(user{ID: 1, Roles: []string{"admin"}}).Where("id = ?", 1),
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := tt.stmt.SQL(WithDialect(tt.dialect)); !errors.Is(err, ErrUnsupported) {
+				t.Fatalf("expected ErrUnsupported, got: %v", err)
+			}
+		})
+	}
+
+	// explicit arrays are bound as is
+	q, args, err := Insert().Into("users").Columns("id", "roles").Values(1, Array([]string{"admin"})).SQL(WithDialect(MySQL))
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	if expect := "INSERT INTO users(id,roles) VALUES (?,?)"; expect != q || len(args) != 2 {
+		t.Fatalf("expected: %s with 2 args, got: %s with %d args", expect, q, len(args))
+	}
+}
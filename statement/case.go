@@ -0,0 +1,126 @@
+package statement
+
+import (
+	"github.com/brunotm/norm/internal/buffer"
+)
+
+// CaseStatement is a `CASE WHEN cond THEN result ... ELSE result END` expression,
+// for use in columns, conditions and ordering.
+type CaseStatement struct {
+	operand *Part
+	when    []caseWhen
+	els     interface{}
+	hasElse bool
+	alias   string
+}
+
+type caseWhen struct {
+	cond   interface{}
+	result interface{}
+}
+
+// Case creates a new searched `CASE WHEN cond THEN result ... END` expression.
+func Case() *CaseStatement {
+	return &CaseStatement{}
+}
+
+// CaseOf creates a new simple `CASE expr WHEN value THEN result ... END` expression,
+// comparing the given expression to the value of each When.
+func CaseOf(expr string, values ...interface{}) *CaseStatement {
+	return &CaseStatement{operand: &Part{Query: expr, Values: values}}
+}
+
+// When adds a `WHEN cond THEN result` branch. On a searched Case the condition is a query fragment
+// without arguments or a Statement like Cond("age > ?", 18), while on a CaseOf it is a value compared to the expression.
+// Results are bound as arguments, or built in place if they are a Statement or an Ident.
+func (s *CaseStatement) When(cond, result interface{}) *CaseStatement {
+	s.when = append(s.when, caseWhen{cond: cond, result: result})
+	return s
+}
+
+// Else adds a `ELSE result` branch.
+func (s *CaseStatement) Else(result interface{}) *CaseStatement {
+	s.els, s.hasElse = result, true
+	return s
+}
+
+// As adds a `AS alias` to the expression, for use in the `SELECT` columns.
+func (s *CaseStatement) As(alias string) *CaseStatement {
+	s.alias = alias
+	return s
+}
+
+// Build builds the expression into the given buffer.
+func (s *CaseStatement) Build(buf Buffer) (err error) {
+	if len(s.when) == 0 {
+		return incomplete("CASE", "WHEN")
+	}
+
+	_, _ = buf.WriteString("CASE")
+
+	if s.operand != nil {
+		_, _ = buf.WriteString(" ")
+		if err = s.operand.Build(buf); err != nil {
+			return err
+		}
+	}
+
+	for x := 0; x < len(s.when); x++ {
+		_, _ = buf.WriteString(" WHEN ")
+
+		switch cond := s.when[x].cond.(type) {
+		case string:
+			if s.operand == nil {
+				_, _ = buf.WriteString(cond)
+				break
+			}
+			err = buildValue(buf, cond, false)
+		case Statement:
+			err = cond.Build(buf)
+		default:
+			err = buildValue(buf, cond, false)
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, _ = buf.WriteString(" THEN ")
+		if err = buildValue(buf, s.when[x].result, false); err != nil {
+			return err
+		}
+	}
+
+	if s.hasElse {
+		_, _ = buf.WriteString(" ELSE ")
+		if err = buildValue(buf, s.els, false); err != nil {
+			return err
+		}
+	}
+
+	_, _ = buf.WriteString(" END")
+
+	if s.alias != "" {
+		_, _ = buf.WriteString(" AS ")
+		writeIdent(buf, s.alias)
+	}
+
+	return nil
+}
+
+// String builds the expression and returns the resulting query string.
+func (s *CaseStatement) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// SQL builds the expression and returns the resulting parameterized query and arguments.
+func (s *CaseStatement) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
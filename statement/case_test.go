@@ -0,0 +1,60 @@
+package statement
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCase(t *testing.T) {
+	cases := []struct {
+		name   string
+		stmt   Parameterized
+		expect string
+		args   []interface{}
+	}{
+		{
+			name: "searched",
+			stmt: Select().Columns("id",
+				Case().When("age < 18", "minor").When(Cond("age >= ?", 65), "senior").Else("adult").As("category")).
+				From("users"),
+			expect: `SELECT id,CASE WHEN age < 18 THEN $1 WHEN age >= $2 THEN $3 ELSE $4 END AS category FROM users`,
+			args:   []interface{}{"minor", 65, "senior", "adult"},
+		},
+		{
+			name: "simple",
+			stmt: Select().Columns("id",
+				CaseOf("status").When(1, "active").When(2, "inactive").Else(Ident("status")).As("status")).
+				From("users").Where("id = ?", 7),
+			expect: `SELECT id,CASE status WHEN $1 THEN $2 WHEN $3 THEN $4 ELSE status END AS status FROM users WHERE id = $5`,
+			args:   []interface{}{1, "active", 2, "inactive", 7},
+		},
+		{
+			name: "order_by",
+			stmt: Select().Columns("id").From("tickets").
+				OrderDesc("priority").OrderExpr(CaseOf("status").When("open", 0).Else(1)),
+			expect: `SELECT id FROM tickets ORDER BY priority,CASE status WHEN $1 THEN $2 ELSE $3 END DESC`,
+			args:   []interface{}{"open", 0, 1},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(Postgres))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+
+	if _, err := Case().String(); err == nil {
+		t.Fatalf("expected error building case without branches")
+	}
+}
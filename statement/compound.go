@@ -0,0 +1,184 @@
+package statement
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/brunotm/norm/internal/buffer"
+)
+
+// CompoundStatement combines the results of statements with set operations,
+// like `UNION`, `UNION ALL`, `INTERSECT` and `EXCEPT`.
+type CompoundStatement struct {
+	limitCount  int64
+	offsetCount int64
+	order       string
+	orderBy     []string
+	ops         []string
+	stmts       []Statement
+}
+
+// Union creates a `a UNION b` compound statement.
+func Union(a, b Statement) *CompoundStatement {
+	return compound(a).Union(b)
+}
+
+// UnionAll creates a `a UNION ALL b` compound statement.
+func UnionAll(a, b Statement) *CompoundStatement {
+	return compound(a).UnionAll(b)
+}
+
+// Intersect creates a `a INTERSECT b` compound statement.
+func Intersect(a, b Statement) *CompoundStatement {
+	return compound(a).Intersect(b)
+}
+
+// Except creates a `a EXCEPT b` compound statement, built as `a MINUS b` on the Oracle dialect.
+func Except(a, b Statement) *CompoundStatement {
+	return compound(a).Except(b)
+}
+
+func compound(stmt Statement) *CompoundStatement {
+	return &CompoundStatement{stmts: []Statement{stmt}}
+}
+
+// Union adds a `UNION stmt` to the compound statement.
+func (s *CompoundStatement) Union(stmt Statement) *CompoundStatement {
+	return s.add("UNION", stmt)
+}
+
+// UnionAll adds a `UNION ALL stmt` to the compound statement.
+func (s *CompoundStatement) UnionAll(stmt Statement) *CompoundStatement {
+	return s.add("UNION ALL", stmt)
+}
+
+// Intersect adds a `INTERSECT stmt` to the compound statement.
+func (s *CompoundStatement) Intersect(stmt Statement) *CompoundStatement {
+	return s.add("INTERSECT", stmt)
+}
+
+// Except adds a `EXCEPT stmt` to the compound statement, built as `MINUS stmt` on the Oracle dialect.
+func (s *CompoundStatement) Except(stmt Statement) *CompoundStatement {
+	return s.add("EXCEPT", stmt)
+}
+
+func (s *CompoundStatement) add(op string, stmt Statement) *CompoundStatement {
+	s.ops = append(s.ops, op)
+	s.stmts = append(s.stmts, stmt)
+	return s
+}
+
+// OrderAsc adds a `ORDER BY columns ASC` clause applied to the whole compound statement.
+func (s *CompoundStatement) OrderAsc(columns ...string) *CompoundStatement {
+	s.orderBy = columns
+	s.order = "ASC"
+	return s
+}
+
+// OrderDesc adds a `ORDER BY columns DESC` clause applied to the whole compound statement.
+func (s *CompoundStatement) OrderDesc(columns ...string) *CompoundStatement {
+	s.orderBy = columns
+	s.order = "DESC"
+	return s
+}
+
+// Limit adds a `LIMIT n` clause applied to the whole compound statement, built for the dialect as with
+// SelectStatement.Limit. On SQLServer the compound statement must be ordered.
+func (s *CompoundStatement) Limit(n int64) *CompoundStatement {
+	s.limitCount = n
+	return s
+}
+
+// Offset adds a `OFFSET n` clause, only if LIMIT is also set.
+func (s *CompoundStatement) Offset(n int64) *CompoundStatement {
+	s.offsetCount = n
+	return s
+}
+
+// Clone returns a copy of the statement that can be modified without affecting the original,
+// allowing variants to be derived from a shared base statement.
+// The combined statements are shared by both statements.
+func (s *CompoundStatement) Clone() *CompoundStatement {
+	c := *s
+	c.orderBy = append(s.orderBy[:0:0], s.orderBy...)
+	c.ops = append(s.ops[:0:0], s.ops...)
+	c.stmts = append(s.stmts[:0:0], s.stmts...)
+	return &c
+}
+
+// Build builds the statement into the given buffer.
+// It returns ErrColumnCount if the combined statements project a different number of columns,
+// when the number of columns are known from plain column names.
+func (s *CompoundStatement) Build(buf Buffer) (err error) {
+	count := columnCount(s.stmts[0])
+
+	for x := 0; x < len(s.stmts); x++ {
+		if x > 0 {
+			op := s.ops[x-1]
+			if op == "EXCEPT" && dialectOf(buf) == Oracle {
+				op = "MINUS"
+			}
+
+			_, _ = buf.WriteString(" ")
+			_, _ = buf.WriteString(op)
+			_, _ = buf.WriteString(" ")
+
+			if n := columnCount(s.stmts[x]); count != -1 && n != -1 && count != n {
+				return fmt.Errorf("%w: expected %d, got %d", ErrColumnCount, count, n)
+			}
+		}
+
+		if err = s.stmts[x].Build(buf); err != nil {
+			return err
+		}
+	}
+
+	if len(s.orderBy) > 0 {
+		_, _ = buf.WriteString(" ORDER BY ")
+		writeIdents(buf, s.orderBy)
+		_, _ = buf.WriteString(" ")
+		_, _ = buf.WriteString(s.order)
+	}
+
+	return buildLimit(buf, s.limitCount, s.offsetCount, len(s.orderBy) > 0)
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *CompoundStatement) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// SQL builds the statement and returns the resulting parameterized query and arguments.
+func (s *CompoundStatement) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
+
+// columnCount returns the number of columns projected by the given statement,
+// or -1 if it can't be known without parsing column expressions.
+func columnCount(stmt Statement) (n int) {
+	switch s := stmt.(type) {
+	case *CompoundStatement:
+		return columnCount(s.stmts[0])
+	case *SelectStatement:
+		if len(s.columns) == 0 {
+			return -1
+		}
+
+		for _, c := range s.columns {
+			c, ok := c.(string)
+			if !ok || strings.ContainsAny(c, ",*(") {
+				return -1
+			}
+		}
+		return len(s.columns)
+	}
+
+	return -1
+}
@@ -0,0 +1,95 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCompound(t *testing.T) {
+	users := Select().Columns("id", "email").From("users").Where("tenant_id = ?", 42)
+	admins := Select().Columns("id", "email").From("admins").Where("tenant_id = ?", 42)
+	banned := Select().Columns("id", "email").From("banned").Where("reason = ?", "spam")
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    *CompoundStatement
+		expect  string
+		args    []interface{}
+		wantErr error
+	}{
+		{
+			name:    "union",
+			dialect: Postgres,
+			stmt:    Union(users, admins),
+			expect:  `SELECT id,email FROM users WHERE tenant_id = $1 UNION SELECT id,email FROM admins WHERE tenant_id = $2`,
+			args:    []interface{}{42, 42},
+		},
+		{
+			name:    "union_all_except_order_limit",
+			dialect: Postgres,
+			stmt:    UnionAll(users, admins).Except(banned).OrderAsc("email").Limit(10).Offset(20),
+			expect:  `SELECT id,email FROM users WHERE tenant_id = $1 UNION ALL SELECT id,email FROM admins WHERE tenant_id = $2 EXCEPT SELECT id,email FROM banned WHERE reason = $3 ORDER BY email ASC LIMIT 10 OFFSET 20`,
+			args:    []interface{}{42, 42, "spam"},
+		},
+		{
+			name:    "intersect",
+			dialect: MySQL,
+			stmt:    Intersect(users, admins),
+			expect:  `SELECT id,email FROM users WHERE tenant_id = ? INTERSECT SELECT id,email FROM admins WHERE tenant_id = ?`,
+			args:    []interface{}{42, 42},
+		},
+		{
+			name:    "except_oracle",
+			dialect: Oracle,
+			stmt:    Except(users, banned),
+			expect:  `SELECT id,email FROM users WHERE tenant_id = :1 MINUS SELECT id,email FROM banned WHERE reason = :2`,
+			args:    []interface{}{42, "spam"},
+		},
+		{
+			name:    "nested",
+			dialect: SQLite,
+			stmt:    Union(Intersect(users, admins), banned),
+			expect:  `SELECT id,email FROM users WHERE tenant_id = ? INTERSECT SELECT id,email FROM admins WHERE tenant_id = ? UNION SELECT id,email FROM banned WHERE reason = ?`,
+			args:    []interface{}{42, 42, "spam"},
+		},
+		{
+			name:    "unknown_column_count",
+			dialect: Postgres,
+			stmt:    Union(users, Select().Columns("*").From("admins")),
+			expect:  `SELECT id,email FROM users WHERE tenant_id = $1 UNION SELECT * FROM admins`,
+			args:    []interface{}{42},
+		},
+		{
+			name:    "column_count_mismatch",
+			dialect: Postgres,
+			stmt:    Union(users, Select().Columns("id").From("admins")),
+			wantErr: ErrColumnCount,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected error: %v, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package statement
+
+import (
+	"fmt"
+
+	"github.com/brunotm/norm/internal/buffer"
+)
+
+// Cond creates a condition from the given query fragment and values for use with WhereCond, And and Or.
+// Conditions combining multiple predicates with `OR` should be wrapped with parentheses or built with Or.
+func Cond(q string, values ...interface{}) Statement {
+	return &Part{Query: q, Values: values}
+}
+
+// In creates a `column IN (values)` condition. A single slice value is expanded into its elements,
+// a single Statement value is built as a `column IN (SELECT ...)` subquery,
+// and an empty list of values creates a constant false `1=0` condition.
+func In(column string, values ...interface{}) Statement {
+	return buildWhereIn(column, values...)
+}
+
+// Exists creates a `EXISTS (stmt)` condition, where the statement can be a correlated subquery
+// referencing the tables of the outer statement.
+func Exists(stmt Statement) Statement {
+	return &Part{Query: "EXISTS ?", Values: []interface{}{stmt}}
+}
+
+// NotExists creates a `NOT EXISTS (stmt)` condition, where the statement can be a correlated subquery
+// referencing the tables of the outer statement.
+func NotExists(stmt Statement) Statement {
+	return &Part{Query: "NOT EXISTS ?", Values: []interface{}{stmt}}
+}
+
+// Between creates a `column BETWEEN start AND end` condition.
+func Between(column string, start, end interface{}) Statement {
+	return &Part{Query: "? BETWEEN ? AND ?", Values: []interface{}{columnIdent(column), start, end}}
+}
+
+// Like creates a `column LIKE pattern` condition.
+func Like(column string, pattern interface{}) Statement {
+	return &Part{Query: "? LIKE ?", Values: []interface{}{columnIdent(column), pattern}}
+}
+
+// IsNull creates a `column IS NULL` condition.
+func IsNull(column string) Statement {
+	return &Part{Query: "? IS NULL", Values: []interface{}{columnIdent(column)}}
+}
+
+// IsNotNull creates a `column IS NOT NULL` condition.
+func IsNotNull(column string) Statement {
+	return &Part{Query: "? IS NOT NULL", Values: []interface{}{columnIdent(column)}}
+}
+
+// And creates a `(cond AND cond...)` group of conditions.
+// An empty group creates a constant true `1=1` condition.
+func And(conds ...Statement) Statement {
+	return &group{op: " AND ", conds: conds}
+}
+
+// Or creates a `(cond OR cond...)` group of conditions.
+// An empty group creates a constant false `1=0` condition.
+func Or(conds ...Statement) Statement {
+	return &group{op: " OR ", conds: conds}
+}
+
+// group represents a group of conditions combined by a logical operator.
+type group struct {
+	op    string
+	conds []Statement
+}
+
+// Build builds the statement into the given buffer.
+func (s *group) Build(buf Buffer) (err error) {
+	switch len(s.conds) {
+	case 0:
+		if s.op == " AND " {
+			_, _ = buf.WriteString("1=1")
+		} else {
+			_, _ = buf.WriteString("1=0")
+		}
+		return nil
+	case 1:
+		return s.conds[0].Build(buf)
+	}
+
+	_, _ = buf.WriteString("(")
+	for x := 0; x < len(s.conds); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(s.op)
+		}
+
+		if err = s.conds[x].Build(buf); err != nil {
+			return err
+		}
+	}
+	_, _ = buf.WriteString(")")
+
+	return nil
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *group) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// keyset represents a keyset pagination condition.
+type keyset struct {
+	desc    bool
+	columns []string
+	values  []interface{}
+}
+
+// Build builds the statement into the given buffer.
+// Row value comparisons are expanded into `OR` groups on the Oracle and SQLServer dialects,
+// which don't support them.
+func (s *keyset) Build(buf Buffer) (err error) {
+	if len(s.columns) == 0 || len(s.columns) != len(s.values) {
+		return fmt.Errorf("%w: keyset columns %v, values %#v", ErrInvalidArgNumber, s.columns, s.values)
+	}
+
+	op := " > "
+	if s.desc {
+		op = " < "
+	}
+
+	if len(s.columns) == 1 {
+		writeIdent(buf, s.columns[0])
+		_, _ = buf.WriteString(op)
+		return buildValue(buf, s.values[0], false)
+	}
+
+	switch dialectOf(buf) {
+	case Oracle, SQLServer:
+		// (a > ?) OR (a = ? AND b > ?) ...
+		_, _ = buf.WriteString("(")
+		for x := 0; x < len(s.columns); x++ {
+			if x > 0 {
+				_, _ = buf.WriteString(" OR ")
+			}
+
+			_, _ = buf.WriteString("(")
+			for y := 0; y < x; y++ {
+				writeIdent(buf, s.columns[y])
+				_, _ = buf.WriteString(" = ")
+				if err = buildValue(buf, s.values[y], false); err != nil {
+					return err
+				}
+				_, _ = buf.WriteString(" AND ")
+			}
+
+			writeIdent(buf, s.columns[x])
+			_, _ = buf.WriteString(op)
+			if err = buildValue(buf, s.values[x], false); err != nil {
+				return err
+			}
+			_, _ = buf.WriteString(")")
+		}
+		_, _ = buf.WriteString(")")
+		return nil
+	}
+
+	_, _ = buf.WriteString("(")
+	writeIdents(buf, s.columns)
+	_, _ = buf.WriteString(")")
+	_, _ = buf.WriteString(op)
+	_, _ = buf.WriteString("(")
+	for x := 0; x < len(s.values); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(",")
+		}
+
+		if err = buildValue(buf, s.values[x], false); err != nil {
+			return err
+		}
+	}
+	_, _ = buf.WriteString(")")
+
+	return nil
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *keyset) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,413 @@
+package statement
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestCond(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		args    []interface{}
+	}{
+		{
+			name:    "in_empty",
+			dialect: Postgres,
+			stmt:    Select().Columns("id").From("users").WhereCond(In("role", []string{})),
+			expect:  `SELECT id FROM users WHERE 1=0`,
+		},
+		{
+			name:    "where_in_empty",
+			dialect: Postgres,
+			stmt:    Delete().From("users").WhereIn("id"),
+			expect:  `DELETE FROM users WHERE 1=0`,
+		},
+		{
+			name:    "in_slice",
+			dialect: Postgres,
+			stmt:    Select().Columns("id").From("users").Where("tenant_id = ?", 42).WhereCond(In("role", []string{"admin", "owner", "user"})),
+			expect:  `SELECT id FROM users WHERE tenant_id = $1 AND role IN ($2,$3,$4)`,
+			args:    []interface{}{42, "admin", "owner", "user"},
+		},
+		{
+			name:    "in_values",
+			dialect: SQLServer,
+			stmt:    Update().Table("users").Set("active", false).WhereCond(In("id", 1, 2, 3)),
+			expect:  `UPDATE users SET active = @p1 WHERE id IN (@p2,@p3,@p4)`,
+			args:    []interface{}{false, 1, 2, 3},
+		},
+		{
+			name:    "nested_group",
+			dialect: Postgres,
+			stmt: Select().Columns("id").From("users").WhereCond(
+				And(Cond("tenant_id = ?", 42), Or(Like("email", "%@email.com"), IsNull("email"))),
+			),
+			expect: `SELECT id FROM users WHERE (tenant_id = $1 AND (email LIKE $2 OR email IS NULL))`,
+			args:   []interface{}{42, "%@email.com"},
+		},
+		{
+			name:    "between_not_null",
+			dialect: MySQL,
+			stmt:    Select().Columns("id").From("events").WhereCond(Between("created_at", 10, 20)).WhereCond(IsNotNull("user_id")),
+			expect:  `SELECT id FROM events WHERE created_at BETWEEN ? AND ? AND user_id IS NOT NULL`,
+			args:    []interface{}{10, 20},
+		},
+		{
+			name:    "single_and_empty_groups",
+			dialect: Postgres,
+			stmt:    Select().Columns("id").From("users").WhereCond(Or(Cond("id = ?", 1))).WhereCond(And()).WhereCond(Or()),
+			expect:  `SELECT id FROM users WHERE id = $1 AND 1=1 AND 1=0`,
+			args:    []interface{}{1},
+		},
+		{
+			name:    "scalar_subquery",
+			dialect: Postgres,
+			stmt: Select().Columns("id").Column("? AS orders", Select().Columns(Count("*")).From("orders o").Where("o.customer_id = c.id")).
+				From("customers c").Where("c.tenant_id = ?", 1).
+				WhereCond(Cond("c.total > ?", Select().Columns(Avg("total")).From("customers").Where("tenant_id = ?", 1))),
+			expect: `SELECT id,(SELECT COUNT(*) FROM orders o WHERE o.customer_id = c.id) AS orders FROM customers c WHERE c.tenant_id = $1 AND c.total > (SELECT AVG(total) FROM customers WHERE tenant_id = $2)`,
+			args:   []interface{}{1, 1},
+		},
+		{
+			name:    "in_subquery",
+			dialect: Postgres,
+			stmt: Select().Columns("id").From("users").Where("active = ?", true).
+				WhereCond(In("role_id", Select().Columns("id").From("roles").Where("name = ?", "admin"))),
+			expect: `SELECT id FROM users WHERE active = $1 AND role_id IN (SELECT id FROM roles WHERE name = $2)`,
+			args:   []interface{}{true, "admin"},
+		},
+		{
+			name:    "exists_correlated",
+			dialect: Postgres,
+			stmt: Select().Columns("c.id").From("customers c").Where("c.tenant_id = ?", 1).
+				WhereCond(Exists(Select().Columns("1").From("orders o").Where("o.customer_id = c.id AND o.total > ?", 100))).
+				WhereCond(NotExists(Select().Columns("1").From("bans b").Where("b.customer_id = c.id"))),
+			expect: `SELECT c.id FROM customers c WHERE c.tenant_id = $1 AND EXISTS (SELECT 1 FROM orders o WHERE o.customer_id = c.id AND o.total > $2) AND NOT EXISTS (SELECT 1 FROM bans b WHERE b.customer_id = c.id)`,
+			args:   []interface{}{1, 100},
+		},
+		{
+			name:    "derived_table",
+			dialect: Postgres,
+			stmt: Select().Columns("t.dept", Max("t.total")).
+				FromAs(Select().Columns("dept", As(Sum("salary"), "total")).From("employees").Where("active = ?", true).GroupBy("dept"), "t").
+				Where("t.total > ?", 1000).GroupBy("t.dept"),
+			expect: `SELECT t.dept,MAX(t.total) FROM ( SELECT dept,SUM(salary) AS total FROM employees WHERE active = $1 GROUP BY dept ) AS t WHERE t.total > $2 GROUP BY t.dept`,
+			args:   []interface{}{true, 1000},
+		},
+		{
+			name:    "derived_table_oracle",
+			dialect: Oracle,
+			stmt:    Select().Columns("t.id").FromAs(Select().Columns("id").From("users"), "t"),
+			expect:  `SELECT t.id FROM ( SELECT id FROM users ) t`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestKeysetAfter(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    *SelectStatement
+		expect  string
+		args    []interface{}
+		wantErr bool
+	}{
+		{
+			name:    "composite_asc",
+			dialect: Postgres,
+			stmt: Select().Columns("id", "created_at").From("events").Where("tenant_id = ?", 42).
+				KeysetAfter([]string{"created_at", "id"}, []interface{}{100, 7}).Limit(50),
+			expect: `SELECT id,created_at FROM events WHERE tenant_id = $1 AND (created_at,id) > ($2,$3) ORDER BY created_at ASC,id ASC LIMIT 50 OFFSET 0`,
+			args:   []interface{}{42, 100, 7},
+		},
+		{
+			name:    "composite_desc",
+			dialect: MySQL,
+			stmt: Select().Columns("id", "created_at").From("events").OrderDesc("created_at", "id").
+				KeysetAfter([]string{"created_at", "id"}, []interface{}{100, 7}).Limit(50),
+			expect: `SELECT id,created_at FROM events WHERE (created_at,id) < (?,?) ORDER BY created_at DESC,id DESC LIMIT 50 OFFSET 0`,
+			args:   []interface{}{100, 7},
+		},
+		{
+			name:    "single",
+			dialect: SQLite,
+			stmt:    Select().Columns("id").From("events").KeysetAfter([]string{"id"}, []interface{}{7}).Limit(10),
+			expect:  `SELECT id FROM events WHERE id > ? ORDER BY id ASC LIMIT 10 OFFSET 0`,
+			args:    []interface{}{7},
+		},
+		{
+			name:    "composite_sqlserver",
+			dialect: SQLServer,
+			stmt:    Select().Columns("id").From("events").KeysetAfter([]string{"created_at", "id"}, []interface{}{100, 7}),
+			expect:  `SELECT id FROM events WHERE ((created_at > @p1) OR (created_at = @p2 AND id > @p3)) ORDER BY created_at ASC,id ASC`,
+			args:    []interface{}{100, 100, 7},
+		},
+		{
+			name:    "mismatched_values",
+			dialect: Postgres,
+			stmt:    Select().Columns("id").From("events").KeysetAfter([]string{"created_at", "id"}, []interface{}{100}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidArgNumber) {
+					t.Fatalf("expected ErrInvalidArgNumber, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestCondIdents(t *testing.T) {
+	stmt := Select().Columns("id").From("events").
+		WhereCond(In("order", 1, 2)).
+		WhereCond(In("user", Select().Columns("id").From("users"))).
+		WhereCond(Between("e.Created", 10, 20)).
+		WhereCond(Like("group", "a%")).
+		WhereCond(Or(IsNull("deleted_at"), IsNotNull("Archived"))).
+		WhereIn("user", 3)
+
+	q, args, err := stmt.SQL(WithDialect(Postgres), WithQuoting())
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	expect := `SELECT id FROM events WHERE "order" IN ($1,$2) AND "user" IN (SELECT id FROM users) AND e."Created" BETWEEN $3 AND $4 AND "group" LIKE $5 AND (deleted_at IS NULL OR "Archived" IS NOT NULL) AND "user" IN ($6)`
+	if expect != q {
+		t.Fatalf("expected: %s, got: %s", expect, q)
+	}
+
+	if expectArgs := []interface{}{1, 2, 10, 20, "a%", 3}; !reflect.DeepEqual(expectArgs, args) {
+		t.Fatalf("expected args: %#v, got: %#v", expectArgs, args)
+	}
+
+	column := "id = 1 OR 1"
+	for _, cond := range []Statement{In(column, 1), Between(column, 1, 2), Like(column, "a"), IsNull(column), IsNotNull(column)} {
+		if _, _, err = Select().Columns("id").From("events").WhereCond(cond).SQL(WithStrictIdents()); !errors.Is(err, ErrInvalidIdent) {
+			t.Fatalf("expected ErrInvalidIdent, got: %v", err)
+		}
+	}
+}
+
+func TestKeysetIdents(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect Dialect
+		columns []string
+		values  []interface{}
+		expect  string
+	}{
+		{
+			name:    "single",
+			dialect: Postgres,
+			columns: []string{"order"},
+			values:  []interface{}{7},
+			expect:  `SELECT id FROM events WHERE "order" > $1 ORDER BY "order" ASC`,
+		},
+		{
+			name:    "composite",
+			dialect: Postgres,
+			columns: []string{"user", "id"},
+			values:  []interface{}{1, 7},
+			expect:  `SELECT id FROM events WHERE ("user",id) > ($1,$2) ORDER BY "user" ASC,id ASC`,
+		},
+		{
+			name:    "composite_sqlserver",
+			dialect: SQLServer,
+			columns: []string{"user", "id"},
+			values:  []interface{}{1, 7},
+			expect:  `SELECT id FROM events WHERE (([user] > @p1) OR ([user] = @p2 AND id > @p3)) ORDER BY [user] ASC,id ASC`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := Select().Columns("id").From("events").KeysetAfter(tt.columns, tt.values)
+
+			q, _, err := stmt.SQL(WithDialect(tt.dialect), WithQuoting())
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			// an invalid identifier in any position fails on every keyset shape
+			columns := append([]string{}, tt.columns...)
+			columns[len(columns)-1] = "id; DROP TABLE events"
+			stmt = Select().Columns("id").From("events").KeysetAfter(columns, tt.values)
+
+			if _, _, err = stmt.SQL(WithDialect(tt.dialect), WithStrictIdents()); !errors.Is(err, ErrInvalidIdent) {
+				t.Fatalf("expected ErrInvalidIdent, got: %v", err)
+			}
+		})
+	}
+}
+
+func TestWhereKey(t *testing.T) {
+	type membership struct {
+		TenantID int64  `db:"tenant_id,key"`
+		UserID   int64  `db:"user_id,key"`
+		Role     string `db:"role"`
+	}
+
+	type assignment struct {
+		TenantID int64   `db:"tenant_id,key"`
+		Region   *string `db:"region,key"`
+	}
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		args    []interface{}
+		err     error
+	}{
+		{
+			name:    "map",
+			dialect: Postgres,
+			stmt:    Select().Columns("role").From("memberships").WhereKey(map[string]interface{}{"user_id": 7, "tenant_id": 42}),
+			expect:  `SELECT role FROM memberships WHERE (tenant_id = $1 AND user_id = $2)`,
+			args:    []interface{}{42, 7},
+		},
+		{
+			name:    "struct",
+			dialect: Postgres,
+			stmt:    Update().Table("memberships").Set("role", "owner").WhereKeyStruct(&membership{TenantID: 42, UserID: 7, Role: "admin"}),
+			expect:  `UPDATE memberships SET role = $1 WHERE (tenant_id = $2 AND user_id = $3)`,
+			args:    []interface{}{"owner", int64(42), int64(7)},
+		},
+		{
+			name:    "struct_and_where",
+			dialect: MySQL,
+			stmt:    Delete().From("memberships").Where("role = ?", "guest").WhereKeyStruct(membership{TenantID: 42, UserID: 7}),
+			expect:  "DELETE FROM memberships WHERE role = ? AND (tenant_id = ? AND user_id = ?)",
+			args:    []interface{}{"guest", int64(42), int64(7)},
+		},
+		{
+			name:    "single_column_null",
+			dialect: Postgres,
+			stmt:    Select().Columns("id").From("users").WhereCond(Key(map[string]interface{}{"deleted_at": nil})),
+			expect:  `SELECT id FROM users WHERE deleted_at IS NULL`,
+		},
+		{
+			name:    "struct_nil_pointer",
+			dialect: Postgres,
+			stmt:    Delete().From("assignments").WhereKeyStruct(assignment{TenantID: 42}),
+			expect:  `DELETE FROM assignments WHERE (tenant_id = $1 AND region IS NULL)`,
+			args:    []interface{}{int64(42)},
+		},
+		{
+			name:    "map_typed_nil_and_null_valuer",
+			dialect: MySQL,
+			stmt: Select().Columns("id").From("users").
+				WhereKey(map[string]interface{}{"email": (*string)(nil), "name": sql.NullString{}, "tenant_id": 42}),
+			expect: "SELECT id FROM users WHERE (email IS NULL AND name IS NULL AND tenant_id = ?)",
+			args:   []interface{}{42},
+		},
+		{
+			name:    "empty_map",
+			dialect: Postgres,
+			stmt:    Delete().From("memberships").WhereKey(nil),
+			err:     ErrIncomplete,
+		},
+		{
+			name:    "struct_without_keys",
+			dialect: Postgres,
+			stmt:    Delete().From("memberships").WhereKeyStruct(struct{ ID int }{ID: 1}),
+			err:     ErrIncomplete,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected error %s, got: %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestWhereKeyIdents(t *testing.T) {
+	stmt := Select().Columns("id").From("events").WhereKey(map[string]interface{}{"order": 7, "user": nil, "id": 1})
+
+	q, args, err := stmt.SQL(WithDialect(Postgres), WithQuoting())
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	expect := `SELECT id FROM events WHERE (id = $1 AND "order" = $2 AND "user" IS NULL)`
+	if expect != q {
+		t.Fatalf("expected: %s, got: %s", expect, q)
+	}
+
+	if expectArgs := []interface{}{1, 7}; !reflect.DeepEqual(expectArgs, args) {
+		t.Fatalf("expected args: %#v, got: %#v", expectArgs, args)
+	}
+
+	for _, cond := range []Statement{
+		Key(map[string]interface{}{"id = 1 OR 1": 1}),
+		Key(map[string]interface{}{"tenant_id": 42, "id; DROP TABLE users": nil}),
+	} {
+		if _, _, err = Select().Columns("id").From("events").WhereCond(cond).SQL(WithStrictIdents()); !errors.Is(err, ErrInvalidIdent) {
+			t.Fatalf("expected ErrInvalidIdent, got: %v", err)
+		}
+	}
+}
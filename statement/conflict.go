@@ -0,0 +1,93 @@
+package statement
+
+import (
+	"sort"
+)
+
+// Excluded references the value proposed for insertion for the given column within a conflict
+// update clause. It is built as `EXCLUDED.column`, or `VALUES(column)` on the MySQL dialect.
+type Excluded string
+
+func (e Excluded) build(buf Buffer) (err error) {
+	if dialectOf(buf) == MySQL {
+		_, _ = buf.WriteString("VALUES(")
+		writeIdent(buf, string(e))
+		_, _ = buf.WriteString(")")
+		return nil
+	}
+
+	_, _ = buf.WriteString("EXCLUDED.")
+	writeIdent(buf, string(e))
+	return nil
+}
+
+// conflict represents a dialect aware `ON CONFLICT` or `ON DUPLICATE KEY UPDATE` clause.
+type conflict struct {
+	nothing bool
+	target  []string
+	update  map[string]interface{}
+}
+
+// build builds the clause into the given buffer, the insert columns
+// are required to emulate DO NOTHING on the MySQL dialect.
+func (s *conflict) build(buf Buffer, columns []string) (err error) {
+	switch d := dialectOf(buf); d {
+	case Oracle, SQLServer:
+		return unsupported("ON CONFLICT", d)
+
+	case MySQL:
+		if len(s.target) > 0 {
+			return unsupported("ON CONFLICT target", d)
+		}
+
+		_, _ = buf.WriteString("ON DUPLICATE KEY UPDATE ")
+		if s.nothing {
+			if len(columns) == 0 {
+				return unsupported("ON CONFLICT DO NOTHING without columns", d)
+			}
+
+			writeIdent(buf, columns[0])
+			_, _ = buf.WriteString(" = ")
+			writeIdent(buf, columns[0])
+			return nil
+		}
+
+	default:
+		if !s.nothing && len(s.target) == 0 {
+			return unsupported("ON CONFLICT DO UPDATE without target", d)
+		}
+
+		_, _ = buf.WriteString("ON CONFLICT ")
+		if len(s.target) > 0 {
+			_, _ = buf.WriteString("(")
+			writeIdents(buf, s.target)
+			_, _ = buf.WriteString(") ")
+		}
+
+		if s.nothing {
+			_, _ = buf.WriteString("DO NOTHING")
+			return nil
+		}
+		_, _ = buf.WriteString("DO UPDATE SET ")
+	}
+
+	sorted := make([]string, 0, len(s.update))
+	for k := range s.update {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	for x := 0; x < len(sorted); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		writeIdent(buf, sorted[x])
+		_, _ = buf.WriteString(" = ")
+
+		if err = buildValue(buf, s.update[sorted[x]], false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
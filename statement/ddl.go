@@ -11,16 +11,15 @@ type DDL struct {
 // Comment adds a SQL comment to the generated query.
 // Each call to comment creates a new `-- <comment>` line.
 func (s *DDL) Comment(c string, values ...interface{}) *DDL {
-	buf := buffer.New()
-	defer buf.Release()
-
-	_, _ = buf.WriteString("-- ")
-	_, _ = buf.WriteString(c)
+	s.comment = append(s.comment, buildComment(c, values...))
+	return s
+}
 
-	p := &Part{}
-	p.Query = buf.String()
-	p.Values = values
-	s.comment = append(s.comment, p)
+// Tag adds a `/*key='value'*/` comment with the given tags in the sqlcommenter format to the generated query,
+// like `/*action='list',controller='orders'*/`, to correlate queries in the database logs with the code that issued them.
+// As comments, tags are built before the statement in their own line.
+func (s *DDL) Tag(tags map[string]string) *DDL {
+	s.comment = append(s.comment, buildTag(tags))
 	return s
 }
 
@@ -88,6 +87,13 @@ func Truncate(query string, values ...interface{}) *DDL {
 	}
 }
 
+// Clone returns a copy of the statement that can be modified without affecting the original.
+func (s *DDL) Clone() *DDL {
+	c := *s
+	c.comment = append(s.comment[:0:0], s.comment...)
+	return &c
+}
+
 // Build builds the statement into the given buffer.
 func (s *DDL) Build(buf Buffer) (err error) {
 	for x := 0; x < len(s.comment); x++ {
@@ -110,3 +116,195 @@ func (s *DDL) String() (q string, err error) {
 
 	return buf.String(), nil
 }
+
+// SQL builds the statement and returns the resulting query.
+// DDL statements do not support bound arguments, so values are always interpolated.
+func (s *DDL) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
+
+// TruncateStatement is a `TRUNCATE TABLE` statement.
+type TruncateStatement struct {
+	tables   []string
+	cascade  bool
+	identity bool
+}
+
+// TruncateTable creates a new `TRUNCATE TABLE tables` statement, built according to the dialect.
+// The MySQL, SQLServer and Oracle dialects support truncating a single table, and the SQLite dialect,
+// which doesn't support `TRUNCATE`, is built as `DELETE FROM table`.
+func TruncateTable(tables ...string) *TruncateStatement {
+	return &TruncateStatement{tables: tables}
+}
+
+// Cascade adds a `CASCADE` clause, also truncating the tables with foreign keys to the truncated tables.
+// It is only supported on the Default, Postgres and Oracle dialects.
+func (s *TruncateStatement) Cascade() *TruncateStatement {
+	s.cascade = true
+	return s
+}
+
+// RestartIdentity adds a `RESTART IDENTITY` clause, resetting the sequences owned by the truncated tables.
+// It is only supported on the Default and Postgres dialects, and is implicit on the MySQL and SQLServer dialects.
+func (s *TruncateStatement) RestartIdentity() *TruncateStatement {
+	s.identity = true
+	return s
+}
+
+// Build builds the statement into the given buffer.
+func (s *TruncateStatement) Build(buf Buffer) (err error) {
+	if len(s.tables) == 0 {
+		return incomplete("TRUNCATE", "tables")
+	}
+
+	d := dialectOf(buf)
+	switch {
+	case len(s.tables) > 1 && d != Default && d != Postgres:
+		return unsupported("TRUNCATE of multiple tables", d)
+	case s.cascade && d != Default && d != Postgres && d != Oracle:
+		return unsupported("TRUNCATE CASCADE", d)
+	case s.identity && (d == SQLite || d == Oracle):
+		return unsupported("TRUNCATE RESTART IDENTITY", d)
+	}
+
+	if d == SQLite {
+		_, _ = buf.WriteString("DELETE FROM ")
+		writeIdent(buf, s.tables[0])
+		return nil
+	}
+
+	_, _ = buf.WriteString("TRUNCATE TABLE ")
+	writeIdents(buf, s.tables)
+
+	if s.identity && (d == Default || d == Postgres) {
+		_, _ = buf.WriteString(" RESTART IDENTITY")
+	}
+
+	if s.cascade {
+		_, _ = buf.WriteString(" CASCADE")
+	}
+
+	return nil
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *TruncateStatement) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// SQL builds the statement and returns the resulting query.
+func (s *TruncateStatement) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
+
+// IndexStatement is a `CREATE INDEX` or `DROP INDEX` statement.
+type IndexStatement struct {
+	drop    bool
+	unique  bool
+	exists  bool
+	name    string
+	table   string
+	columns []string
+}
+
+// CreateIndex creates a new `CREATE INDEX name ON table (columns)` statement.
+func CreateIndex(name, table string, columns ...string) *IndexStatement {
+	return &IndexStatement{name: name, table: table, columns: columns}
+}
+
+// DropIndex creates a new `DROP INDEX name` statement for the index on the given table.
+// The table is only part of the statement on the MySQL and SQLServer dialects, as `DROP INDEX name ON table`.
+func DropIndex(name, table string) *IndexStatement {
+	return &IndexStatement{drop: true, name: name, table: table}
+}
+
+// Unique makes the created index a `UNIQUE` index.
+func (s *IndexStatement) Unique() *IndexStatement {
+	s.unique = true
+	return s
+}
+
+// IfExists adds a `IF NOT EXISTS` clause when creating or a `IF EXISTS` clause when dropping the index,
+// so that the statement succeeds if the index already exists or doesn't exist.
+// It is only supported on the Default, Postgres and SQLite dialects, and when dropping on the SQLServer dialect.
+func (s *IndexStatement) IfExists() *IndexStatement {
+	s.exists = true
+	return s
+}
+
+// Build builds the statement into the given buffer.
+func (s *IndexStatement) Build(buf Buffer) (err error) {
+	switch {
+	case s.name == "":
+		return incomplete("INDEX", "name")
+	case !s.drop && (s.table == "" || len(s.columns) == 0):
+		return incomplete("CREATE INDEX", "table and columns")
+	}
+
+	d := dialectOf(buf)
+	if s.exists {
+		switch {
+		case d == MySQL, d == Oracle, d == SQLServer && !s.drop:
+			return unsupported("INDEX IF EXISTS", d)
+		}
+	}
+
+	if s.drop {
+		_, _ = buf.WriteString("DROP INDEX ")
+		if s.exists {
+			_, _ = buf.WriteString("IF EXISTS ")
+		}
+		writeIdent(buf, s.name)
+
+		if d == MySQL || d == SQLServer {
+			if s.table == "" {
+				return incomplete("DROP INDEX", "table")
+			}
+			_, _ = buf.WriteString(" ON ")
+			writeIdent(buf, s.table)
+		}
+
+		return nil
+	}
+
+	_, _ = buf.WriteString("CREATE ")
+	if s.unique {
+		_, _ = buf.WriteString("UNIQUE ")
+	}
+	_, _ = buf.WriteString("INDEX ")
+	if s.exists {
+		_, _ = buf.WriteString("IF NOT EXISTS ")
+	}
+	writeIdent(buf, s.name)
+	_, _ = buf.WriteString(" ON ")
+	writeIdent(buf, s.table)
+	_, _ = buf.WriteString(" (")
+	writeIdents(buf, s.columns)
+	_, _ = buf.WriteString(")")
+
+	return nil
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *IndexStatement) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// SQL builds the statement and returns the resulting query.
+func (s *IndexStatement) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
@@ -57,3 +57,58 @@ func TestDDL(t *testing.T) {
 		})
 	}
 }
+
+func TestTruncateIndex(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		wantErr bool
+	}{
+		{name: "truncate_postgres", dialect: Postgres, stmt: TruncateTable("users", "orders").RestartIdentity().Cascade(),
+			expect: `TRUNCATE TABLE users,orders RESTART IDENTITY CASCADE`},
+		{name: "truncate_mysql", dialect: MySQL, stmt: TruncateTable("users").RestartIdentity(),
+			expect: `TRUNCATE TABLE users`},
+		{name: "truncate_mysql_cascade", dialect: MySQL, stmt: TruncateTable("users").Cascade(), wantErr: true},
+		{name: "truncate_mysql_tables", dialect: MySQL, stmt: TruncateTable("users", "orders"), wantErr: true},
+		{name: "truncate_oracle", dialect: Oracle, stmt: TruncateTable("users").Cascade(),
+			expect: `TRUNCATE TABLE users CASCADE`},
+		{name: "truncate_sqlserver", dialect: SQLServer, stmt: TruncateTable("users"),
+			expect: `TRUNCATE TABLE users`},
+		{name: "truncate_sqlite", dialect: SQLite, stmt: TruncateTable("users"),
+			expect: `DELETE FROM users`},
+		{name: "truncate_empty", dialect: Postgres, stmt: TruncateTable(), wantErr: true},
+		{name: "create_index", dialect: Postgres, stmt: CreateIndex("ix_users_email", "users", "email", "deleted_at").Unique().IfExists(),
+			expect: `CREATE UNIQUE INDEX IF NOT EXISTS ix_users_email ON users (email,deleted_at)`},
+		{name: "create_index_mysql", dialect: MySQL, stmt: CreateIndex("ix_users_email", "users", "email"),
+			expect: `CREATE INDEX ix_users_email ON users (email)`},
+		{name: "create_index_mysql_exists", dialect: MySQL, stmt: CreateIndex("ix_users_email", "users", "email").IfExists(), wantErr: true},
+		{name: "drop_index", dialect: Postgres, stmt: DropIndex("ix_users_email", "users").IfExists(),
+			expect: `DROP INDEX IF EXISTS ix_users_email`},
+		{name: "drop_index_mysql", dialect: MySQL, stmt: DropIndex("ix_users_email", "users"),
+			expect: `DROP INDEX ix_users_email ON users`},
+		{name: "drop_index_sqlserver", dialect: SQLServer, stmt: DropIndex("ix_users_email", "users").IfExists(),
+			expect: `DROP INDEX IF EXISTS ix_users_email ON users`},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected error, got: %s", q)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+		})
+	}
+}
@@ -1,17 +1,14 @@
 package statement
 
-import (
-	"strings"
-
-	"github.com/brunotm/norm/internal/buffer"
-)
+import "github.com/brunotm/norm/internal/buffer"
 
 // DeleteStatement statement.
 type DeleteStatement struct {
 	table     string
-	with      Statement
+	with      *with
 	comment   []Statement
 	where     []Statement
+	using     []joinTable
 	returning []string
 }
 
@@ -23,16 +20,15 @@ func Delete() (s *DeleteStatement) {
 // Comment adds a SQL comment to the generated query.
 // Each call to comment creates a new `-- <comment>` line.
 func (s *DeleteStatement) Comment(c string, values ...interface{}) *DeleteStatement {
-	buf := buffer.New()
-	defer buf.Release()
-
-	_, _ = buf.WriteString("-- ")
-	_, _ = buf.WriteString(c)
+	s.comment = append(s.comment, buildComment(c, values...))
+	return s
+}
 
-	p := &Part{}
-	p.Query = buf.String()
-	p.Values = values
-	s.comment = append(s.comment, p)
+// Tag adds a `/*key='value'*/` comment with the given tags in the sqlcommenter format to the generated query,
+// like `/*action='list',controller='orders'*/`, to correlate queries in the database logs with the code that issued them.
+// As comments, tags are built before the statement in their own line.
+func (s *DeleteStatement) Tag(tags map[string]string) *DeleteStatement {
+	s.comment = append(s.comment, buildTag(tags))
 	return s
 }
 
@@ -42,9 +38,17 @@ func (s *DeleteStatement) From(table string) *DeleteStatement {
 	return s
 }
 
+// Using adds a `USING table` clause joining the table with the given condition, as
+// `DELETE FROM target USING table WHERE cond`. On the MySQL and SQLServer dialects it is built
+// as `DELETE target FROM target INNER JOIN table ON cond`. Multiple calls join multiple tables.
+func (s *DeleteStatement) Using(table, on string, values ...interface{}) *DeleteStatement {
+	s.using = append(s.using, joinTable{table: table, on: &Part{Query: on, Values: values}})
+	return s
+}
+
 // With adds a `WITH alias AS (stmt)`
 func (s *DeleteStatement) With(alias string, stmt Statement) *DeleteStatement {
-	s.with = &with{alias: alias, stmt: stmt}
+	s.with = s.with.add(alias, false, stmt)
 	return s
 }
 
@@ -60,14 +64,65 @@ func (s *DeleteStatement) WhereIn(column string, values ...interface{}) *DeleteS
 	return s
 }
 
+// WhereCond adds a `WHERE cond` clause for conditions built with Cond, In, Between, Like, IsNull,
+// IsNotNull, And and Or, multiple calls to WhereCond are `ANDed` together.
+func (s *DeleteStatement) WhereCond(cond Statement) *DeleteStatement {
+	s.where = append(s.where, cond)
+	return s
+}
+
+// WhereKey adds a `WHERE (column = value AND ...)` clause matching the columns of a composite key
+// to the given values as with Key, multiple calls to WhereKey are `ANDed` together.
+func (s *DeleteStatement) WhereKey(key map[string]interface{}) *DeleteStatement {
+	s.where = append(s.where, Key(key))
+	return s
+}
+
+// WhereKeyStruct adds a `WHERE (column = value AND ...)` clause matching the fields of the given struct
+// tagged as key columns with `db:"column,key"` as with KeyStruct, multiple calls to WhereKeyStruct are `ANDed` together.
+func (s *DeleteStatement) WhereKeyStruct(structValue interface{}) *DeleteStatement {
+	s.where = append(s.where, KeyStruct(structValue))
+	return s
+}
+
 // Returning adds a `RETURNING columns` clause.
 func (s *DeleteStatement) Returning(columns ...string) *DeleteStatement {
 	s.returning = columns
 	return s
 }
 
+// Clone returns a copy of the statement that can be modified without affecting the original,
+// allowing variants to be derived from a shared base statement.
+// Nested statements, like subqueries, are shared by both statements.
+func (s *DeleteStatement) Clone() *DeleteStatement {
+	c := *s
+	c.with = s.with.clone()
+	c.comment = append(s.comment[:0:0], s.comment...)
+	c.where = append(s.where[:0:0], s.where...)
+	c.using = append(s.using[:0:0], s.using...)
+	c.returning = append(s.returning[:0:0], s.returning...)
+	return &c
+}
+
+// Validate checks that the statement is fully specified, returning ErrIncomplete if it has no table.
+func (s *DeleteStatement) Validate() (err error) {
+	if s.table == "" {
+		return incomplete("DELETE", "table")
+	}
+	return nil
+}
+
 // Build builds the statement into the given buffer.
+// With the WithRequireWhere option statements without a `WHERE` clause return ErrMissingWhere.
 func (s *DeleteStatement) Build(buf Buffer) (err error) {
+	if err = s.Validate(); err != nil {
+		return err
+	}
+
+	if err = checkWhere(buf, "DELETE", s.where); err != nil {
+		return err
+	}
+
 	for x := 0; x < len(s.comment); x++ {
 		if err = s.comment[x].Build(buf); err != nil {
 			return err
@@ -82,18 +137,38 @@ func (s *DeleteStatement) Build(buf Buffer) (err error) {
 		_, _ = buf.WriteString(" ")
 	}
 
-	_, _ = buf.WriteString("DELETE FROM ")
-	_, _ = buf.WriteString(s.table)
-	if err = buildWhere(buf, s.where); err != nil {
-		return err
+	where := s.where
+
+	switch d := dialectOf(buf); {
+	case len(s.using) == 0:
+		_, _ = buf.WriteString("DELETE FROM ")
+		writeIdent(buf, s.table)
+		buildOutput(buf, "DELETED", s.returning)
+
+	case d == MySQL || d == SQLServer:
+		_, _ = buf.WriteString("DELETE ")
+		writeIdent(buf, tableAlias(s.table))
+		buildOutput(buf, "DELETED", s.returning)
+		_, _ = buf.WriteString(" FROM ")
+		writeIdent(buf, s.table)
+		if err = buildJoinTables(buf, s.using); err != nil {
+			return err
+		}
+
+	case d == Oracle || d == SQLite:
+		return unsupported("DELETE USING", d)
+
+	default:
+		_, _ = buf.WriteString("DELETE FROM ")
+		writeIdent(buf, s.table)
+		where = buildTableList(buf, " USING ", s.using, where)
 	}
 
-	if len(s.returning) > 0 {
-		_, _ = buf.WriteString(" RETURNING ")
-		_, _ = buf.WriteString(strings.Join(s.returning, ","))
+	if err = buildWhere(buf, where); err != nil {
+		return err
 	}
 
-	return nil
+	return buildReturning(buf, s.returning)
 }
 
 // String builds the statement and returns the resulting query string.
@@ -107,3 +182,8 @@ func (s *DeleteStatement) String() (q string, err error) {
 
 	return buf.String(), nil
 }
+
+// SQL builds the statement and returns the resulting parameterized query and arguments.
+func (s *DeleteStatement) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
@@ -25,7 +25,7 @@ var (
 		},
 		{
 			name:   "with",
-			expect: `WITH roles_to_delete AS (SELECT id,name FROM roles WHERE expires_at < now()-'1m'::interval) DELETE FROM users WHERE role IN ((SELECT name FROM roles_to_delete))`,
+			expect: `WITH roles_to_delete AS (SELECT id,name FROM roles WHERE expires_at < now()-'1m'::interval) DELETE FROM users WHERE role IN (SELECT name FROM roles_to_delete)`,
 			stmt: Delete().With("roles_to_delete", Select().Columns("id", "name").From("roles").Where("expires_at < now()-?::interval", "1m")).
 				From("users").WhereIn("role", Select().Columns("name").From("roles_to_delete")),
 			wantErr: false,
@@ -0,0 +1,118 @@
+package statement
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ErrUnsupported is returned when a statement clause is not supported by the selected dialect.
+var ErrUnsupported = fmt.Errorf("statement: not supported by dialect")
+
+// Dialect represents the SQL dialect used for building parameterized statements.
+type Dialect int
+
+const (
+	// Default dialect uses the `?` placeholder.
+	Default Dialect = iota
+	// MySQL dialect uses the `?` placeholder.
+	MySQL
+	// Postgres dialect uses the `$1, $2, ...` placeholders.
+	Postgres
+	// SQLite dialect uses the `?` placeholder.
+	SQLite
+	// Oracle dialect uses the `:1, :2, ...` placeholders.
+	Oracle
+	// SQLServer dialect uses the `@p1, @p2, ...` placeholders.
+	SQLServer
+)
+
+// String returns the dialect name.
+func (d Dialect) String() string {
+	switch d {
+	case MySQL:
+		return "mysql"
+	case Postgres:
+		return "postgres"
+	case SQLite:
+		return "sqlite"
+	case Oracle:
+		return "oracle"
+	case SQLServer:
+		return "sqlserver"
+	default:
+		return "default"
+	}
+}
+
+// placeholder returns the placeholder for the nth (1 based) argument in a statement.
+func (d Dialect) placeholder(n int) string {
+	switch d {
+	case Postgres:
+		return "$" + strconv.Itoa(n)
+	case Oracle:
+		return ":" + strconv.Itoa(n)
+	case SQLServer:
+		return "@p" + strconv.Itoa(n)
+	default:
+		return "?"
+	}
+}
+
+// numbered returns true if the dialect placeholders are numbered and can be referenced multiple times.
+func (d Dialect) numbered() bool {
+	return d == Postgres || d == Oracle || d == SQLServer
+}
+
+// maxArgs returns the maximum number of arguments in a single statement.
+func (d Dialect) maxArgs() int {
+	switch d {
+	case SQLite:
+		return 999
+	case SQLServer:
+		return 2100
+	default:
+		return 65535
+	}
+}
+
+// Option configures how statements are built.
+type Option func(o *options)
+
+type options struct {
+	dialect      Dialect
+	maxArgs      int
+	quote        bool
+	pretty       bool
+	requireWhere bool
+	strictIdents bool
+	dedupArgs    bool
+	encoders     []ArgEncoder
+}
+
+// WithDialect sets the dialect used for building the statement.
+func WithDialect(d Dialect) Option {
+	return func(o *options) {
+		o.dialect = d
+	}
+}
+
+// WithRequireWhere makes building update and delete statements without a `WHERE` clause
+// return ErrMissingWhere, preventing accidental changes to all rows of a table.
+func WithRequireWhere() Option {
+	return func(o *options) {
+		o.requireWhere = true
+	}
+}
+
+// unsupported returns an ErrUnsupported error for the given clause and dialect.
+func unsupported(clause string, d Dialect) error {
+	return fmt.Errorf("%w: %s, dialect: %s", ErrUnsupported, clause, d)
+}
+
+// WithMaxArgs sets the maximum number of arguments in a single statement
+// when splitting statements in batches.
+func WithMaxArgs(n int) Option {
+	return func(o *options) {
+		o.maxArgs = n
+	}
+}
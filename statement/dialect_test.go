@@ -0,0 +1,712 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestDialectPlaceholders(t *testing.T) {
+	stmt := Select().Columns("id", "name").From("users").
+		Where("tenant_id = ?", 42).
+		Where("role_id IN ?", Select().Columns("id").From("roles").Where("tenant_id = ?", 42).WhereIn("name", "admin", "owner")).
+		Where("email = ?", "john.doe@email.com")
+
+	args := []interface{}{42, 42, "admin", "owner", "john.doe@email.com"}
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		expect  string
+	}{
+		{
+			name:    "default",
+			dialect: Default,
+			expect:  `SELECT id,name FROM users WHERE tenant_id = ? AND role_id IN (SELECT id FROM roles WHERE tenant_id = ? AND name IN (?,?)) AND email = ?`,
+		},
+		{
+			name:    "mysql",
+			dialect: MySQL,
+			expect:  `SELECT id,name FROM users WHERE tenant_id = ? AND role_id IN (SELECT id FROM roles WHERE tenant_id = ? AND name IN (?,?)) AND email = ?`,
+		},
+		{
+			name:    "sqlite",
+			dialect: SQLite,
+			expect:  `SELECT id,name FROM users WHERE tenant_id = ? AND role_id IN (SELECT id FROM roles WHERE tenant_id = ? AND name IN (?,?)) AND email = ?`,
+		},
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			expect:  `SELECT id,name FROM users WHERE tenant_id = $1 AND role_id IN (SELECT id FROM roles WHERE tenant_id = $2 AND name IN ($3,$4)) AND email = $5`,
+		},
+		{
+			name:    "oracle",
+			dialect: Oracle,
+			expect:  `SELECT id,name FROM users WHERE tenant_id = :1 AND role_id IN (SELECT id FROM roles WHERE tenant_id = :2 AND name IN (:3,:4)) AND email = :5`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, a, err := stmt.SQL(WithDialect(tt.dialect))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(args, a) {
+				t.Fatalf("expected args: %#v, got: %#v", args, a)
+			}
+		})
+	}
+}
+
+func TestDialectReturning(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		wantErr bool
+	}{
+		{
+			name:    "insert_postgres",
+			dialect: Postgres,
+			stmt:    Insert().Into("users").Columns("id", "name").Values(1, "john").Returning("id", "created_at"),
+			expect:  `INSERT INTO users(id,name) VALUES ($1,$2) RETURNING id,created_at`,
+		},
+		{
+			name:    "insert_sqlite",
+			dialect: SQLite,
+			stmt:    Insert().Into("users").Columns("id", "name").Values(1, "john").Returning("id"),
+			expect:  `INSERT INTO users(id,name) VALUES (?,?) RETURNING id`,
+		},
+		{
+			name:    "insert_sqlserver",
+			dialect: SQLServer,
+			stmt:    Insert().Into("users").Columns("id", "name").Values(1, "john").Returning("id", "created_at"),
+			expect:  `INSERT INTO users(id,name) OUTPUT INSERTED.id,INSERTED.created_at VALUES (@p1,@p2)`,
+		},
+		{
+			name:    "update_sqlserver",
+			dialect: SQLServer,
+			stmt:    Update().Table("users").Set("name", "john").Where("id = ?", 1).Returning("id"),
+			expect:  `UPDATE users SET name = @p1 OUTPUT INSERTED.id WHERE id = @p2`,
+		},
+		{
+			name:    "delete_sqlserver",
+			dialect: SQLServer,
+			stmt:    Delete().From("users").Where("id = ?", 1).Returning("id"),
+			expect:  `DELETE FROM users OUTPUT DELETED.id WHERE id = @p1`,
+		},
+		{
+			name:    "insert_mysql",
+			dialect: MySQL,
+			stmt:    Insert().Into("users").Columns("id", "name").Values(1, "john").Returning("id"),
+			wantErr: true,
+		},
+		{
+			name:    "delete_oracle",
+			dialect: Oracle,
+			stmt:    Delete().From("users").Where("id = ?", 1).Returning("id"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupported) {
+					t.Fatalf("expected ErrUnsupported, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+		})
+	}
+}
+
+func TestDialectUpsert(t *testing.T) {
+	update := map[string]interface{}{"email": Excluded("email"), "name": "john"}
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		wantErr bool
+	}{
+		{
+			name:    "do_update_postgres",
+			dialect: Postgres,
+			stmt:    Insert().Into("users").Columns("id", "email").Values(1, "john@email.com").OnConflictDoUpdate([]string{"id"}, update),
+			expect:  `INSERT INTO users(id,email) VALUES ($1,$2) ON CONFLICT (id) DO UPDATE SET email = EXCLUDED.email, name = $3`,
+		},
+		{
+			name:    "do_update_sqlite",
+			dialect: SQLite,
+			stmt:    Insert().Into("users").Columns("id", "email").Values(1, "john@email.com").OnConflictDoUpdate([]string{"id"}, update),
+			expect:  `INSERT INTO users(id,email) VALUES (?,?) ON CONFLICT (id) DO UPDATE SET email = EXCLUDED.email, name = ?`,
+		},
+		{
+			name:    "do_update_mysql",
+			dialect: MySQL,
+			stmt:    Insert().Into("users").Columns("id", "email").Values(1, "john@email.com").OnConflictDoUpdate(nil, update),
+			expect:  `INSERT INTO users(id,email) VALUES (?,?) ON DUPLICATE KEY UPDATE email = VALUES(email), name = ?`,
+		},
+		{
+			name:    "do_nothing_postgres",
+			dialect: Postgres,
+			stmt:    Insert().Into("users").Columns("id", "email").Values(1, "john@email.com").OnConflictDoNothing(),
+			expect:  `INSERT INTO users(id,email) VALUES ($1,$2) ON CONFLICT DO NOTHING`,
+		},
+		{
+			name:    "do_nothing_target_postgres",
+			dialect: Postgres,
+			stmt:    Insert().Into("users").Columns("id", "email").Values(1, "john@email.com").OnConflictDoNothing("id").Returning("id"),
+			expect:  `INSERT INTO users(id,email) VALUES ($1,$2) ON CONFLICT (id) DO NOTHING RETURNING id`,
+		},
+		{
+			name:    "do_nothing_mysql",
+			dialect: MySQL,
+			stmt:    Insert().Into("users").Columns("id", "email").Values(1, "john@email.com").OnConflictDoNothing(),
+			expect:  `INSERT INTO users(id,email) VALUES (?,?) ON DUPLICATE KEY UPDATE id = id`,
+		},
+		{
+			name:    "do_update_without_target_postgres",
+			dialect: Postgres,
+			stmt:    Insert().Into("users").Columns("id", "email").Values(1, "john@email.com").OnConflictDoUpdate(nil, update),
+			wantErr: true,
+		},
+		{
+			name:    "target_mysql",
+			dialect: MySQL,
+			stmt:    Insert().Into("users").Columns("id", "email").Values(1, "john@email.com").OnConflictDoNothing("id"),
+			wantErr: true,
+		},
+		{
+			name:    "oracle",
+			dialect: Oracle,
+			stmt:    Insert().Into("users").Columns("id", "email").Values(1, "john@email.com").OnConflictDoNothing(),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupported) {
+					t.Fatalf("expected ErrUnsupported, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+		})
+	}
+}
+
+func TestDialectLocking(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		wantErr bool
+	}{
+		{
+			name:    "postgres_skip_locked",
+			dialect: Postgres,
+			stmt:    Select().Columns("id").From("jobs").Where("status = ?", "pending").Limit(1).ForUpdate().SkipLocked(),
+			expect:  `SELECT id FROM jobs WHERE status = $1 LIMIT 1 OFFSET 0 FOR UPDATE SKIP LOCKED`,
+		},
+		{
+			name:    "mysql_for_share_of",
+			dialect: MySQL,
+			stmt:    Select().Columns("j.id").From("jobs j").JoinInner("queues q", "q.id = j.queue_id").ForShare().Of("j"),
+			expect:  `SELECT j.id FROM jobs j INNER JOIN queues q ON q.id = j.queue_id FOR SHARE OF j`,
+		},
+		{
+			name:    "oracle_nowait",
+			dialect: Oracle,
+			stmt:    Select().Columns("id").From("jobs").Where("id = ?", 1).NoWait(),
+			expect:  `SELECT id FROM jobs WHERE id = :1 FOR UPDATE NOWAIT`,
+		},
+		{
+			name:    "oracle_for_share",
+			dialect: Oracle,
+			stmt:    Select().Columns("id").From("jobs").ForShare(),
+			wantErr: true,
+		},
+		{
+			name:    "sqlite",
+			dialect: SQLite,
+			stmt:    Select().Columns("id").From("jobs").ForUpdate(),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupported) {
+					t.Fatalf("expected ErrUnsupported, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+		})
+	}
+}
+
+func TestDialectLimit(t *testing.T) {
+	ordered := Select().Columns("id").From("users").Where("active = ?", true).OrderAsc("id").Limit(10).Offset(20)
+	unordered := Select().Distinct().Columns("id").From("users").Limit(10)
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		wantErr error
+	}{
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			stmt:    ordered,
+			expect:  `SELECT id FROM users WHERE active = $1 ORDER BY id ASC LIMIT 10 OFFSET 20`,
+		},
+		{
+			name:    "mysql",
+			dialect: MySQL,
+			stmt:    ordered,
+			expect:  `SELECT id FROM users WHERE active = ? ORDER BY id ASC LIMIT 10 OFFSET 20`,
+		},
+		{
+			name:    "sqlite",
+			dialect: SQLite,
+			stmt:    unordered,
+			expect:  `SELECT DISTINCT id FROM users LIMIT 10 OFFSET 0`,
+		},
+		{
+			name:    "oracle",
+			dialect: Oracle,
+			stmt:    ordered,
+			expect:  `SELECT id FROM users WHERE active = :1 ORDER BY id ASC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY`,
+		},
+		{
+			name:    "oracle_unordered",
+			dialect: Oracle,
+			stmt:    unordered,
+			expect:  `SELECT DISTINCT id FROM users OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY`,
+		},
+		{
+			name:    "sqlserver",
+			dialect: SQLServer,
+			stmt:    ordered,
+			expect:  `SELECT id FROM users WHERE active = @p1 ORDER BY id ASC OFFSET 20 ROWS FETCH NEXT 10 ROWS ONLY`,
+		},
+		{
+			name:    "sqlserver_top",
+			dialect: SQLServer,
+			stmt:    unordered,
+			expect:  `SELECT DISTINCT TOP 10 id FROM users`,
+		},
+		{
+			name:    "sqlserver_keyset",
+			dialect: SQLServer,
+			stmt:    Select().Columns("id").From("users").KeysetAfter([]string{"id"}, []interface{}{7}).Limit(10),
+			expect:  `SELECT id FROM users WHERE id > @p1 ORDER BY id ASC OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY`,
+		},
+		{
+			name:    "sqlserver_offset_unordered",
+			dialect: SQLServer,
+			stmt:    Select().Columns("id").From("users").Limit(10).Offset(20),
+			wantErr: ErrMissingOrder,
+		},
+		{
+			name:    "sqlserver_compound",
+			dialect: SQLServer,
+			stmt:    Union(Select().Columns("id").From("users"), Select().Columns("id").From("admins")).OrderAsc("id").Limit(5),
+			expect:  `SELECT id FROM users UNION SELECT id FROM admins ORDER BY id ASC OFFSET 0 ROWS FETCH NEXT 5 ROWS ONLY`,
+		},
+		{
+			name:    "sqlserver_compound_unordered",
+			dialect: SQLServer,
+			stmt:    Union(Select().Columns("id").From("users"), Select().Columns("id").From("admins")).Limit(5),
+			wantErr: ErrMissingOrder,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+		})
+	}
+}
+
+func TestDialectWith(t *testing.T) {
+	stmt := Select().
+		With("tenant_users", Select().Columns("id", "manager_id").From("users").Where("tenant_id = ?", 42)).
+		WithRecursive("reports",
+			Select().Columns("id", "manager_id").From("tenant_users").Where("id = ?", 7),
+			Select().Columns("u.id", "u.manager_id").From("tenant_users u").JoinInner("reports r", "r.id = u.manager_id"),
+		).
+		Columns("id").From("reports").Where("id <> ?", 7)
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		expect  string
+	}{
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			expect:  `WITH RECURSIVE tenant_users AS (SELECT id,manager_id FROM users WHERE tenant_id = $1), reports AS (SELECT id,manager_id FROM tenant_users WHERE id = $2 UNION ALL SELECT u.id,u.manager_id FROM tenant_users u INNER JOIN reports r ON r.id = u.manager_id) SELECT id FROM reports WHERE id <> $3`,
+		},
+		{
+			name:    "sqlserver",
+			dialect: SQLServer,
+			expect:  `WITH tenant_users AS (SELECT id,manager_id FROM users WHERE tenant_id = @p1), reports AS (SELECT id,manager_id FROM tenant_users WHERE id = @p2 UNION ALL SELECT u.id,u.manager_id FROM tenant_users u INNER JOIN reports r ON r.id = u.manager_id) SELECT id FROM reports WHERE id <> @p3`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := stmt.SQL(WithDialect(tt.dialect))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual([]interface{}{42, 7, 7}, args) {
+				t.Fatalf("unexpected args: %#v", args)
+			}
+		})
+	}
+}
+
+func TestDialectJoin(t *testing.T) {
+	stmt := Select().Columns("u.id", "o.city", "r.name").From("users u").
+		JoinLeft("offices o", "o.id = u.office_id AND o.country = ?", "uk").
+		JoinLeft("roles r", "r.id = u.role_id AND r.tenant_id = ?", 42).
+		JoinUsing(InnerJoin, "tenants", "tenant_id").
+		JoinCross("settings").
+		Where("u.active = ?", true)
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		expect  string
+	}{
+		{
+			name:    "default",
+			dialect: Default,
+			expect:  `SELECT u.id,o.city,r.name FROM users u LEFT OUTER JOIN offices o ON o.id = u.office_id AND o.country = ? LEFT OUTER JOIN roles r ON r.id = u.role_id AND r.tenant_id = ? INNER JOIN tenants USING (tenant_id) CROSS JOIN settings WHERE u.active = ?`,
+		},
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			expect:  `SELECT u.id,o.city,r.name FROM users u LEFT OUTER JOIN offices o ON o.id = u.office_id AND o.country = $1 LEFT OUTER JOIN roles r ON r.id = u.role_id AND r.tenant_id = $2 INNER JOIN tenants USING (tenant_id) CROSS JOIN settings WHERE u.active = $3`,
+		},
+		{
+			name:    "mysql",
+			dialect: MySQL,
+			expect:  `SELECT u.id,o.city,r.name FROM users u LEFT OUTER JOIN offices o ON o.id = u.office_id AND o.country = ? LEFT OUTER JOIN roles r ON r.id = u.role_id AND r.tenant_id = ? INNER JOIN tenants USING (tenant_id) CROSS JOIN settings WHERE u.active = ?`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := stmt.SQL(WithDialect(tt.dialect))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual([]interface{}{"uk", 42, true}, args) {
+				t.Fatalf("unexpected args: %#v", args)
+			}
+		})
+	}
+}
+
+func TestDialectLateral(t *testing.T) {
+	latest := Select().Columns("id", "total").From("orders").
+		Where("customer_id = c.id AND status = ?", "paid").OrderDesc("created_at").Limit(3)
+
+	left := Select().Columns("c.id", "o.id", "o.total").From("customers c").
+		LeftJoinLateral(latest, "o", "").Where("c.active = ?", true)
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		args    []interface{}
+		wantErr bool
+	}{
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			stmt:    left,
+			expect:  `SELECT c.id,o.id,o.total FROM customers c LEFT OUTER JOIN LATERAL (SELECT id,total FROM orders WHERE customer_id = c.id AND status = $1 ORDER BY created_at DESC LIMIT 3 OFFSET 0) AS o ON true WHERE c.active = $2`,
+			args:    []interface{}{"paid", true},
+		},
+		{
+			name:    "postgres_cross",
+			dialect: Postgres,
+			stmt: Select().Columns("c.id", "o.id").From("customers c").
+				CrossJoinLateral(latest, "o").Where("c.region = ?", "eu"),
+			expect: `SELECT c.id,o.id FROM customers c CROSS JOIN LATERAL (SELECT id,total FROM orders WHERE customer_id = c.id AND status = $1 ORDER BY created_at DESC LIMIT 3 OFFSET 0) AS o WHERE c.region = $2`,
+			args:   []interface{}{"paid", "eu"},
+		},
+		{
+			name:    "mysql_cond",
+			dialect: MySQL,
+			stmt: Select().Columns("c.id", "o.id").From("customers c").
+				LeftJoinLateral(latest, "o", "o.total > ?", 100),
+			expect: `SELECT c.id,o.id FROM customers c LEFT OUTER JOIN LATERAL (SELECT id,total FROM orders WHERE customer_id = c.id AND status = ? ORDER BY created_at DESC LIMIT 3 OFFSET 0) AS o ON o.total > ?`,
+			args:   []interface{}{"paid", 100},
+		},
+		{
+			name:    "oracle",
+			dialect: Oracle,
+			stmt:    left,
+			expect:  `SELECT c.id,o.id,o.total FROM customers c LEFT OUTER JOIN LATERAL (SELECT id,total FROM orders WHERE customer_id = c.id AND status = :1 ORDER BY created_at DESC OFFSET 0 ROWS FETCH NEXT 3 ROWS ONLY) o ON 1 = 1 WHERE c.active = :2`,
+			args:    []interface{}{"paid", true},
+		},
+		{
+			name:    "sqlserver",
+			dialect: SQLServer,
+			stmt:    left,
+			expect:  `SELECT c.id,o.id,o.total FROM customers c OUTER APPLY (SELECT id,total FROM orders WHERE customer_id = c.id AND status = @p1 ORDER BY created_at DESC OFFSET 0 ROWS FETCH NEXT 3 ROWS ONLY) AS o WHERE c.active = @p2`,
+			args:    []interface{}{"paid", true},
+		},
+		{
+			name:    "sqlserver_cond",
+			dialect: SQLServer,
+			stmt:    Select().Columns("c.id").From("customers c").LeftJoinLateral(latest, "o", "o.total > ?", 100),
+			wantErr: true,
+		},
+		{
+			name:    "sqlite",
+			dialect: SQLite,
+			stmt:    left,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupported) {
+					t.Fatalf("expected ErrUnsupported, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestDialectJoinTables(t *testing.T) {
+	update := func() *UpdateStatement {
+		return Update().Table("users u").Set("plan", "pro").
+			From("accounts a", "a.id = u.account_id AND a.tier = ?", "gold").
+			Where("u.active = ?", true)
+	}
+	del := func() *DeleteStatement {
+		return Delete().From("sessions s").
+			Using("users u", "u.id = s.user_id AND u.status = ?", "banned").
+			Where("s.expired = ?", true)
+	}
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		args    []interface{}
+		wantErr bool
+	}{
+		{
+			name:    "postgres_update_from",
+			dialect: Postgres,
+			stmt:    update(),
+			expect:  `UPDATE users u SET plan = $1 FROM accounts a WHERE a.id = u.account_id AND a.tier = $2 AND u.active = $3`,
+			args:    []interface{}{"pro", "gold", true},
+		},
+		{
+			name:    "postgres_delete_using",
+			dialect: Postgres,
+			stmt:    del().Returning("s.id"),
+			expect:  `DELETE FROM sessions s USING users u WHERE u.id = s.user_id AND u.status = $1 AND s.expired = $2 RETURNING s.id`,
+			args:    []interface{}{"banned", true},
+		},
+		{
+			name:    "postgres_update_from_multiple",
+			dialect: Postgres,
+			stmt:    Update().Table("users").Set("plan", "pro").From("accounts", "accounts.id = users.account_id").From("tiers", "tiers.id = accounts.tier_id"),
+			expect:  `UPDATE users SET plan = $1 FROM accounts, tiers WHERE accounts.id = users.account_id AND tiers.id = accounts.tier_id`,
+			args:    []interface{}{"pro"},
+		},
+		{
+			name:    "mysql_update_join",
+			dialect: MySQL,
+			stmt:    update(),
+			expect:  `UPDATE users u INNER JOIN accounts a ON a.id = u.account_id AND a.tier = ? SET plan = ? WHERE u.active = ?`,
+			args:    []interface{}{"gold", "pro", true},
+		},
+		{
+			name:    "mysql_delete_join",
+			dialect: MySQL,
+			stmt:    del(),
+			expect:  `DELETE s FROM sessions s INNER JOIN users u ON u.id = s.user_id AND u.status = ? WHERE s.expired = ?`,
+			args:    []interface{}{"banned", true},
+		},
+		{
+			name:    "sqlserver_update_from",
+			dialect: SQLServer,
+			stmt:    update(),
+			expect:  `UPDATE u SET plan = @p1 FROM users u INNER JOIN accounts a ON a.id = u.account_id AND a.tier = @p2 WHERE u.active = @p3`,
+			args:    []interface{}{"pro", "gold", true},
+		},
+		{
+			name:    "oracle_update_from",
+			dialect: Oracle,
+			stmt:    update(),
+			wantErr: true,
+		},
+		{
+			name:    "sqlite_delete_using",
+			dialect: SQLite,
+			stmt:    del(),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnsupported) {
+					t.Fatalf("expected ErrUnsupported, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("unexpected args: %#v", args)
+			}
+		})
+	}
+}
+
+func TestDialectInsertFromSelect(t *testing.T) {
+	stmt := Insert().Into("users_history").Columns("id", "name", "archived_by").
+		With("expired", Select().Columns("id").From("users").Where("deleted_at < ?", "2020-01-01")).
+		FromSelect(Select().Columns("u.id", "u.name", Raw("?", "cleanup")).From("users u").
+			JoinInner("expired e", "e.id = u.id").Where("u.tenant_id = ?", 42)).
+		Returning("id")
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		expect  string
+	}{
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			expect:  `WITH expired AS (SELECT id FROM users WHERE deleted_at < $1) INSERT INTO users_history(id,name,archived_by) SELECT u.id,u.name,$2 FROM users u INNER JOIN expired e ON e.id = u.id WHERE u.tenant_id = $3 RETURNING id`,
+		},
+		{
+			name:    "sqlserver",
+			dialect: SQLServer,
+			expect:  `WITH expired AS (SELECT id FROM users WHERE deleted_at < @p1) INSERT INTO users_history(id,name,archived_by) OUTPUT INSERTED.id SELECT u.id,u.name,@p2 FROM users u INNER JOIN expired e ON e.id = u.id WHERE u.tenant_id = @p3`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := stmt.SQL(WithDialect(tt.dialect))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual([]interface{}{"2020-01-01", "cleanup", 42}, args) {
+				t.Fatalf("unexpected args: %#v", args)
+			}
+		})
+	}
+}
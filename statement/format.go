@@ -0,0 +1,126 @@
+package statement
+
+import (
+	"strings"
+)
+
+// WithPretty formats the built query with Format, for readable logs and golden files.
+// Only whitespace is changed, the arguments and the semantics of the query are the same as the compact form.
+func WithPretty() Option {
+	return func(o *options) {
+		o.pretty = true
+	}
+}
+
+// clauses are the keywords that start a new line when formatting queries, longest first
+// so that clauses like `FOR UPDATE` are not split at a shorter keyword.
+var clauses = []string{
+	"ON DUPLICATE KEY UPDATE", "LEFT OUTER JOIN", "RIGHT OUTER JOIN", "FULL OUTER JOIN",
+	"UNION ALL", "INNER JOIN", "CROSS JOIN", "ON CONFLICT", "FOR UPDATE", "FOR SHARE",
+	"GROUP BY", "ORDER BY", "INTERSECT", "RETURNING", "EXCEPT", "HAVING", "SELECT", "VALUES",
+	"OFFSET", "UNION", "WHERE", "FETCH", "LIMIT", "FROM", "SET",
+}
+
+// Format formats the given query with each clause, like `FROM` or `WHERE`, in a new line
+// and subqueries indented within their parentheses. Quoted strings, quoted identifiers and comments
+// are left as is, and only whitespace is changed.
+func Format(query string) (q string) {
+	var b strings.Builder
+	b.Grow(len(query) + len(query)/4)
+
+	// subqueries holds whether each open parenthesis encloses a subquery
+	var subqueries []bool
+	level := 0
+
+	for x := 0; x < len(query); x++ {
+		c := query[x]
+
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(query[x+1:], c)
+			if end == -1 {
+				_, _ = b.WriteString(query[x:])
+				return b.String()
+			}
+			_, _ = b.WriteString(query[x : x+end+2])
+			x += end + 1
+
+		case c == '-' && strings.HasPrefix(query[x:], "--"):
+			end := strings.IndexByte(query[x:], '\n')
+			if end == -1 {
+				_, _ = b.WriteString(query[x:])
+				return b.String()
+			}
+			_, _ = b.WriteString(query[x : x+end+1])
+			x += end
+
+		case c == '(':
+			rest := strings.TrimLeft(query[x+1:], " ")
+			sub := keywordAt(rest, "SELECT") || keywordAt(rest, "WITH")
+			subqueries = append(subqueries, sub)
+			_ = b.WriteByte(c)
+
+			if sub {
+				level++
+				newline(&b, level)
+				x += len(query[x+1:]) - len(rest)
+			}
+
+		case c == ')':
+			if n := len(subqueries); n > 0 {
+				if subqueries[n-1] {
+					level--
+					newline(&b, level)
+				}
+				subqueries = subqueries[:n-1]
+			}
+			_ = b.WriteByte(c)
+
+		case c == ' ' && (len(subqueries) == 0 || subqueries[len(subqueries)-1]):
+			clause := ""
+			for _, k := range clauses {
+				if keywordAt(query[x+1:], k) {
+					clause = k
+					break
+				}
+			}
+
+			if clause == "" {
+				_ = b.WriteByte(c)
+				continue
+			}
+
+			newline(&b, level)
+			_, _ = b.WriteString(clause)
+			x += len(clause)
+
+		default:
+			_ = b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
+
+// keywordAt returns true if s starts with the given keyword as a whole word.
+func keywordAt(s, keyword string) bool {
+	if !strings.HasPrefix(s, keyword) {
+		return false
+	}
+
+	if len(s) == len(keyword) {
+		return true
+	}
+
+	switch s[len(keyword)] {
+	case ' ', '\n', '\t':
+		return true
+	}
+
+	return false
+}
+
+func newline(b *strings.Builder, level int) {
+	_ = b.WriteByte('\n')
+	_, _ = b.WriteString(strings.Repeat("  ", level))
+}
@@ -0,0 +1,78 @@
+package statement
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	cases := []struct {
+		name   string
+		stmt   Parameterized
+		expect string
+	}{
+		{
+			name: "select",
+			stmt: Select().Columns("id", "name").From("users u").
+				JoinLeft("roles r", "r.id = u.role_id").
+				Where("u.name = ?", "FROM 'x'").
+				Where("u.role_id IN ?", Select().Columns("id").From("roles").Where("name IN (?,?)", "admin", "owner")).
+				GroupBy("id", "name").OrderAsc("name").Limit(10),
+			expect: "SELECT id,name\n" +
+				"FROM users u\n" +
+				"LEFT OUTER JOIN roles r ON r.id = u.role_id\n" +
+				"WHERE u.name = $1 AND u.role_id IN (\n" +
+				"  SELECT id\n" +
+				"  FROM roles\n" +
+				"  WHERE name IN ($2,$3)\n" +
+				")\n" +
+				"GROUP BY id,name\n" +
+				"ORDER BY name ASC\n" +
+				"LIMIT 10\n" +
+				"OFFSET 0",
+		},
+		{
+			name: "insert",
+			stmt: Insert().Comment("insert users").Into("users").Columns("id", "name").
+				Values(1, "john").OnConflictDoNothing("id").Returning("id"),
+			expect: "-- insert users\n" +
+				"INSERT INTO users(id,name)\n" +
+				"VALUES ($1,$2)\n" +
+				"ON CONFLICT (id) DO NOTHING\n" +
+				"RETURNING id",
+		},
+		{
+			name: "update",
+			stmt: Update().Table("users").Set("name", "john").Where("id = ?", 1),
+			expect: "UPDATE users\n" +
+				"SET name = $1\n" +
+				"WHERE id = $2",
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(Postgres), WithPretty())
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected:\n%s\ngot:\n%s", tt.expect, q)
+			}
+
+			compact, compactArgs, err := tt.stmt.SQL(WithDialect(Postgres))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if !reflect.DeepEqual(compactArgs, args) {
+				t.Fatalf("expected the compact args %#v, got: %#v", compactArgs, args)
+			}
+
+			if Format(compact) != q {
+				t.Fatalf("expected Format to format the compact query as:\n%s\ngot:\n%s", q, Format(compact))
+			}
+		})
+	}
+}
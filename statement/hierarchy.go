@@ -0,0 +1,192 @@
+package statement
+
+import (
+	"strconv"
+
+	"github.com/brunotm/norm/internal/buffer"
+)
+
+// HierarchyStatement is a recursive query walking a parent/child tree stored in a table, as categories
+// or org charts, returning each row with its `depth`, starting at 1 for the root rows, and its `path`,
+// the slash separated ids from the root as `/1/4/9/`. Rows are ordered by path, so each subtree follows its root.
+type HierarchyStatement struct {
+	table    string
+	id       string
+	parent   string
+	root     Statement
+	columns  []string
+	maxDepth int
+	noCycles bool
+}
+
+// Hierarchy creates a recursive query walking the tree stored in the given table from the rows matching the
+// root condition, as Cond("parent_id IS NULL"), to their children through the parent column referencing the id column.
+// It is built as a `WITH RECURSIVE hierarchy AS (...) SELECT * FROM hierarchy ORDER BY path` statement.
+func Hierarchy(table, idColumn, parentColumn string, root Statement) *HierarchyStatement {
+	return &HierarchyStatement{table: table, id: idColumn, parent: parentColumn, root: root}
+}
+
+// Columns sets the table columns returned for each row, instead of all columns.
+// They are required on the Oracle dialect, whose recursive queries need an explicit column list.
+func (s *HierarchyStatement) Columns(columns ...string) *HierarchyStatement {
+	s.columns = columns
+	return s
+}
+
+// MaxDepth limits the walk to the given depth, where the root rows have depth 1. If 0 the depth is not limited.
+func (s *HierarchyStatement) MaxDepth(depth int) *HierarchyStatement {
+	s.maxDepth = depth
+	return s
+}
+
+// NoCycles stops the walk at rows already visited in their path, so trees with cycles in their parent
+// references terminate. A `UNION` between the recursive terms can't detect them, as the depth and path
+// of each visit differ, so the visited ids are tracked in the path.
+func (s *HierarchyStatement) NoCycles() *HierarchyStatement {
+	s.noCycles = true
+	return s
+}
+
+// Build builds the statement into the given buffer.
+func (s *HierarchyStatement) Build(buf Buffer) (err error) {
+	switch {
+	case s.table == "":
+		return incomplete("Hierarchy", "table")
+	case s.id == "" || s.parent == "":
+		return incomplete("Hierarchy", "id and parent columns")
+	case s.root == nil:
+		return incomplete("Hierarchy", "root condition")
+	}
+
+	d := dialectOf(buf)
+	if d == Oracle && len(s.columns) == 0 {
+		return unsupported("Hierarchy without Columns", d)
+	}
+
+	_, _ = buf.WriteString("WITH ")
+	if d != Oracle && d != SQLServer {
+		_, _ = buf.WriteString("RECURSIVE ")
+	}
+	_, _ = buf.WriteString("hierarchy")
+
+	if len(s.columns) > 0 {
+		_, _ = buf.WriteString(" (")
+		writeIdents(buf, s.columns)
+		_, _ = buf.WriteString(",depth,path)")
+	}
+
+	// anchor rows
+	_, _ = buf.WriteString(" AS (SELECT ")
+	s.buildColumns(buf, "t")
+	_, _ = buf.WriteString(",1 AS depth,")
+	s.buildPath(buf, d, "'/'", "t", "'/'")
+	_, _ = buf.WriteString(" AS path FROM ")
+	writeIdent(buf, s.table)
+	_, _ = buf.WriteString(" t WHERE ")
+	if err = s.root.Build(buf); err != nil {
+		return err
+	}
+
+	// children rows
+	_, _ = buf.WriteString(" UNION ALL SELECT ")
+	s.buildColumns(buf, "c")
+	_, _ = buf.WriteString(",h.depth + 1,")
+	s.buildPath(buf, d, "h.path", "c", "'/'")
+	_, _ = buf.WriteString(" FROM ")
+	writeIdent(buf, s.table)
+	_, _ = buf.WriteString(" c INNER JOIN hierarchy h ON ")
+	writeIdent(buf, "c."+s.parent)
+	_, _ = buf.WriteString(" = ")
+	writeIdent(buf, "h."+s.id)
+
+	where := " WHERE "
+	if s.maxDepth > 0 {
+		_, _ = buf.WriteString(where)
+		_, _ = buf.WriteString("h.depth < ")
+		_, _ = buf.WriteString(strconv.Itoa(s.maxDepth))
+		where = " AND "
+	}
+
+	if s.noCycles {
+		_, _ = buf.WriteString(where)
+		_, _ = buf.WriteString("h.path NOT LIKE ")
+		s.buildPath(buf, d, "'%/'", "c", "'/%'")
+	}
+
+	_, _ = buf.WriteString(") SELECT * FROM hierarchy ORDER BY path")
+	return nil
+}
+
+// buildColumns builds the table columns qualified with the given alias.
+func (s *HierarchyStatement) buildColumns(buf Buffer, alias string) {
+	if len(s.columns) == 0 {
+		_, _ = buf.WriteString(alias)
+		_, _ = buf.WriteString(".*")
+		return
+	}
+
+	for x := 0; x < len(s.columns); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(",")
+		}
+		writeIdent(buf, alias+"."+s.columns[x])
+	}
+}
+
+// buildPath builds the concatenation of the head, the id column of the given alias as text and the tail
+// for the buffer dialect.
+func (s *HierarchyStatement) buildPath(buf Buffer, d Dialect, head, alias, tail string) {
+	var text string
+	switch d {
+	case Postgres, SQLite:
+		text = "TEXT"
+	case MySQL:
+		text = "CHAR(4000)"
+	case Oracle:
+		text = "VARCHAR2(4000)"
+	case SQLServer:
+		text = "VARCHAR(MAX)"
+	default:
+		text = "VARCHAR(4000)"
+	}
+
+	op := " || "
+	switch d {
+	case MySQL:
+		_, _ = buf.WriteString("CONCAT(")
+		op = ","
+	case SQLServer:
+		op = " + "
+	}
+
+	_, _ = buf.WriteString(head)
+	_, _ = buf.WriteString(op)
+	_, _ = buf.WriteString("CAST(")
+	writeIdent(buf, alias+"."+s.id)
+	_, _ = buf.WriteString(" AS ")
+	_, _ = buf.WriteString(text)
+	_, _ = buf.WriteString(")")
+	_, _ = buf.WriteString(op)
+	_, _ = buf.WriteString(tail)
+
+	if d == MySQL {
+		_, _ = buf.WriteString(")")
+	}
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *HierarchyStatement) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// SQL builds the statement and returns the resulting parameterized query and arguments.
+func (s *HierarchyStatement) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
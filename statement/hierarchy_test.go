@@ -0,0 +1,86 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHierarchy(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		args    []interface{}
+		err     error
+	}{
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			stmt:    Hierarchy("categories", "id", "parent_id", Cond("parent_id IS NULL")),
+			expect:  `WITH RECURSIVE hierarchy AS (SELECT t.*,1 AS depth,'/' || CAST(t.id AS TEXT) || '/' AS path FROM categories t WHERE parent_id IS NULL UNION ALL SELECT c.*,h.depth + 1,h.path || CAST(c.id AS TEXT) || '/' FROM categories c INNER JOIN hierarchy h ON c.parent_id = h.id) SELECT * FROM hierarchy ORDER BY path`,
+		},
+		{
+			name:    "postgres_max_depth_no_cycles",
+			dialect: Postgres,
+			stmt:    Hierarchy("employees", "id", "manager_id", Cond("id = ?", 1)).Columns("id", "manager_id", "name").MaxDepth(3).NoCycles(),
+			expect:  `WITH RECURSIVE hierarchy (id,manager_id,name,depth,path) AS (SELECT t.id,t.manager_id,t.name,1 AS depth,'/' || CAST(t.id AS TEXT) || '/' AS path FROM employees t WHERE id = $1 UNION ALL SELECT c.id,c.manager_id,c.name,h.depth + 1,h.path || CAST(c.id AS TEXT) || '/' FROM employees c INNER JOIN hierarchy h ON c.manager_id = h.id WHERE h.depth < 3 AND h.path NOT LIKE '%/' || CAST(c.id AS TEXT) || '/%') SELECT * FROM hierarchy ORDER BY path`,
+			args:    []interface{}{1},
+		},
+		{
+			name:    "mysql",
+			dialect: MySQL,
+			stmt:    Hierarchy("categories", "id", "parent_id", Cond("parent_id IS NULL")).NoCycles(),
+			expect:  `WITH RECURSIVE hierarchy AS (SELECT t.*,1 AS depth,CONCAT('/',CAST(t.id AS CHAR(4000)),'/') AS path FROM categories t WHERE parent_id IS NULL UNION ALL SELECT c.*,h.depth + 1,CONCAT(h.path,CAST(c.id AS CHAR(4000)),'/') FROM categories c INNER JOIN hierarchy h ON c.parent_id = h.id WHERE h.path NOT LIKE CONCAT('%/',CAST(c.id AS CHAR(4000)),'/%')) SELECT * FROM hierarchy ORDER BY path`,
+		},
+		{
+			name:    "sqlserver",
+			dialect: SQLServer,
+			stmt:    Hierarchy("categories", "id", "parent_id", Cond("parent_id IS NULL")).MaxDepth(2),
+			expect:  `WITH hierarchy AS (SELECT t.*,1 AS depth,'/' + CAST(t.id AS VARCHAR(MAX)) + '/' AS path FROM categories t WHERE parent_id IS NULL UNION ALL SELECT c.*,h.depth + 1,h.path + CAST(c.id AS VARCHAR(MAX)) + '/' FROM categories c INNER JOIN hierarchy h ON c.parent_id = h.id WHERE h.depth < 2) SELECT * FROM hierarchy ORDER BY path`,
+		},
+		{
+			name:    "oracle",
+			dialect: Oracle,
+			stmt:    Hierarchy("categories", "id", "parent_id", Cond("parent_id IS NULL")).Columns("id", "parent_id"),
+			expect:  `WITH hierarchy (id,parent_id,depth,path) AS (SELECT t.id,t.parent_id,1 AS depth,'/' || CAST(t.id AS VARCHAR2(4000)) || '/' AS path FROM categories t WHERE parent_id IS NULL UNION ALL SELECT c.id,c.parent_id,h.depth + 1,h.path || CAST(c.id AS VARCHAR2(4000)) || '/' FROM categories c INNER JOIN hierarchy h ON c.parent_id = h.id) SELECT * FROM hierarchy ORDER BY path`,
+		},
+		{
+			name:    "oracle_without_columns",
+			dialect: Oracle,
+			stmt:    Hierarchy("categories", "id", "parent_id", Cond("parent_id IS NULL")),
+			err:     ErrUnsupported,
+		},
+		{
+			name:    "without_root",
+			dialect: Postgres,
+			stmt:    Hierarchy("categories", "id", "parent_id", nil),
+			err:     ErrIncomplete,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("expected error %s, got: %v", tt.err, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package statement
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IndexHint types
+type IndexHint string
+
+var (
+	// UseIndex hint type
+	UseIndex IndexHint = "USE INDEX"
+	// ForceIndex hint type
+	ForceIndex IndexHint = "FORCE INDEX"
+	// IgnoreIndex hint type
+	IgnoreIndex IndexHint = "IGNORE INDEX"
+)
+
+// indexHint is an index hint for a table of a select statement.
+type indexHint struct {
+	table   string
+	hint    IndexHint
+	indexes []string
+}
+
+// IndexHint adds an index hint for the given table of the `FROM` or `JOIN` clauses, referenced by its name or alias.
+// It is built as `table USE INDEX (indexes)` on MySQL, `table WITH (INDEX(indexes))` on SQLServer,
+// `table INDEXED BY index` on SQLite, which supports a single index, and as a `/*+ INDEX(table indexes) */`
+// optimizer hint on Oracle. Hints are ignored on the Default and Postgres dialects, which have no index hints.
+// Dialects without an equivalent for the hint type return ErrUnsupported.
+func (s *SelectStatement) IndexHint(table string, hint IndexHint, indexes ...string) *SelectStatement {
+	s.indexHints = append(s.indexHints, indexHint{table: table, hint: hint, indexes: indexes})
+	return s
+}
+
+// hintsFor returns the index hints for the given table reference.
+func (s *SelectStatement) hintsFor(ref string) (hints []indexHint) {
+	for _, h := range s.indexHints {
+		if tableRef(ref, h.table) {
+			hints = append(hints, h)
+		}
+	}
+	return hints
+}
+
+// hintRef returns the table reference of the `FROM` or `JOIN` clauses for the given index hint table.
+func (s *SelectStatement) hintRef(table string) (ref string, ok bool) {
+	if p, ok := s.table.(*Part); ok && !s.tableStatement && tableRef(p.Query, table) {
+		return p.Query, true
+	}
+
+	for _, j := range s.join {
+		if j, ok := j.(*joinClause); ok && j.values == nil && j.lateral == nil && tableRef(j.table, table) {
+			return j.table, true
+		}
+	}
+
+	return "", false
+}
+
+// validHints returns ErrIncomplete if the index hints reference tables that are not in the statement.
+func (s *SelectStatement) validHints() (err error) {
+	for _, h := range s.indexHints {
+		if _, ok := s.hintRef(h.table); !ok {
+			return fmt.Errorf("%w: index hint for table %s not in the FROM or JOIN clauses", ErrIncomplete, h.table)
+		}
+	}
+
+	return nil
+}
+
+// tableRef returns true if the table reference, as `table` or `table alias`, has the given name or alias.
+func tableRef(ref, table string) bool {
+	f := strings.Fields(ref)
+	return len(f) > 0 && (f[0] == table || f[len(f)-1] == table)
+}
+
+// buildTableHints builds the given index hints following a table reference for the buffer dialect.
+func buildTableHints(buf Buffer, hints []indexHint) (err error) {
+	d := dialectOf(buf)
+	for _, h := range hints {
+		switch d {
+		case MySQL:
+			_, _ = buf.WriteString(" ")
+			_, _ = buf.WriteString(string(h.hint))
+			_, _ = buf.WriteString(" (")
+			writeIdents(buf, h.indexes)
+			_, _ = buf.WriteString(")")
+
+		case SQLServer:
+			if h.hint == IgnoreIndex {
+				return unsupported(string(h.hint), d)
+			}
+			_, _ = buf.WriteString(" WITH (INDEX(")
+			writeIdents(buf, h.indexes)
+			_, _ = buf.WriteString("))")
+
+		case SQLite:
+			if h.hint == IgnoreIndex || len(h.indexes) != 1 {
+				return unsupported(fmt.Sprintf("%s with %d indexes", h.hint, len(h.indexes)), d)
+			}
+			_, _ = buf.WriteString(" INDEXED BY ")
+			writeIdent(buf, h.indexes[0])
+		}
+	}
+
+	return nil
+}
+
+// buildOptimizerHints builds the index hints as an Oracle optimizer hint comment following the `SELECT` keyword.
+func (s *SelectStatement) buildOptimizerHints(buf Buffer) {
+	if len(s.indexHints) == 0 || dialectOf(buf) != Oracle {
+		return
+	}
+
+	_, _ = buf.WriteString("/*+")
+	for _, h := range s.indexHints {
+		_, _ = buf.WriteString(" ")
+		if h.hint == IgnoreIndex {
+			_, _ = buf.WriteString("NO_")
+		}
+		_, _ = buf.WriteString("INDEX(")
+		ref, _ := s.hintRef(h.table)
+		writeIdent(buf, tableAlias(ref))
+		for _, index := range h.indexes {
+			_, _ = buf.WriteString(" ")
+			writeIdent(buf, index)
+		}
+		_, _ = buf.WriteString(")")
+	}
+	_, _ = buf.WriteString(" */ ")
+}
@@ -0,0 +1,91 @@
+package statement
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIndexHint(t *testing.T) {
+	stmt := Select().Columns("u.id", "o.total").From("users u").
+		JoinInner("orders o", "o.user_id = u.id").
+		Where("u.email = ?", "a@b.c").
+		IndexHint("users", UseIndex, "users_email_idx").
+		IndexHint("o", ForceIndex, "orders_user_idx", "orders_created_idx")
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		wantErr error
+	}{
+		{
+			name:    "mysql",
+			dialect: MySQL,
+			stmt:    stmt,
+			expect:  `SELECT u.id,o.total FROM users u USE INDEX (users_email_idx) INNER JOIN orders o FORCE INDEX (orders_user_idx,orders_created_idx) ON o.user_id = u.id WHERE u.email = ?`,
+		},
+		{
+			name:    "mysql_ignore",
+			dialect: MySQL,
+			stmt:    Select().Columns("id").From("events").IndexHint("events", IgnoreIndex, "events_type_idx").OrderAsc("id").Limit(10),
+			expect:  `SELECT id FROM events IGNORE INDEX (events_type_idx) ORDER BY id ASC LIMIT 10 OFFSET 0`,
+		},
+		{
+			name:    "sqlserver",
+			dialect: SQLServer,
+			stmt:    stmt,
+			expect:  `SELECT u.id,o.total FROM users u WITH (INDEX(users_email_idx)) INNER JOIN orders o WITH (INDEX(orders_user_idx,orders_created_idx)) ON o.user_id = u.id WHERE u.email = @p1`,
+		},
+		{
+			name:    "oracle",
+			dialect: Oracle,
+			stmt:    stmt,
+			expect:  `SELECT /*+ INDEX(u users_email_idx) INDEX(o orders_user_idx orders_created_idx) */ u.id,o.total FROM users u INNER JOIN orders o ON o.user_id = u.id WHERE u.email = :1`,
+		},
+		{
+			name:    "sqlite",
+			dialect: SQLite,
+			stmt:    Select().Columns("id").From("users").IndexHint("users", UseIndex, "users_email_idx"),
+			expect:  `SELECT id FROM users INDEXED BY users_email_idx`,
+		},
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			stmt:    stmt,
+			expect:  `SELECT u.id,o.total FROM users u INNER JOIN orders o ON o.user_id = u.id WHERE u.email = $1`,
+		},
+		{
+			name:    "sqlite_multiple",
+			dialect: SQLite,
+			stmt:    stmt,
+			wantErr: ErrUnsupported,
+		},
+		{
+			name:    "unknown_table",
+			dialect: MySQL,
+			stmt:    Select().Columns("id").From("users").IndexHint("orders", UseIndex, "orders_user_idx"),
+			wantErr: ErrIncomplete,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got: %v", tt.wantErr, err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+		})
+	}
+}
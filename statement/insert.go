@@ -1,6 +1,7 @@
 package statement
 
 import (
+	"database/sql/driver"
 	"reflect"
 	"sort"
 	"strings"
@@ -13,12 +14,15 @@ import (
 type InsertStatement struct {
 	table        string
 	columns      []string
-	values       []Statement
+	values       []*Part
 	comment      []Statement
 	valuesSelect *SelectStatement
-	with         Statement
+	fromSelect   Statement
+	with         *with
 	onConflict   Statement
+	conflict     *conflict
 	returning    []string
+	managed      *Timestamps
 }
 
 // Insert creates a new `INSERT` statement.
@@ -29,16 +33,15 @@ func Insert() (s *InsertStatement) {
 // Comment adds a SQL comment to the generated query.
 // Each call to comment creates a new `-- <comment>` line.
 func (s *InsertStatement) Comment(c string, values ...interface{}) *InsertStatement {
-	buf := buffer.New()
-	defer buf.Release()
-
-	_, _ = buf.WriteString("-- ")
-	_, _ = buf.WriteString(c)
+	s.comment = append(s.comment, buildComment(c, values...))
+	return s
+}
 
-	p := &Part{}
-	p.Query = buf.String()
-	p.Values = values
-	s.comment = append(s.comment, p)
+// Tag adds a `/*key='value'*/` comment with the given tags in the sqlcommenter format to the generated query,
+// like `/*action='list',controller='orders'*/`, to correlate queries in the database logs with the code that issued them.
+// As comments, tags are built before the statement in their own line.
+func (s *InsertStatement) Tag(tags map[string]string) *InsertStatement {
+	s.comment = append(s.comment, buildTag(tags))
 	return s
 }
 
@@ -76,8 +79,48 @@ func (s *InsertStatement) Values(values ...interface{}) (st *InsertStatement) {
 	return s
 }
 
+// Rows specifies multiple rows of values for the `VALUES` clause.
+func (s *InsertStatement) Rows(rows ...[]interface{}) (st *InsertStatement) {
+	for x := 0; x < len(rows); x++ {
+		s.Values(rows[x]...)
+	}
+	return s
+}
+
+// Batches splits the statement rows into multiple statements, each within the maximum number of
+// arguments for a single statement. The limit defaults to the maximum supported by the dialect
+// set with WithDialect and can be changed with WithMaxArgs.
+func (s *InsertStatement) Batches(opts ...Option) (batches []*InsertStatement) {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	max := o.maxArgs
+	if max <= 0 {
+		max = o.dialect.maxArgs()
+	}
+
+	start, args := 0, 0
+	for x := 0; x < len(s.values); x++ {
+		n := len(s.values[x].Values)
+		if x > start && args+n > max {
+			batch := *s
+			batch.values = s.values[start:x:x]
+			batches = append(batches, &batch)
+			start, args = x, 0
+		}
+		args += n
+	}
+
+	batch := *s
+	batch.values = s.values[start:len(s.values):len(s.values)]
+	return append(batches, &batch)
+}
+
 // Record add the values from the given struct for insert.
-// If no columns where specified before calling Record(), the columns will be defined by the struct fields.
+// If no columns where specified before calling Record(), the columns will be defined by the struct fields,
+// except for the fields tagged as generated by the database with `db:"column,auto"` or `db:"column,readonly"`.
 func (s *InsertStatement) Record(structValue interface{}) (st *InsertStatement) {
 	v := reflect.Indirect(reflect.ValueOf(structValue))
 
@@ -89,7 +132,11 @@ func (s *InsertStatement) Record(structValue interface{}) (st *InsertStatement)
 		// if no columns were specified up to this point
 		if len(s.columns) == 0 {
 			s.columns = make([]string, 0, len(m))
-			for key := range m {
+			for key, index := range m {
+				field := v.Type().FieldByIndex(index)
+				if scan.IsAuto(field) || scan.IsReadOnly(field) || scan.IsParent(m, index) {
+					continue
+				}
 				s.columns = append(s.columns, key)
 			}
 
@@ -99,7 +146,7 @@ func (s *InsertStatement) Record(structValue interface{}) (st *InsertStatement)
 
 		for _, key := range s.columns {
 			if index, ok := m[key]; ok {
-				value = append(value, v.FieldByIndex(index).Interface())
+				value = append(value, recordValue(v.Type().FieldByIndex(index), v.FieldByIndex(index)))
 			} else {
 				value = append(value, nil)
 			}
@@ -110,12 +157,56 @@ func (s *InsertStatement) Record(structValue interface{}) (st *InsertStatement)
 	return s
 }
 
+// SetStruct adds the values from the given struct, or from each struct of the given slice
+// as multiple rows, for insert as with Record.
+func (s *InsertStatement) SetStruct(value interface{}) (st *InsertStatement) {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	if v.Kind() != reflect.Slice {
+		return s.Record(value)
+	}
+
+	for x := 0; x < v.Len(); x++ {
+		s.Record(v.Index(x).Interface())
+	}
+
+	return s
+}
+
+// recordValue returns the value for binding the given record field,
+// handling json tagged fields as JSON and slices other than []byte as arrays, which are checked
+// against the dialect when built.
+func recordValue(field reflect.StructField, v reflect.Value) interface{} {
+	value := v.Interface()
+	if _, ok := value.(driver.Valuer); ok {
+		return value
+	}
+
+	switch {
+	case scan.IsJSON(field):
+		return JSON(value)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8:
+		return recordArray{scan.Array{V: value}}
+	}
+
+	return value
+}
+
 // ValuesSelect specifies a Select statement from which values will be inserted.
 func (s *InsertStatement) ValuesSelect(values *SelectStatement) (st *InsertStatement) {
+	s.fromSelect = nil
 	s.valuesSelect = values
 	return s
 }
 
+// FromSelect specifies a select or compound statement from which rows will be inserted, as
+// `INSERT INTO table(columns) SELECT ...`, with the statement arguments bound in place.
+// The columns are optional, if none are specified the rows must match the table columns.
+func (s *InsertStatement) FromSelect(stmt Statement) (st *InsertStatement) {
+	s.valuesSelect = nil
+	s.fromSelect = stmt
+	return s
+}
+
 // OnConflict adds a `ON CONFLICT` clause.
 func (s *InsertStatement) OnConflict(q string, values ...interface{}) (st *InsertStatement) {
 	buf := buffer.New()
@@ -128,13 +219,40 @@ func (s *InsertStatement) OnConflict(q string, values ...interface{}) (st *Inser
 	p.Query = buf.String()
 	p.Values = values
 
+	s.conflict = nil
 	s.onConflict = p
 	return s
 }
 
+// OnConflictDoNothing adds a `ON CONFLICT (columns) DO NOTHING` clause, the conflict target columns are optional.
+// On the MySQL dialect it is built as `ON DUPLICATE KEY UPDATE column = column` with the first insert column
+// and conflict target columns are not supported.
+func (s *InsertStatement) OnConflictDoNothing(columns ...string) (st *InsertStatement) {
+	s.onConflict = nil
+	s.conflict = &conflict{nothing: true, target: columns}
+	return s
+}
+
+// OnConflictDoUpdate adds a `ON CONFLICT (columns) DO UPDATE SET column = value` clause
+// for the given assignments, where values can reference the row proposed for insertion with Excluded.
+// On the MySQL dialect it is built as `ON DUPLICATE KEY UPDATE column = value`
+// and conflict target columns are not supported.
+func (s *InsertStatement) OnConflictDoUpdate(columns []string, assignments map[string]interface{}) (st *InsertStatement) {
+	s.onConflict = nil
+	s.conflict = &conflict{target: columns, update: assignments}
+	return s
+}
+
+// Timestamps sets the CreatedAt column from the given Timestamps to Now for each row
+// of the `VALUES` clause, unless it is in the insert columns.
+func (s *InsertStatement) Timestamps(ts Timestamps) *InsertStatement {
+	s.managed = &ts
+	return s
+}
+
 // With adds a `WITH alias AS (stmt)`
 func (s *InsertStatement) With(alias string, stmt Statement) *InsertStatement {
-	s.with = &with{alias: alias, stmt: stmt}
+	s.with = s.with.add(alias, false, stmt)
 	return s
 }
 
@@ -144,8 +262,37 @@ func (s *InsertStatement) Returning(columns ...string) *InsertStatement {
 	return s
 }
 
+// Clone returns a copy of the statement that can be modified without affecting the original,
+// allowing variants to be derived from a shared base statement.
+// Nested statements, like the values select, are shared by both statements.
+func (s *InsertStatement) Clone() *InsertStatement {
+	c := *s
+	c.with = s.with.clone()
+	c.columns = append(s.columns[:0:0], s.columns...)
+	c.values = append(s.values[:0:0], s.values...)
+	c.comment = append(s.comment[:0:0], s.comment...)
+	c.returning = append(s.returning[:0:0], s.returning...)
+	return &c
+}
+
+// Validate checks that the statement is fully specified, returning ErrIncomplete
+// if it has no table or no values or select statement to insert from.
+func (s *InsertStatement) Validate() (err error) {
+	switch {
+	case s.table == "":
+		return incomplete("INSERT", "table")
+	case len(s.values) == 0 && s.valuesSelect == nil && s.fromSelect == nil:
+		return incomplete("INSERT", "values")
+	}
+	return nil
+}
+
 // Build builds the statement into the given buffer.
 func (s *InsertStatement) Build(buf Buffer) (err error) {
+	if err = s.Validate(); err != nil {
+		return err
+	}
+
 	for x := 0; x < len(s.comment); x++ {
 		if err = s.comment[x].Build(buf); err != nil {
 			return err
@@ -161,22 +308,53 @@ func (s *InsertStatement) Build(buf Buffer) (err error) {
 	}
 
 	_, _ = buf.WriteString("INSERT INTO ")
-	_, _ = buf.WriteString(s.table)
+	writeIdent(buf, s.table)
 
-	_, _ = buf.WriteString("(")
-	_, _ = buf.WriteString(strings.Join(s.columns, ","))
-	_, _ = buf.WriteString(")")
+	columns, created := s.columns, s.created()
+	if created != "" {
+		columns = append(columns[:len(columns):len(columns)], created)
+	}
+
+	if len(columns) > 0 || s.fromSelect == nil {
+		_, _ = buf.WriteString("(")
+		writeIdents(buf, columns)
+		_, _ = buf.WriteString(")")
+	}
+
+	buildOutput(buf, "INSERTED", s.returning)
 
-	if s.valuesSelect != nil {
+	switch {
+	case s.fromSelect != nil:
+		_, _ = buf.WriteString(" ")
+		if err = s.fromSelect.Build(buf); err != nil {
+			return err
+		}
+
+	case s.valuesSelect != nil:
 		_, _ = buf.WriteString(" (")
 		if err = s.valuesSelect.Build(buf); err != nil {
 			return err
 		}
 		_, _ = buf.WriteString(")")
-	} else {
+
+	default:
 		_, _ = buf.WriteString(" VALUES ")
 		for x := 0; x < len(s.values); x++ {
-			if err = s.values[0].Build(buf); err != nil {
+			if x > 0 {
+				_, _ = buf.WriteString(",")
+			}
+
+			if created == "" {
+				if err = s.values[x].Build(buf); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// add the created column value to the row
+			row := s.values[x]
+			values := append(row.Values[:len(row.Values):len(row.Values)], Now{})
+			if err = (&Part{Query: strings.TrimSuffix(row.Query, ")") + ",?)", Values: values}).Build(buf); err != nil {
 				return err
 			}
 		}
@@ -189,12 +367,30 @@ func (s *InsertStatement) Build(buf Buffer) (err error) {
 		}
 	}
 
-	if len(s.returning) > 0 {
-		_, _ = buf.WriteString(" RETURNING ")
-		_, _ = buf.WriteString(strings.Join(s.returning, ","))
+	if s.conflict != nil {
+		_, _ = buf.WriteString(" ")
+		if err = s.conflict.build(buf, columns); err != nil {
+			return err
+		}
+	}
+
+	return buildReturning(buf, s.returning)
+}
+
+// created returns the managed CreatedAt column if it must be added to the insert columns.
+func (s *InsertStatement) created() (column string) {
+	if s.managed == nil || s.fromSelect != nil || s.valuesSelect != nil {
+		return ""
 	}
 
-	return nil
+	column = s.managed.column(s.table, s.managed.CreatedAt)
+	for x := 0; x < len(s.columns); x++ {
+		if s.columns[x] == column {
+			return ""
+		}
+	}
+
+	return column
 }
 
 // String builds the statement and returns the resulting query string.
@@ -208,3 +404,8 @@ func (s *InsertStatement) String() (q string, err error) {
 
 	return buf.String(), nil
 }
+
+// SQL builds the statement and returns the resulting parameterized query and arguments.
+func (s *InsertStatement) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
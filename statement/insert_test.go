@@ -1,9 +1,18 @@
 package statement
 
 import (
+	"errors"
+	"reflect"
 	"testing"
 )
 
+type insertUser struct {
+	ID       int64 `db:"id,auto"`
+	Name     string
+	Email    string
+	Password string `db:"-"`
+}
+
 var (
 	insertCases = []struct {
 		name    string
@@ -17,6 +26,27 @@ var (
 			stmt:    Insert().Into("users").Columns("id", "user", "email", "role").Values(123, "john.doe", "john.doe@email.com", "admin"),
 			wantErr: false,
 		},
+		{
+			name:    "valuer",
+			expect:  `INSERT INTO users(id,tags) VALUES (123,'admin,owner')`,
+			stmt:    Insert().Into("users").Columns("id", "tags").Values(123, tags{"admin", "owner"}),
+			wantErr: false,
+		},
+		{
+			name:    "set_struct",
+			expect:  `INSERT INTO users(email,name) VALUES ('john.doe@email.com','john')`,
+			stmt:    Insert().Into("users").SetStruct(insertUser{ID: 1, Name: "john", Email: "john.doe@email.com", Password: "secret"}),
+			wantErr: false,
+		},
+		{
+			name:   "set_struct_slice",
+			expect: `INSERT INTO users(email,name) VALUES ('john.doe@email.com','john'),('jane.doe@email.com','jane')`,
+			stmt: Insert().Into("users").SetStruct([]*insertUser{
+				{Name: "john", Email: "john.doe@email.com"},
+				{Name: "jane", Email: "jane.doe@email.com"},
+			}),
+			wantErr: false,
+		},
 		{
 			name:   "from_select",
 			expect: `INSERT INTO users(id,user,email,role) (SELECT id,user,email,role FROM old_users INNER JOIN roles ON old_users.id = roles.user_id)`,
@@ -24,6 +54,13 @@ var (
 				Select().Columns("id", "user", "email", "role").From("old_users").JoinInner("roles", "old_users.id = roles.user_id")),
 			wantErr: false,
 		},
+		{
+			name:   "from_select_statement",
+			expect: `INSERT INTO users_history SELECT * FROM users WHERE deleted_at < '2020-01-01'`,
+			stmt: Insert().Into("users_history").FromSelect(
+				Select().Columns("*").From("users").Where("deleted_at < ?", "2020-01-01")),
+			wantErr: false,
+		},
 		{
 			name:   "on_conflict",
 			expect: `INSERT INTO users(id,user,email,role) VALUES (123,'john.doe','john.doe@email.com','admin') ON CONFLICT ON CONSTRAINT users_pkey DO UPDATE SET email = 'john.doe@email.com', role = 'admin', user = 'john.doe'`,
@@ -37,6 +74,12 @@ var (
 			stmt:    Insert().Into("users").Columns("id", "user", "email", "role").Values(123, "john.doe", "john.doe@email.com", "admin").Returning("id"),
 			wantErr: false,
 		},
+		{
+			name:    "multiple_rows",
+			expect:  `INSERT INTO users(id,user) VALUES (123,'john.doe'),(124,'jane.doe'),(125,'susan.vix')`,
+			stmt:    Insert().Into("users").Columns("id", "user").Values(123, "john.doe").Rows([]interface{}{124, "jane.doe"}, []interface{}{125, "susan.vix"}),
+			wantErr: false,
+		},
 		{
 			name: "invalid_with_alias",
 			stmt: Insert().Into("users").Columns("id", "user", "email", "role").
@@ -67,3 +110,128 @@ func TestInsert(t *testing.T) {
 		})
 	}
 }
+
+func TestInsertBatches(t *testing.T) {
+	stmt := Insert().Into("users").Columns("id", "user").Returning("id")
+	for x := 0; x < 5; x++ {
+		stmt.Values(x, "user")
+	}
+
+	cases := []struct {
+		name   string
+		opts   []Option
+		expect []string
+	}{
+		{
+			name:   "default",
+			opts:   nil,
+			expect: []string{`INSERT INTO users(id,user) VALUES (?,?),(?,?),(?,?),(?,?),(?,?) RETURNING id`},
+		},
+		{
+			name: "max_args",
+			opts: []Option{WithDialect(Postgres), WithMaxArgs(4)},
+			expect: []string{
+				`INSERT INTO users(id,user) VALUES ($1,$2),($3,$4) RETURNING id`,
+				`INSERT INTO users(id,user) VALUES ($1,$2),($3,$4) RETURNING id`,
+				`INSERT INTO users(id,user) VALUES ($1,$2) RETURNING id`,
+			},
+		},
+		{
+			name: "max_args_below_row",
+			opts: []Option{WithMaxArgs(1)},
+			expect: []string{
+				`INSERT INTO users(id,user) VALUES (?,?) RETURNING id`,
+				`INSERT INTO users(id,user) VALUES (?,?) RETURNING id`,
+				`INSERT INTO users(id,user) VALUES (?,?) RETURNING id`,
+				`INSERT INTO users(id,user) VALUES (?,?) RETURNING id`,
+				`INSERT INTO users(id,user) VALUES (?,?) RETURNING id`,
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			batches := stmt.Batches(tt.opts...)
+			if len(batches) != len(tt.expect) {
+				t.Fatalf("expected %d batches, got: %d", len(tt.expect), len(batches))
+			}
+
+			var args []interface{}
+			for x := range batches {
+				q, a, err := batches[x].SQL(tt.opts...)
+				if err != nil {
+					t.Fatalf("error building statement: %s", err)
+				}
+
+				if tt.expect[x] != q {
+					t.Fatalf("expected: %s, got: %s", tt.expect[x], q)
+				}
+				args = append(args, a...)
+			}
+
+			// all rows must be present and in order
+			for x := 0; x < 5; x++ {
+				if args[x*2] != x {
+					t.Fatalf("expected row %d, got: %#v", x, args[x*2])
+				}
+			}
+		})
+	}
+}
+
+func TestInsertDefaultValue(t *testing.T) {
+	stmt := Insert().Into("users").Columns("id", "name", "role").
+		Values(DefaultValue{}, "john", "admin").
+		Values(42, "jane", DefaultValue{}).
+		Values(DefaultValue{}, "joe", DefaultValue{})
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		expect  string
+		args    []interface{}
+	}{
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			expect:  `INSERT INTO users(id,name,role) VALUES (DEFAULT,$1,$2),($3,$4,DEFAULT),(DEFAULT,$5,DEFAULT)`,
+			args:    []interface{}{"john", "admin", 42, "jane", "joe"},
+		},
+		{
+			name:    "mysql",
+			dialect: MySQL,
+			expect:  `INSERT INTO users(id,name,role) VALUES (DEFAULT,?,?),(?,?,DEFAULT),(DEFAULT,?,DEFAULT)`,
+			args:    []interface{}{"john", "admin", 42, "jane", "joe"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := stmt.SQL(WithDialect(tt.dialect))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+
+	s, err := stmt.String()
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	if expect := `INSERT INTO users(id,name,role) VALUES (DEFAULT,'john','admin'),(42,'jane',DEFAULT),(DEFAULT,'joe',DEFAULT)`; expect != s {
+		t.Fatalf("expected: %s, got: %s", expect, s)
+	}
+
+	if _, _, err = stmt.SQL(WithDialect(SQLite)); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported on sqlite, got: %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package statement
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/brunotm/norm/internal/buffer"
+)
+
+// Interpolate builds the statement with each bound argument written in place as a literal of the dialect
+// set with WithDialect, for logging and debugging the queries that were executed. Strings are quoted and escaped,
+// so that the values can't change the query structure, and arguments are encoded by the encoders added with
+// WithArgEncoder as they would be for execution.
+//
+// Interpolated queries are meant for humans only, they must never be executed. Use SQL to build the
+// parameterized query and arguments for execution.
+func Interpolate(s Statement, opts ...Option) (q string, err error) {
+	buf := &params{Buffer: buffer.New(), inline: true}
+	defer buf.Release()
+
+	for _, opt := range opts {
+		opt(&buf.options)
+	}
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	if buf.err != nil {
+		return "", buf.err
+	}
+
+	if buf.pretty {
+		return Format(buf.String()), nil
+	}
+
+	return buf.String(), nil
+}
+
+// literal writes the given argument into the buffer as a literal of the dialect.
+func (d Dialect) literal(buf Buffer, arg interface{}) (err error) {
+	if v, ok := arg.(driver.Valuer); ok {
+		if arg, err = v.Value(); err != nil {
+			return err
+		}
+	}
+
+	switch arg := underlying(arg).(type) {
+	case nil:
+		_, _ = buf.WriteString("NULL")
+	case string:
+		_, _ = buf.WriteString(d.quoteString(arg))
+	case []byte:
+		_, _ = buf.WriteString(d.quoteBytes(arg))
+	case time.Time:
+		_, _ = buf.WriteString(d.quoteTime(arg))
+	case bool:
+		switch d {
+		case SQLite, Oracle, SQLServer:
+			if arg {
+				_, _ = buf.WriteString("1")
+			} else {
+				_, _ = buf.WriteString("0")
+			}
+		default:
+			_, _ = buf.WriteString(strings.ToUpper(strconv.FormatBool(arg)))
+		}
+	default:
+		return writeValue(buf, arg, true)
+	}
+
+	return nil
+}
+
+// quoteString returns the given string as a quoted literal of the dialect.
+func (d Dialect) quoteString(s string) string {
+	s = strings.ReplaceAll(s, "'", "''")
+
+	switch d {
+	case MySQL:
+		// backslashes are escape characters in MySQL strings unless NO_BACKSLASH_ESCAPES is set
+		return "'" + strings.ReplaceAll(s, `\`, `\\`) + "'"
+	case SQLServer:
+		return "N'" + s + "'"
+	default:
+		return "'" + s + "'"
+	}
+}
+
+// quoteBytes returns the given bytes as a binary literal of the dialect.
+func (d Dialect) quoteBytes(b []byte) string {
+	switch d {
+	case MySQL, SQLite:
+		return "X'" + hex.EncodeToString(b) + "'"
+	case SQLServer:
+		return "0x" + hex.EncodeToString(b)
+	case Oracle:
+		return "HEXTORAW('" + hex.EncodeToString(b) + "')"
+	default:
+		return `'\x` + hex.EncodeToString(b) + "'"
+	}
+}
+
+// quoteTime returns the given time as a timestamp literal of the dialect.
+func (d Dialect) quoteTime(t time.Time) string {
+	switch d {
+	case MySQL:
+		return t.Format("'2006-01-02 15:04:05.999999'")
+	case SQLServer:
+		return t.Format("'2006-01-02T15:04:05.9999999-07:00'")
+	case Oracle:
+		return t.Format("TIMESTAMP '2006-01-02 15:04:05.999999 -07:00'")
+	default:
+		return t.Format(rfc3339micro)
+	}
+}
@@ -0,0 +1,63 @@
+package statement
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInterpolate(t *testing.T) {
+	created := time.Date(2021, 3, 4, 10, 20, 30, 500000000, time.UTC)
+	stmt := Select().Columns("id").From("users").
+		Where("name = ? AND path = ?", "O'Brien", `C:\tmp`).
+		Where("created > ? AND active = ? AND avatar = ? AND score > ? AND deleted IS ?", created, true, []byte{0xca, 0xfe}, 4.5, nil)
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		expect  string
+	}{
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			expect:  `SELECT id FROM users WHERE name = 'O''Brien' AND path = 'C:\tmp' AND created > '2021-03-04T10:20:30.5Z' AND active = TRUE AND avatar = '\xcafe' AND score > 4.5 AND deleted IS NULL`,
+		},
+		{
+			name:    "mysql",
+			dialect: MySQL,
+			expect:  `SELECT id FROM users WHERE name = 'O''Brien' AND path = 'C:\\tmp' AND created > '2021-03-04 10:20:30.5' AND active = TRUE AND avatar = X'cafe' AND score > 4.5 AND deleted IS NULL`,
+		},
+		{
+			name:    "sqlite",
+			dialect: SQLite,
+			expect:  `SELECT id FROM users WHERE name = 'O''Brien' AND path = 'C:\tmp' AND created > '2021-03-04T10:20:30.5Z' AND active = 1 AND avatar = X'cafe' AND score > 4.5 AND deleted IS NULL`,
+		},
+		{
+			name:    "oracle",
+			dialect: Oracle,
+			expect:  `SELECT id FROM users WHERE name = 'O''Brien' AND path = 'C:\tmp' AND created > TIMESTAMP '2021-03-04 10:20:30.5 +00:00' AND active = 1 AND avatar = HEXTORAW('cafe') AND score > 4.5 AND deleted IS NULL`,
+		},
+		{
+			name:    "sqlserver",
+			dialect: SQLServer,
+			expect:  `SELECT id FROM users WHERE name = N'O''Brien' AND path = N'C:\tmp' AND created > '2021-03-04T10:20:30.5+00:00' AND active = 1 AND avatar = 0xcafe AND score > 4.5 AND deleted IS NULL`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := Interpolate(stmt, WithDialect(tt.dialect))
+			if err != nil {
+				t.Fatalf("error interpolating statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			// the parameterized form is not affected
+			if _, args, err := stmt.SQL(WithDialect(tt.dialect)); err != nil || len(args) != 7 {
+				t.Fatalf("expected 7 args, got: %d, err: %v", len(args), err)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package statement
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/brunotm/norm/internal/buffer"
+	"github.com/brunotm/norm/internal/scan"
+)
+
+// Key creates a `(column = value AND ...)` condition matching the columns of a composite key to the given values,
+// with the columns in sorted order. Nil values, nil pointers and driver.Valuer values of NULL, as an invalid
+// sql.NullString, are built as `column IS NULL`.
+// An empty key returns ErrIncomplete, as to not match every row of an update or delete.
+func Key(values map[string]interface{}) Statement {
+	k := &key{columns: make([]string, 0, len(values)), values: make([]interface{}, 0, len(values))}
+	for column := range values {
+		k.columns = append(k.columns, column)
+	}
+	sort.Strings(k.columns)
+
+	for _, column := range k.columns {
+		k.values = append(k.values, values[column])
+	}
+
+	return k
+}
+
+// KeyStruct creates a `(column = value AND ...)` condition matching the fields of the given struct tagged
+// as key columns with `db:"column,key"`, with the columns in the fields declaration order and the fields
+// mapped to columns as with Insert().Record(). Nil pointer and NULL driver.Valuer fields are built as `column IS NULL`.
+// Values that are not a struct or without key fields return ErrIncomplete.
+func KeyStruct(structValue interface{}) Statement {
+	k := &key{}
+
+	v := reflect.Indirect(reflect.ValueOf(structValue))
+	if v.Kind() != reflect.Struct {
+		return k
+	}
+
+	columns, fields := scan.KeyFields(v.Type())
+	for x, index := range fields {
+		k.columns = append(k.columns, columns[x])
+		k.values = append(k.values, recordValue(v.Type().FieldByIndex(index), v.FieldByIndex(index)))
+	}
+
+	return k
+}
+
+// key represents a composite key equality condition.
+type key struct {
+	columns []string
+	values  []interface{}
+}
+
+// Build builds the statement into the given buffer.
+func (s *key) Build(buf Buffer) (err error) {
+	if len(s.columns) == 0 {
+		return fmt.Errorf("%w: key condition without columns", ErrIncomplete)
+	}
+
+	if len(s.columns) > 1 {
+		_, _ = buf.WriteString("(")
+	}
+
+	for x := 0; x < len(s.columns); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(" AND ")
+		}
+
+		writeIdent(buf, s.columns[x])
+		if isNull(s.values[x]) {
+			_, _ = buf.WriteString(" IS NULL")
+			continue
+		}
+
+		_, _ = buf.WriteString(" = ")
+		if err = buildValue(buf, s.values[x], false); err != nil {
+			return err
+		}
+	}
+
+	if len(s.columns) > 1 {
+		_, _ = buf.WriteString(")")
+	}
+
+	return nil
+}
+
+// isNull returns true if the value is nil, a nil pointer or a driver.Valuer with a NULL value.
+func isNull(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && v.IsNil() {
+		return true
+	}
+
+	if valuer, ok := value.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		return err == nil && v == nil
+	}
+
+	return false
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *key) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
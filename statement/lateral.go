@@ -0,0 +1,72 @@
+package statement
+
+// LeftJoinLateral adds a `LEFT OUTER JOIN LATERAL (stmt) AS alias ON cond` clause, where the statement
+// can reference the columns of the preceding tables, as for fetching the top rows of each group.
+// If cond is empty it is built as `ON true`, or `ON 1 = 1` on Oracle. On SQLServer it is built as
+// `OUTER APPLY (stmt) AS alias` and only an empty or `true` condition is supported.
+func (s *SelectStatement) LeftJoinLateral(stmt Statement, alias, cond string, values ...interface{}) *SelectStatement {
+	c := &joinClause{join: LeftOuterJoin, lateral: stmt, table: alias}
+	if cond != "" {
+		c.on = &Part{Query: cond, Values: values}
+	}
+
+	s.join = append(s.join, c)
+	return s
+}
+
+// CrossJoinLateral adds a `CROSS JOIN LATERAL (stmt) AS alias` clause, where the statement
+// can reference the columns of the preceding tables. On SQLServer it is built as `CROSS APPLY (stmt) AS alias`.
+func (s *SelectStatement) CrossJoinLateral(stmt Statement, alias string) *SelectStatement {
+	s.join = append(s.join, &joinClause{join: CrossJoin, lateral: stmt, table: alias})
+	return s
+}
+
+// buildLateral builds a lateral join clause for the buffer dialect.
+func (j *joinClause) buildLateral(buf Buffer) (err error) {
+	d := dialectOf(buf)
+
+	switch d {
+	case SQLite:
+		return unsupported("LATERAL", d)
+	case SQLServer:
+		if j.on != nil && (j.on.Query != "true" || len(j.on.Values) > 0) {
+			return unsupported("LATERAL join condition", d)
+		}
+
+		if j.join == CrossJoin {
+			_, _ = buf.WriteString("CROSS APPLY (")
+		} else {
+			_, _ = buf.WriteString("OUTER APPLY (")
+		}
+	default:
+		_, _ = buf.WriteString(string(j.join))
+		_, _ = buf.WriteString(" LATERAL (")
+	}
+
+	if err = j.lateral.Build(buf); err != nil {
+		return err
+	}
+
+	_, _ = buf.WriteString(")")
+	if d != Oracle {
+		_, _ = buf.WriteString(" AS")
+	}
+	_, _ = buf.WriteString(" ")
+	writeIdent(buf, j.table)
+
+	if j.join == CrossJoin || d == SQLServer {
+		return nil
+	}
+
+	_, _ = buf.WriteString(" ON ")
+	switch {
+	case j.on != nil:
+		return j.on.Build(buf)
+	case d == Oracle:
+		_, _ = buf.WriteString("1 = 1")
+	default:
+		_, _ = buf.WriteString("true")
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package statement
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/brunotm/norm/internal/buffer"
+	"github.com/brunotm/norm/internal/scan"
+)
+
+// ErrMissingNamedArg will be returned when a named parameter has no value in the bound arguments.
+var ErrMissingNamedArg = fmt.Errorf("statement: missing named argument")
+
+// Named creates a statement from a query with named parameters as `:name`, bound to the values
+// from the given map[string]interface{} or struct, where fields are named as in scanning.
+// Parameters are built as positional placeholders for the dialect, and repeated parameters are
+// bound to the same value at each position. Postgres casts as `::type` and quoted strings are ignored.
+func Named(query string, arg interface{}) Parameterized {
+	return &named{query: query, arg: arg}
+}
+
+// Rebind replaces the `?` placeholders in the given query with the placeholders for the dialect.
+func Rebind(d Dialect, query string) string {
+	buf := buffer.New()
+	defer buf.Release()
+
+	n := 0
+	for {
+		idx := strings.IndexByte(query, '?')
+		if idx == -1 {
+			_, _ = buf.WriteString(query)
+			break
+		}
+
+		n++
+		_, _ = buf.WriteString(query[:idx])
+		_, _ = buf.WriteString(d.placeholder(n))
+		query = query[idx+1:]
+	}
+
+	return buf.String()
+}
+
+// named represents a query with named parameters.
+type named struct {
+	query string
+	arg   interface{}
+}
+
+// lookup returns the value for the given name from the bound arguments.
+func (s *named) lookup(name string) (value interface{}, ok bool) {
+	if m, ok := s.arg.(map[string]interface{}); ok {
+		value, ok = m[name]
+		return value, ok
+	}
+
+	v := reflect.Indirect(reflect.ValueOf(s.arg))
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	index, ok := scan.StructMap(v.Type())[name]
+	if !ok {
+		return nil, false
+	}
+
+	return v.FieldByIndex(index).Interface(), true
+}
+
+// Build builds the statement into the given buffer.
+func (s *named) Build(buf Buffer) (err error) {
+	query := s.query
+	quoted := false
+
+	for x := 0; x < len(query); x++ {
+		c := query[x]
+
+		switch {
+		case c == '\'':
+			quoted = !quoted
+
+		case c == ':' && !quoted && x+1 < len(query) && query[x+1] == ':':
+			// postgres cast
+			x++
+			continue
+
+		case c == ':' && !quoted && x+1 < len(query) && isNameStart(query[x+1]):
+			end := x + 1
+			for end < len(query) && isNamePart(query[end]) {
+				end++
+			}
+
+			name := query[x+1 : end]
+			value, ok := s.lookup(name)
+			if !ok {
+				return fmt.Errorf("%w: %s, query: %s", ErrMissingNamedArg, name, s.query)
+			}
+
+			_, _ = buf.WriteString(query[:x])
+			if err = buildValue(buf, value, false); err != nil {
+				return err
+			}
+
+			query, x = query[end:], -1
+		}
+	}
+
+	_, _ = buf.WriteString(query)
+	return nil
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *named) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// SQL builds the statement and returns the resulting parameterized query and arguments.
+func (s *named) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNamePart(c byte) bool {
+	return isNameStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,99 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNamed(t *testing.T) {
+	type filter struct {
+		TenantID int
+		Status   string `db:"state"`
+	}
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		args    []interface{}
+		wantErr bool
+	}{
+		{
+			name:    "map",
+			dialect: Postgres,
+			stmt: Named("SELECT id FROM jobs WHERE tenant_id = :tenant_id AND (status = :status OR prev_status = :status)",
+				map[string]interface{}{"tenant_id": 42, "status": "pending"}),
+			expect: `SELECT id FROM jobs WHERE tenant_id = $1 AND (status = $2 OR prev_status = $3)`,
+			args:   []interface{}{42, "pending", "pending"},
+		},
+		{
+			name:    "struct",
+			dialect: SQLServer,
+			stmt:    Named("UPDATE jobs SET status = :state WHERE tenant_id = :tenant_id", &filter{TenantID: 42, Status: "done"}),
+			expect:  `UPDATE jobs SET status = @p1 WHERE tenant_id = @p2`,
+			args:    []interface{}{"done", 42},
+		},
+		{
+			name:    "casts_and_quotes",
+			dialect: Postgres,
+			stmt: Named("SELECT payload::text, ':literal' FROM jobs WHERE created_at > :since::timestamp",
+				map[string]interface{}{"since": "2021-01-01"}),
+			expect: `SELECT payload::text, ':literal' FROM jobs WHERE created_at > $1::timestamp`,
+			args:   []interface{}{"2021-01-01"},
+		},
+		{
+			name:    "missing",
+			dialect: Postgres,
+			stmt:    Named("SELECT id FROM jobs WHERE tenant_id = :tenant_id AND status = :status", map[string]interface{}{"tenant_id": 42}),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.wantErr {
+				if !errors.Is(err, ErrMissingNamedArg) {
+					t.Fatalf("expected ErrMissingNamedArg, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestRebind(t *testing.T) {
+	query := "SELECT id FROM users WHERE tenant_id = ? AND role IN (?,?)"
+
+	cases := []struct {
+		dialect Dialect
+		expect  string
+	}{
+		{dialect: MySQL, expect: "SELECT id FROM users WHERE tenant_id = ? AND role IN (?,?)"},
+		{dialect: Postgres, expect: "SELECT id FROM users WHERE tenant_id = $1 AND role IN ($2,$3)"},
+		{dialect: Oracle, expect: "SELECT id FROM users WHERE tenant_id = :1 AND role IN (:2,:3)"},
+		{dialect: SQLServer, expect: "SELECT id FROM users WHERE tenant_id = @p1 AND role IN (@p2,@p3)"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.dialect.String(), func(t *testing.T) {
+			if q := Rebind(tt.dialect, query); tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+		})
+	}
+}
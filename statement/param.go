@@ -0,0 +1,242 @@
+package statement
+
+import (
+	"database/sql/driver"
+	"math/big"
+	"reflect"
+	"time"
+
+	"github.com/brunotm/norm/internal/buffer"
+)
+
+// Parameterized represents a statement that can be built into a parameterized query,
+// where values are replaced by placeholders and returned separately as arguments.
+type Parameterized interface {
+	Statement
+	SQL(opts ...Option) (q string, args []interface{}, err error)
+}
+
+// argWriter is implemented by buffers that collect bound arguments
+// instead of interpolating values into the query.
+type argWriter interface {
+	WriteArg(arg interface{}) error
+}
+
+// ArgEncoder transforms a bound argument before it is added to the query arguments,
+// like converting values to the representation expected by a driver.
+// Arguments not handled by the encoder should be returned as is.
+type ArgEncoder func(arg interface{}) (v interface{}, err error)
+
+// WithArgEncoder adds encoders that transform each bound argument of parameterized statements,
+// applied in the given order and after any previously added encoders.
+// Values interpolated in the query, as with String, are not encoded.
+func WithArgEncoder(encoders ...ArgEncoder) Option {
+	return func(o *options) {
+		o.encoders = append(o.encoders, encoders...)
+	}
+}
+
+// TimeUTC is an ArgEncoder that converts time.Time arguments to UTC.
+func TimeUTC(arg interface{}) (v interface{}, err error) {
+	if t, ok := arg.(time.Time); ok {
+		return t.UTC(), nil
+	}
+	return arg, nil
+}
+
+// TimeIn returns an ArgEncoder that converts time.Time and *time.Time arguments to the given location,
+// for drivers that bind times by their wall clock, like MySQL for DATETIME columns.
+func TimeIn(loc *time.Location) ArgEncoder {
+	return func(arg interface{}) (v interface{}, err error) {
+		switch t := arg.(type) {
+		case time.Time:
+			return t.In(loc), nil
+		case *time.Time:
+			if t != nil {
+				return t.In(loc), nil
+			}
+		}
+		return arg, nil
+	}
+}
+
+// WithDedupArgs binds repeated argument values to a single placeholder referenced at each position,
+// as `tenant_id = $1 AND owner_tenant_id = $1`, reducing the number of arguments of the statement.
+// Only dialects with numbered placeholders, Postgres, Oracle and SQLServer, can reference a placeholder
+// multiple times, others bind each value at each position. Only values of scalar types, like integers,
+// strings and time.Time, are deduplicated after encoding with the buffer encoders.
+func WithDedupArgs() Option {
+	return func(o *options) {
+		o.dedupArgs = true
+	}
+}
+
+// BigDecimal is an ArgEncoder that converts *big.Int, *big.Float and *big.Rat arguments to their exact
+// decimal representation as a string, which drivers bind to NUMERIC and DECIMAL columns without the
+// precision loss of float64. It returns an error for *big.Rat values without a finite decimal representation.
+func BigDecimal(arg interface{}) (v interface{}, err error) {
+	switch arg.(type) {
+	case *big.Int, *big.Float, *big.Rat:
+		text, ok, err := bigText(arg)
+		if err != nil || !ok {
+			return nil, err
+		}
+		return text, nil
+	}
+	return arg, nil
+}
+
+// BoolInt is an ArgEncoder that converts bool arguments to 1 or 0, for drivers without native booleans.
+func BoolInt(arg interface{}) (v interface{}, err error) {
+	if b, ok := arg.(bool); ok {
+		if b {
+			return 1, nil
+		}
+		return 0, nil
+	}
+	return arg, nil
+}
+
+// basicTypes are the predeclared types of the scalar kinds, to which named types are converted when bound.
+var basicTypes = map[reflect.Kind]reflect.Type{
+	reflect.Bool:    reflect.TypeOf(false),
+	reflect.String:  reflect.TypeOf(""),
+	reflect.Int:     reflect.TypeOf(int(0)),
+	reflect.Int8:    reflect.TypeOf(int8(0)),
+	reflect.Int16:   reflect.TypeOf(int16(0)),
+	reflect.Int32:   reflect.TypeOf(int32(0)),
+	reflect.Int64:   reflect.TypeOf(int64(0)),
+	reflect.Uint:    reflect.TypeOf(uint(0)),
+	reflect.Uint8:   reflect.TypeOf(uint8(0)),
+	reflect.Uint16:  reflect.TypeOf(uint16(0)),
+	reflect.Uint32:  reflect.TypeOf(uint32(0)),
+	reflect.Uint64:  reflect.TypeOf(uint64(0)),
+	reflect.Float32: reflect.TypeOf(float32(0)),
+	reflect.Float64: reflect.TypeOf(float64(0)),
+}
+
+// underlying returns the value of named scalar and []byte types, like `type Status string` enums,
+// as their underlying predeclared type, so drivers that don't convert named types accept them.
+// Values implementing driver.Valuer and other types are returned as is.
+func underlying(arg interface{}) interface{} {
+	if _, ok := arg.(driver.Valuer); ok || arg == nil {
+		return arg
+	}
+
+	t := reflect.TypeOf(arg)
+	if t.PkgPath() == "" {
+		return arg
+	}
+
+	if b, ok := basicTypes[t.Kind()]; ok {
+		return reflect.ValueOf(arg).Convert(b).Interface()
+	}
+
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return reflect.ValueOf(arg).Convert(reflect.TypeOf([]byte(nil))).Interface()
+	}
+
+	return arg
+}
+
+// params is a Buffer that writes a placeholder for each value
+// and collects the values as query arguments, or writes the values as literals if inline.
+type params struct {
+	*buffer.Buffer
+	options
+	args   []interface{}
+	bound  map[interface{}]int
+	inline bool
+	err    error
+}
+
+// WriteArg writes a placeholder for the given argument into the buffer, after encoding it
+// with the buffer encoders. Values of named scalar types without a driver.Valuer implementation, like
+// `type Status string`, are then bound as their underlying type, and can be bound otherwise with an ArgEncoder.
+// Placeholders are numbered by their position within the whole statement,
+// including any nested statements built into the same buffer.
+func (p *params) WriteArg(arg interface{}) (err error) {
+	for _, enc := range p.encoders {
+		if arg, err = enc(arg); err != nil {
+			return err
+		}
+	}
+	arg = underlying(arg)
+
+	if p.inline {
+		return p.dialect.literal(p, arg)
+	}
+
+	if !p.dedupArgs || !p.dialect.numbered() || !dedupable(arg) {
+		p.args = append(p.args, arg)
+		_, _ = p.WriteString(p.dialect.placeholder(len(p.args)))
+		return nil
+	}
+
+	n, ok := p.bound[arg]
+	if !ok {
+		if p.bound == nil {
+			p.bound = map[interface{}]int{}
+		}
+
+		p.args = append(p.args, arg)
+		n = len(p.args)
+		p.bound[arg] = n
+	}
+
+	_, _ = p.WriteString(p.dialect.placeholder(n))
+	return nil
+}
+
+// dedupable returns true if the given argument is of a scalar type which can be compared for deduplication.
+func dedupable(arg interface{}) bool {
+	switch arg.(type) {
+	case nil:
+		return false
+	case time.Time:
+		return true
+	}
+
+	switch reflect.TypeOf(arg).Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// buildSQL builds the given statement into a parameterized query and its arguments.
+func buildSQL(s Statement, opts ...Option) (q string, args []interface{}, err error) {
+	buf := &params{Buffer: buffer.New()}
+	defer buf.Release()
+
+	for _, opt := range opts {
+		opt(&buf.options)
+	}
+
+	if err = s.Build(buf); err != nil {
+		return "", nil, err
+	}
+
+	if buf.err != nil {
+		return "", nil, buf.err
+	}
+
+	if buf.pretty {
+		return Format(buf.String()), buf.args, nil
+	}
+
+	return buf.String(), buf.args, nil
+}
+
+// dialectOf returns the dialect for the given buffer,
+// or the Default dialect if the buffer was not created with options.
+func dialectOf(buf Buffer) Dialect {
+	if p, ok := buf.(*params); ok {
+		return p.dialect
+	}
+	return Default
+}
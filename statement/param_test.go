@@ -0,0 +1,359 @@
+package statement
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tags is a custom type implementing driver.Valuer
+type tags []string
+
+func (t tags) Value() (driver.Value, error) {
+	return strings.Join(t, ","), nil
+}
+
+var (
+	paramCases = []struct {
+		name    string
+		expect  string
+		args    []interface{}
+		stmt    Parameterized
+		wantErr bool
+	}{
+		{
+			name:   "select",
+			expect: `SELECT id,user,email,role FROM users WHERE email = ? AND role IN (?,?)`,
+			args:   []interface{}{"john.doe@email.com", "admin", "owner"},
+			stmt: Select().Columns("id", "user", "email", "role").From("users").Where("email = ?", "john.doe@email.com").
+				WhereIn("role", "admin", "owner"),
+			wantErr: false,
+		},
+		{
+			name: "select_comment_subquery",
+			expect: `-- request id: 12435
+SELECT id FROM users WHERE role_id IN (SELECT id FROM roles WHERE name = ?) AND email = ?`,
+			args: []interface{}{"admin", "john.doe@email.com"},
+			stmt: Select().Comment("request id: ?", 12435).Columns("id").From("users").
+				Where("role_id IN ?", Select().Columns("id").From("roles").Where("name = ?", "admin")).
+				Where("email = ?", "john.doe@email.com"),
+			wantErr: false,
+		},
+		{
+			name:    "insert",
+			expect:  `INSERT INTO users(id,user) VALUES (?,?) RETURNING id`,
+			args:    []interface{}{123, "john.doe"},
+			stmt:    Insert().Into("users").Columns("id", "user").Values(123, "john.doe").Returning("id"),
+			wantErr: false,
+		},
+		{
+			name:    "update",
+			expect:  `UPDATE users SET email = ?, role = ? WHERE id = ?`,
+			args:    []interface{}{"john.doe@email.com", "admin", 123},
+			stmt:    Update().Table("users").Set("role", "admin").Set("email", "john.doe@email.com").Where("id = ?", 123),
+			wantErr: false,
+		},
+		{
+			name:    "delete",
+			expect:  `DELETE FROM users WHERE id = ?`,
+			args:    []interface{}{123},
+			stmt:    Delete().From("users").Where("id = ?", 123),
+			wantErr: false,
+		},
+		{
+			name:    "ddl",
+			expect:  `DROP TABLE users CASCADE`,
+			args:    nil,
+			stmt:    Drop("TABLE ? CASCADE", "users"),
+			wantErr: false,
+		},
+		{
+			name:    "part_ident",
+			expect:  `INSERT INTO migrations(version,date) VALUES (?,NOW())`,
+			args:    []interface{}{1},
+			stmt:    &Part{Query: "INSERT INTO migrations(version,date) VALUES (?,?)", Values: []interface{}{1, Ident("NOW()")}},
+			wantErr: false,
+		},
+		{
+			name:    "valuer",
+			expect:  `INSERT INTO users(id,tags) VALUES (?,?)`,
+			args:    []interface{}{123, tags{"admin", "owner"}},
+			stmt:    Insert().Into("users").Columns("id", "tags").Values(123, tags{"admin", "owner"}),
+			wantErr: false,
+		},
+		{
+			name:    "invalid_arg_number",
+			stmt:    &Part{Query: "SELECT * FROM users WHERE id = ?"},
+			wantErr: true,
+		},
+	}
+)
+
+func TestParameterized(t *testing.T) {
+	for _, tt := range paramCases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL()
+			if !tt.wantErr && err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.wantErr && err == nil {
+				t.Fatalf("expected error building statement")
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
+
+// status is a custom enum type encoded by its name
+type status int
+
+const (
+	active status = iota + 1
+	disabled
+)
+
+func encodeStatus(arg interface{}) (interface{}, error) {
+	s, ok := arg.(status)
+	if !ok {
+		return arg, nil
+	}
+
+	switch s {
+	case active:
+		return "active", nil
+	case disabled:
+		return "disabled", nil
+	}
+
+	return nil, fmt.Errorf("invalid status: %d", s)
+}
+
+func TestArgEncoder(t *testing.T) {
+	created := time.Date(2021, 10, 1, 12, 0, 0, 0, time.FixedZone("UTC-3", -3*60*60))
+
+	t.Run("encoders", func(t *testing.T) {
+		stmt := Update().Table("users").Set("status", disabled).Set("verified", true).
+			Where("status = ? AND created > ?", active, created)
+
+		q, args, err := stmt.SQL(WithDialect(Postgres), WithArgEncoder(encodeStatus, TimeUTC), WithArgEncoder(BoolInt))
+		if err != nil {
+			t.Fatalf("error building statement: %s", err)
+		}
+
+		if expect := `UPDATE users SET status = $1, verified = $2 WHERE status = $3 AND created > $4`; expect != q {
+			t.Fatalf("expected: %s, got: %s", expect, q)
+		}
+
+		expect := []interface{}{"disabled", 1, "active", created.UTC()}
+		if !reflect.DeepEqual(expect, args) {
+			t.Fatalf("expected args: %#v, got: %#v", expect, args)
+		}
+
+		if args[3].(time.Time).Location() != time.UTC {
+			t.Fatalf("expected UTC time, got: %s", args[3])
+		}
+	})
+
+	t.Run("location", func(t *testing.T) {
+		loc := time.FixedZone("CET", 60*60)
+		stmt := Select().Columns("id").From("users").Where("created > ? AND updated > ?", created, &created)
+
+		_, args, err := stmt.SQL(WithArgEncoder(TimeIn(loc)))
+		if err != nil {
+			t.Fatalf("error building statement: %s", err)
+		}
+
+		for _, arg := range args {
+			if v := arg.(time.Time); !v.Equal(created) || v.Location() != loc {
+				t.Fatalf("expected time in %s, got: %s", loc, v)
+			}
+		}
+	})
+
+	t.Run("big_decimal", func(t *testing.T) {
+		amount, _ := new(big.Rat).SetString("12345678901234567890.123456789")
+		total, _ := new(big.Int).SetString("98765432109876543210", 10)
+		rate := new(big.Float).SetPrec(128).SetFloat64(0.5)
+		var missing *big.Int
+
+		stmt := Insert().Into("ledger").Columns("amount", "total", "rate", "fee").Values(amount, total, rate, missing)
+
+		_, args, err := stmt.SQL(WithDialect(Postgres), WithArgEncoder(BigDecimal))
+		if err != nil {
+			t.Fatalf("error building statement: %s", err)
+		}
+
+		expect := []interface{}{"12345678901234567890.123456789", "98765432109876543210", "0.5", nil}
+		if !reflect.DeepEqual(expect, args) {
+			t.Fatalf("expected args: %#v, got: %#v", expect, args)
+		}
+
+		q, err := Interpolate(stmt)
+		if err != nil {
+			t.Fatalf("error interpolating statement: %s", err)
+		}
+
+		if expect := `INSERT INTO ledger(amount,total,rate,fee) VALUES (12345678901234567890.123456789,98765432109876543210,0.5,null)`; expect != q {
+			t.Fatalf("expected: %s, got: %s", expect, q)
+		}
+
+		if _, _, err = Select().Columns("id").From("ledger").Where("amount = ?", big.NewRat(1, 3)).
+			SQL(WithArgEncoder(BigDecimal)); err == nil {
+			t.Fatalf("expected error for a fraction without a finite decimal representation")
+		}
+	})
+
+	t.Run("error", func(t *testing.T) {
+		stmt := Select().Columns("id").From("users").Where("status = ?", status(42))
+
+		if _, _, err := stmt.SQL(WithArgEncoder(encodeStatus)); err == nil || err.Error() != "invalid status: 42" {
+			t.Fatalf("expected encoder error, got: %v", err)
+		}
+	})
+}
+
+func TestDedupArgs(t *testing.T) {
+	tenant := int64(42)
+	stmt := Select().Columns("p.id").From("projects p").
+		JoinInner("owners o", "o.id = p.owner_id AND o.tenant_id = ?", tenant).
+		Where("p.tenant_id = ? AND p.status = ?", tenant, "active").
+		WhereCond(Exists(Select().Columns("1").From("members m").
+			Where("m.project_id = p.id AND m.tenant_id = ? AND m.tags = ?", tenant, tags{"a"})))
+
+	cases := []struct {
+		name   string
+		opts   []Option
+		expect string
+		args   []interface{}
+	}{
+		{
+			name:   "postgres",
+			opts:   []Option{WithDialect(Postgres), WithDedupArgs()},
+			expect: `SELECT p.id FROM projects p INNER JOIN owners o ON o.id = p.owner_id AND o.tenant_id = $1 WHERE p.tenant_id = $1 AND p.status = $2 AND EXISTS (SELECT 1 FROM members m WHERE m.project_id = p.id AND m.tenant_id = $1 AND m.tags = $3)`,
+			args:   []interface{}{tenant, "active", tags{"a"}},
+		},
+		{
+			name:   "sqlserver",
+			opts:   []Option{WithDialect(SQLServer), WithDedupArgs()},
+			expect: `SELECT p.id FROM projects p INNER JOIN owners o ON o.id = p.owner_id AND o.tenant_id = @p1 WHERE p.tenant_id = @p1 AND p.status = @p2 AND EXISTS (SELECT 1 FROM members m WHERE m.project_id = p.id AND m.tenant_id = @p1 AND m.tags = @p3)`,
+			args:   []interface{}{tenant, "active", tags{"a"}},
+		},
+		{
+			name:   "postgres_without_dedup",
+			opts:   []Option{WithDialect(Postgres)},
+			expect: `SELECT p.id FROM projects p INNER JOIN owners o ON o.id = p.owner_id AND o.tenant_id = $1 WHERE p.tenant_id = $2 AND p.status = $3 AND EXISTS (SELECT 1 FROM members m WHERE m.project_id = p.id AND m.tenant_id = $4 AND m.tags = $5)`,
+			args:   []interface{}{tenant, tenant, "active", tenant, tags{"a"}},
+		},
+		{
+			name:   "mysql",
+			opts:   []Option{WithDialect(MySQL), WithDedupArgs()},
+			expect: `SELECT p.id FROM projects p INNER JOIN owners o ON o.id = p.owner_id AND o.tenant_id = ? WHERE p.tenant_id = ? AND p.status = ? AND EXISTS (SELECT 1 FROM members m WHERE m.project_id = p.id AND m.tenant_id = ? AND m.tags = ?)`,
+			args:   []interface{}{tenant, tenant, "active", tenant, tags{"a"}},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := stmt.SQL(tt.opts...)
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if len(tt.args) != len(args) || !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected %d args: %#v, got %d: %#v", len(tt.args), tt.args, len(args), args)
+			}
+		})
+	}
+
+	// values of distinct types are bound separately
+	_, args, err := Select().Columns("id").From("t").Where("a = ? AND b = ? AND c = ?", 1, int64(1), 1).
+		SQL(WithDialect(Oracle), WithDedupArgs())
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	if expect := []interface{}{1, int64(1)}; !reflect.DeepEqual(expect, args) {
+		t.Fatalf("expected args: %#v, got: %#v", expect, args)
+	}
+}
+
+type orderStatus string
+
+type errorCode int
+
+// eventKind is a named int implementing fmt.Stringer
+type eventKind int
+
+func (k eventKind) String() string {
+	return fmt.Sprintf("kind-%d", int(k))
+}
+
+func TestNamedTypes(t *testing.T) {
+	stmt := Select().Columns("id").From("orders").
+		Where("status = ? AND code = ? AND kind = ?", orderStatus("paid"), errorCode(404), eventKind(2)).
+		Where("tags = ?", tags{"a", "b"})
+
+	q, args, err := stmt.SQL(WithDialect(Postgres))
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	if expect := `SELECT id FROM orders WHERE status = $1 AND code = $2 AND kind = $3 AND tags = $4`; expect != q {
+		t.Fatalf("expected: %s, got: %s", expect, q)
+	}
+
+	// named types are bound as their underlying type, valuers as is
+	expect := []interface{}{"paid", 404, 2, tags{"a", "b"}}
+	if !reflect.DeepEqual(expect, args) {
+		t.Fatalf("expected args: %#v, got: %#v", expect, args)
+	}
+
+	// encoders see the named types first
+	_, args, err = stmt.SQL(WithArgEncoder(func(arg interface{}) (v interface{}, err error) {
+		if s, ok := arg.(orderStatus); ok {
+			return strings.ToUpper(string(s)), nil
+		}
+		return arg, nil
+	}))
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	if args[0] != "PAID" {
+		t.Fatalf("expected encoded status, got: %#v", args[0])
+	}
+
+	q, err = Interpolate(stmt, WithDialect(Postgres))
+	if err != nil {
+		t.Fatalf("error interpolating statement: %s", err)
+	}
+
+	if expect := `SELECT id FROM orders WHERE status = 'paid' AND code = 404 AND kind = 2 AND tags = 'a,b'`; expect != q {
+		t.Fatalf("expected: %s, got: %s", expect, q)
+	}
+
+	s, err := Select().Columns("id").From("orders").Where("status = ? AND code = ?", orderStatus("paid"), errorCode(404)).String()
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	if expect := `SELECT id FROM orders WHERE status = 'paid' AND code = 404`; expect != s {
+		t.Fatalf("expected: %s, got: %s", expect, s)
+	}
+}
@@ -10,6 +10,10 @@ import (
 // Ident type is handled as an user provided identifier as is in the resulting query
 type Ident string
 
+// columnIdent is a column identifier written with writeIdent, as the column of conditions
+// like In, Between, Like, IsNull and IsNotNull, so it is quoted and validated as other columns.
+type columnIdent string
+
 // Part is a query fragment that satisfies the statement.Statement interface
 type Part struct {
 	Query  string
@@ -53,21 +57,15 @@ func (p *Part) build(buf Buffer, keyword bool) (err error) {
 		arg := p.Values[valueIdx]
 		valueIdx++
 
-		switch arg := arg.(type) {
-		case Statement:
-			_, _ = buf.WriteString("(")
-			err = arg.Build(buf)
-			_, _ = buf.WriteString(")")
-		case Ident:
-			_, _ = buf.WriteString(string(arg))
-		default:
-			err = writeValue(buf, arg, keyword)
-		}
-
-		if err != nil {
+		if err = buildValue(buf, arg, keyword); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// SQL builds the statement and returns the resulting parameterized query and arguments.
+func (p *Part) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(p, opts...)
+}
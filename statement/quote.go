@@ -0,0 +1,266 @@
+package statement
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithQuoting quotes the table and column identifiers of the statement that are reserved words
+// or are not lowercase, like `order` or `userId`, according to the dialect set with WithDialect,
+// as `"order"` on the Default, Postgres, SQLite and Oracle dialects, `order` in backticks on MySQL
+// and `[order]` on SQLServer. Lowercase identifiers are left unquoted, as are expressions and
+// user provided query fragments like conditions. The columns of conditions built with In, Between,
+// Like, IsNull, IsNotNull and Key are quoted as other columns.
+func WithQuoting() Option {
+	return func(o *options) {
+		o.quote = true
+	}
+}
+
+// WithStrictIdents makes building statements fail with ErrInvalidIdent for table and column identifiers
+// that are not valid according to ValidIdent, other than `*`, identifiers marked with Quote and the aliases
+// and ordering that can follow them, as `table t`, `column AS c` or `column DESC`. It guards against
+// injection through identifiers provided by users, like sort columns, at the cost of rejecting expressions
+// as columns, like Count("*"), which must be added with Column instead.
+func WithStrictIdents() Option {
+	return func(o *options) {
+		o.strictIdents = true
+	}
+}
+
+// ValidIdent returns true if the given name is a valid unquoted identifier, as `[A-Za-z_][A-Za-z0-9_]*`,
+// optionally qualified with dots as `schema.table.column`. It can be used to validate identifiers provided
+// by users before building statements with them.
+func ValidIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for _, part := range strings.Split(name, ".") {
+		if part == "" {
+			return false
+		}
+
+		for x := 0; x < len(part); x++ {
+			c := part[x]
+			switch {
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+			case x > 0 && c >= '0' && c <= '9':
+			default:
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// strictIdent returns true if the given identifier is valid with the WithStrictIdents option.
+func strictIdent(name string) bool {
+	fields := identFields(name)
+	if len(fields) == 0 || len(fields) > 4 || strings.Join(fields, " ") != name {
+		return false
+	}
+
+	for x, f := range fields {
+		if x > 0 && identKeywords[strings.ToUpper(f)] {
+			continue
+		}
+
+		if x > 0 && !(x == 1 || strings.EqualFold(fields[x-1], "AS")) {
+			return false
+		}
+
+		parts := identParts(f)
+		for y, p := range parts {
+			switch {
+			case p == "*" && y == len(parts)-1 && x == 0:
+			case len(p) > 1 && p[0] == '"' && p[len(p)-1] == '"' &&
+				!strings.Contains(strings.ReplaceAll(p[1:len(p)-1], `""`, ""), `"`):
+			case !ValidIdent(p):
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// Quote marks the given identifier for quoting as `"name"`, which is built with the quoting
+// of the dialect set with WithDialect wherever table and column identifiers are expected,
+// regardless of WithQuoting.
+func Quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quote quotes the given identifier for the dialect.
+func (d Dialect) quote(name string) string {
+	switch d {
+	case MySQL:
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	case SQLServer:
+		return "[" + strings.ReplaceAll(name, "]", "]]") + "]"
+	default:
+		return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+	}
+}
+
+// writeIdents writes the given comma separated identifiers into the buffer.
+func writeIdents(buf Buffer, names []string) {
+	for x := 0; x < len(names); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(",")
+		}
+		writeIdent(buf, names[x])
+	}
+}
+
+// writeIdent writes the given identifier into the buffer, quoting it according to the buffer options.
+// Identifiers can be qualified with any number of dot separated parts, as `table.column`, `schema.table`
+// or `database.schema.table`, and followed by an alias or ordering, as `table t`, `column AS c` or `column DESC`.
+// Each part is quoted independently, and parts marked with Quote can contain dots and spaces, as
+// Quote("my.schema")+".events". Anything else, like expressions, is written as is.
+func writeIdent(buf Buffer, name string) {
+	d, all := Default, false
+	if p, ok := buf.(*params); ok {
+		d, all = p.dialect, p.quote
+
+		if p.strictIdents && p.err == nil && !strictIdent(name) {
+			p.err = fmt.Errorf("%w: %q", ErrInvalidIdent, name)
+		}
+	}
+
+	if !all && !strings.Contains(name, `"`) {
+		_, _ = buf.WriteString(name)
+		return
+	}
+
+	_, _ = buf.WriteString(quoteIdent(d, all, name))
+}
+
+// quoteIdent returns the given identifier quoted for the dialect, or as is if it is an expression.
+func quoteIdent(d Dialect, all bool, name string) string {
+	fields := identFields(name)
+	if len(fields) == 0 {
+		return name
+	}
+
+	for x, f := range fields {
+		if x > 0 && identKeywords[strings.ToUpper(f)] {
+			continue
+		}
+
+		parts := identParts(f)
+		for y, p := range parts {
+			switch {
+			case p == "*" && y == len(parts)-1:
+			case len(p) > 1 && p[0] == '"' && p[len(p)-1] == '"':
+				parts[y] = d.quote(strings.ReplaceAll(p[1:len(p)-1], `""`, `"`))
+			case !isIdent(p):
+				return name
+			case all && needsQuoting(p):
+				parts[y] = d.quote(p)
+			}
+		}
+		fields[x] = strings.Join(parts, ".")
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// identFields splits the given identifier into its whitespace separated fields, as strings.Fields,
+// except for whitespace within double quotes.
+func identFields(name string) (fields []string) {
+	start, quoted := -1, false
+	for x := 0; x < len(name); x++ {
+		c := name[x]
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case !quoted && (c == ' ' || c == '\t' || c == '\n' || c == '\r'):
+			if start != -1 {
+				fields = append(fields, name[start:x])
+				start = -1
+			}
+			continue
+		}
+
+		if start == -1 {
+			start = x
+		}
+	}
+
+	if start != -1 {
+		fields = append(fields, name[start:])
+	}
+
+	return fields
+}
+
+// identParts splits the given identifier field into its dot separated parts,
+// except for dots within double quotes.
+func identParts(field string) (parts []string) {
+	start, quoted := 0, false
+	for x := 0; x < len(field); x++ {
+		switch field[x] {
+		case '"':
+			quoted = !quoted
+		case '.':
+			if !quoted {
+				parts = append(parts, field[start:x])
+				start = x + 1
+			}
+		}
+	}
+
+	return append(parts, field[start:])
+}
+
+// identKeywords are the keywords that can follow an identifier.
+var identKeywords = map[string]bool{
+	"AS": true, "ASC": true, "DESC": true, "NULLS": true, "FIRST": true, "LAST": true,
+}
+
+// isIdent returns true if the given name is a valid unquoted identifier.
+func isIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+
+	for x := 0; x < len(name); x++ {
+		c := name[x]
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case x > 0 && (c >= '0' && c <= '9' || c == '$'):
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// needsQuoting returns true if the given identifier is a reserved word or is not lowercase.
+func needsQuoting(name string) bool {
+	if reserved[strings.ToUpper(name)] {
+		return true
+	}
+
+	return strings.ToLower(name) != name
+}
+
+// reserved are the commonly reserved words of the supported dialects.
+var reserved = map[string]bool{
+	"ALL": true, "ALTER": true, "AND": true, "ANY": true, "AS": true, "ASC": true, "BETWEEN": true,
+	"BY": true, "CASE": true, "CHECK": true, "COLUMN": true, "CONSTRAINT": true, "CREATE": true,
+	"CROSS": true, "CURRENT": true, "DEFAULT": true, "DELETE": true, "DESC": true, "DISTINCT": true,
+	"DROP": true, "ELSE": true, "END": true, "EXCEPT": true, "EXISTS": true, "FETCH": true,
+	"FOR": true, "FOREIGN": true, "FROM": true, "FULL": true, "GRANT": true, "GROUP": true,
+	"HAVING": true, "IN": true, "INDEX": true, "INNER": true, "INSERT": true, "INTERSECT": true,
+	"INTO": true, "IS": true, "JOIN": true, "KEY": true, "LEFT": true, "LEVEL": true, "LIKE": true,
+	"LIMIT": true, "NATURAL": true, "NOT": true, "NULL": true, "OF": true, "OFFSET": true, "ON": true,
+	"OR": true, "ORDER": true, "OUTER": true, "PRIMARY": true, "RANGE": true, "REFERENCES": true,
+	"RIGHT": true, "ROW": true, "ROWS": true, "SELECT": true, "SET": true, "TABLE": true, "THEN": true,
+	"TO": true, "UNION": true, "UNIQUE": true, "UPDATE": true, "USER": true, "USING": true,
+	"VALUES": true, "WHEN": true, "WHERE": true, "WINDOW": true, "WITH": true,
+}
@@ -0,0 +1,219 @@
+package statement
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestQuoting(t *testing.T) {
+	cases := []struct {
+		name   string
+		stmt   Parameterized
+		opts   []Option
+		expect string
+	}{
+		{
+			name:   "default_unquoted",
+			stmt:   Select().Columns("id", "order").From("orders"),
+			expect: `SELECT id,order FROM orders`,
+		},
+		{
+			name:   "default",
+			stmt:   Select().Columns("id", "order").From("orders"),
+			opts:   []Option{WithQuoting()},
+			expect: `SELECT id,"order" FROM orders`,
+		},
+		{
+			name:   "postgres",
+			stmt:   Select().Columns("id", "order").From("orders").OrderAsc("order"),
+			opts:   []Option{WithDialect(Postgres), WithQuoting()},
+			expect: `SELECT id,"order" FROM orders ORDER BY "order" ASC`,
+		},
+		{
+			name:   "mysql",
+			stmt:   Select().Columns("id", "order").From("orders").GroupBy("order"),
+			opts:   []Option{WithDialect(MySQL), WithQuoting()},
+			expect: "SELECT id,`order` FROM orders GROUP BY `order`",
+		},
+		{
+			name:   "sqlserver",
+			stmt:   Select().Columns("id", "order").From("orders"),
+			opts:   []Option{WithDialect(SQLServer), WithQuoting()},
+			expect: `SELECT id,[order] FROM orders`,
+		},
+		{
+			name: "qualified_aliased_mixed_case",
+			stmt: Select().Columns("o.order", "o.userId AS user", "count(*) AS total").From("orders o").
+				JoinInner("User u", "u.id = o.userId").OrderDesc("o.order"),
+			opts:   []Option{WithDialect(Postgres), WithQuoting()},
+			expect: `SELECT o."order",o."userId" AS "user",count(*) AS total FROM orders o INNER JOIN "User" u ON u.id = o.userId ORDER BY o."order" DESC`,
+		},
+		{
+			name:   "marked",
+			stmt:   Select().Columns("id", Quote("Name")).From("users"),
+			opts:   []Option{WithDialect(MySQL)},
+			expect: "SELECT id,`Name` FROM users",
+		},
+		{
+			name:   "insert",
+			stmt:   Insert().Into("orders").Columns("id", "order").Values(1, 2).Returning("order"),
+			opts:   []Option{WithDialect(Postgres), WithQuoting()},
+			expect: `INSERT INTO orders(id,"order") VALUES ($1,$2) RETURNING "order"`,
+		},
+		{
+			name:   "update",
+			stmt:   Update().Table("orders").Set("order", 2).Where("id = ?", 1),
+			opts:   []Option{WithDialect(SQLServer), WithQuoting()},
+			expect: `UPDATE orders SET [order] = @p1 WHERE id = @p2`,
+		},
+		{
+			name:   "delete",
+			stmt:   Delete().From("user").Where("id = ?", 1),
+			opts:   []Option{WithDialect(Postgres), WithQuoting()},
+			expect: `DELETE FROM "user" WHERE id = $1`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _, err := tt.stmt.SQL(tt.opts...)
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+		})
+	}
+}
+
+func TestQualifiedIdents(t *testing.T) {
+	stmt := Select().Columns("e.id", "e.Name", "analytics.events.order").From("analytics.events e").
+		JoinInner("otherdb.dbo.User u", "u.id = e.user_id").
+		JoinInner(Quote("my.schema")+"."+Quote("Order Items")+" oi", "oi.id = e.item_id")
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		opts    []Option
+		expect  string
+	}{
+		{
+			name:    "unquoted",
+			dialect: Postgres,
+			expect:  `SELECT e.id,e.Name,analytics.events.order FROM analytics.events e INNER JOIN otherdb.dbo.User u ON u.id = e.user_id INNER JOIN "my.schema"."Order Items" oi ON oi.id = e.item_id`,
+		},
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			opts:    []Option{WithQuoting()},
+			expect:  `SELECT e.id,e."Name",analytics.events."order" FROM analytics.events e INNER JOIN otherdb.dbo."User" u ON u.id = e.user_id INNER JOIN "my.schema"."Order Items" oi ON oi.id = e.item_id`,
+		},
+		{
+			name:    "mysql",
+			dialect: MySQL,
+			opts:    []Option{WithQuoting()},
+			expect:  "SELECT e.id,e.`Name`,analytics.events.`order` FROM analytics.events e INNER JOIN otherdb.dbo.`User` u ON u.id = e.user_id INNER JOIN `my.schema`.`Order Items` oi ON oi.id = e.item_id",
+		},
+		{
+			name:    "sqlserver",
+			dialect: SQLServer,
+			opts:    []Option{WithQuoting()},
+			expect:  `SELECT e.id,e.[Name],analytics.events.[order] FROM analytics.events e INNER JOIN otherdb.dbo.[User] u ON u.id = e.user_id INNER JOIN [my.schema].[Order Items] oi ON oi.id = e.item_id`,
+		},
+		{
+			name:    "sqlserver_unquoted",
+			dialect: SQLServer,
+			expect:  `SELECT e.id,e.Name,analytics.events.order FROM analytics.events e INNER JOIN otherdb.dbo.User u ON u.id = e.user_id INNER JOIN [my.schema].[Order Items] oi ON oi.id = e.item_id`,
+		},
+		{
+			name:    "strict",
+			dialect: Postgres,
+			opts:    []Option{WithStrictIdents()},
+			expect:  `SELECT e.id,e.Name,analytics.events.order FROM analytics.events e INNER JOIN otherdb.dbo.User u ON u.id = e.user_id INNER JOIN "my.schema"."Order Items" oi ON oi.id = e.item_id`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _, err := stmt.SQL(append(tt.opts, WithDialect(tt.dialect))...)
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+		})
+	}
+}
+
+func TestValidIdent(t *testing.T) {
+	valid := []string{"id", "_id", "user_id2", "users.id", "public.users.id", "CreatedAt"}
+	invalid := []string{"", "1id", "id;", "id --", "users.", ".id", "id name", `"id"`, "id'", "a.b;DROP TABLE users", "a\tb", "naïve"}
+
+	for _, name := range valid {
+		if !ValidIdent(name) {
+			t.Fatalf("expected %q to be valid", name)
+		}
+	}
+
+	for _, name := range invalid {
+		if ValidIdent(name) {
+			t.Fatalf("expected %q to be invalid", name)
+		}
+	}
+}
+
+func TestStrictIdents(t *testing.T) {
+	stmt := Select().Columns("u.id", "u.name AS n", Quote("Order"), "o.*").From("users u").
+		JoinInner("orders o", "o.user_id = u.id").OrderDesc("u.created_at NULLS LAST")
+
+	q, _, err := stmt.SQL(WithStrictIdents())
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	expect := `SELECT u.id,u.name AS n,"Order",o.* FROM users u INNER JOIN orders o ON o.user_id = u.id ORDER BY u.created_at NULLS LAST DESC`
+	if expect != q {
+		t.Fatalf("expected: %s, got: %s", expect, q)
+	}
+
+	for _, column := range []string{"id; DROP TABLE users", "name desc, (SELECT 1)", "COUNT(*)", `"a"b"`, "a b c"} {
+		_, _, err = Select().Columns("id").From("users").OrderAsc(column).SQL(WithStrictIdents())
+		if !errors.Is(err, ErrInvalidIdent) {
+			t.Fatalf("expected ErrInvalidIdent for %q, got: %v", column, err)
+		}
+
+		if _, err = Interpolate(Select().Columns(column).From("users"), WithStrictIdents()); !errors.Is(err, ErrInvalidIdent) {
+			t.Fatalf("expected ErrInvalidIdent for %q, got: %v", column, err)
+		}
+	}
+}
+
+func FuzzValidIdent(f *testing.F) {
+	for _, seed := range []string{"id", "users.id", "id;", "a b", `"id"`, "1a", "a..b", "_", "x\x00"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, name string) {
+		if !ValidIdent(name) {
+			return
+		}
+
+		if strings.ContainsAny(name, " \t\r\n'\"`;-()[]") || name[0] == '.' || name[len(name)-1] == '.' {
+			t.Fatalf("invalid identifier accepted: %q", name)
+		}
+
+		q, _, err := Select().Columns(name).From(name).OrderAsc(name).SQL(WithStrictIdents())
+		if err != nil {
+			t.Fatalf("error building statement with valid identifier %q: %s", name, err)
+		}
+
+		if expect := "SELECT " + name + " FROM " + name + " ORDER BY " + name + " ASC"; expect != q {
+			t.Fatalf("expected: %s, got: %s", expect, q)
+		}
+	})
+}
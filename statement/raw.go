@@ -0,0 +1,103 @@
+package statement
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/brunotm/norm/internal/buffer"
+)
+
+// Raw creates a raw SQL expression that can be used wherever a column, value or condition statement
+// is expected, like Columns, Set, WhereCond and HavingCond, with its arguments bound in place.
+//
+// Placeholders can be either `?`, bound to the arguments in order, or numbered as `$1`, `$2`,
+// bound to the arguments by position, which allows a single argument to be referenced more than once.
+// Placeholders are renumbered for the dialect consistently with the surrounding statement.
+// It is the caller responsibility to match the placeholders with the arguments, though building
+// returns ErrInvalidArgNumber if the number of arguments obviously does not match.
+func Raw(sql string, args ...interface{}) Statement {
+	return &raw{query: sql, args: args}
+}
+
+// SQL creates a statement from a hand written query and its arguments, such as queries loaded from files,
+// so they can be executed and scanned as built statements. Placeholders are handled as in Raw,
+// so the query and arguments are returned as given for queries using `?` with the Default dialect,
+// and placeholders are built for the dialect otherwise.
+func SQL(query string, args ...interface{}) Parameterized {
+	return &raw{query: query, args: args}
+}
+
+// raw represents a raw SQL expression.
+type raw struct {
+	query string
+	args  []interface{}
+}
+
+// Build builds the statement into the given buffer.
+func (s *raw) Build(buf Buffer) (err error) {
+	if !strings.Contains(s.query, "$") || strings.Contains(s.query, "?") {
+		return (&Part{Query: s.query, Values: s.args}).Build(buf)
+	}
+
+	used := make([]bool, len(s.args))
+	query := s.query
+
+	for {
+		idx := strings.IndexByte(query, '$')
+		if idx == -1 {
+			_, _ = buf.WriteString(query)
+			break
+		}
+
+		// only $ followed by digits is a placeholder
+		end := idx + 1
+		for end < len(query) && query[end] >= '0' && query[end] <= '9' {
+			end++
+		}
+
+		if end == idx+1 {
+			_, _ = buf.WriteString(query[:end])
+			query = query[end:]
+			continue
+		}
+
+		n, _ := strconv.Atoi(query[idx+1 : end])
+		if n < 1 || n > len(s.args) {
+			return fmt.Errorf("%w: %s, %#v", ErrInvalidArgNumber, s.query, s.args)
+		}
+
+		_, _ = buf.WriteString(query[:idx])
+		if err = buildValue(buf, s.args[n-1], false); err != nil {
+			return err
+		}
+
+		used[n-1] = true
+		query = query[end:]
+	}
+
+	for x := 0; x < len(used); x++ {
+		if !used[x] {
+			return fmt.Errorf("%w: %s, %#v", ErrInvalidArgNumber, s.query, s.args)
+		}
+	}
+
+	return nil
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *raw) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// SQL builds the statement and returns the resulting parameterized query and arguments.
+func (s *raw) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
@@ -0,0 +1,161 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRaw(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		args    []interface{}
+		wantErr bool
+	}{
+		{
+			name:    "column",
+			dialect: Postgres,
+			stmt: Select().Columns("id", Raw("row_number() OVER (PARTITION BY dept ORDER BY salary DESC) AS rank")).
+				From("employees").Where("tenant_id = ?", 42),
+			expect: `SELECT id,row_number() OVER (PARTITION BY dept ORDER BY salary DESC) AS rank FROM employees WHERE tenant_id = $1`,
+			args:   []interface{}{42},
+		},
+		{
+			name:    "set_numbered",
+			dialect: Postgres,
+			stmt: Update().Table("users").Set("active", true).
+				Set("data", Raw("jsonb_set(data, $1, $2)", "{name}", `"john"`)).Where("id = ?", 1),
+			expect: `UPDATE users SET active = $1, data = (jsonb_set(data, $2, $3)) WHERE id = $4`,
+			args:   []interface{}{true, "{name}", `"john"`, 1},
+		},
+		{
+			name:    "cond_reused_arg",
+			dialect: SQLServer,
+			stmt: Select().Columns("id").From("events").Where("tenant_id = ?", 42).
+				WhereCond(Raw("(starts_at <= $1 AND ends_at > $1)", 100)),
+			expect: `SELECT id FROM events WHERE tenant_id = @p1 AND (starts_at <= @p2 AND ends_at > @p3)`,
+			args:   []interface{}{42, 100, 100},
+		},
+		{
+			name:    "cond_question_marks",
+			dialect: Oracle,
+			stmt:    Select().Columns("id").From("events").WhereCond(Raw("json_value(data, '$.kind') = ?", "click")),
+			expect:  `SELECT id FROM events WHERE json_value(data, '$.kind') = :1`,
+			args:    []interface{}{"click"},
+		},
+		{
+			name:    "missing_arg",
+			dialect: Postgres,
+			stmt:    Select().Columns("id").From("events").WhereCond(Raw("a = $1 AND b = $2", 1)),
+			wantErr: true,
+		},
+		{
+			name:    "unused_arg",
+			dialect: Postgres,
+			stmt:    Select().Columns("id").From("events").WhereCond(Raw("a = $1", 1, 2)),
+			wantErr: true,
+		},
+		{
+			name:    "question_mark_count",
+			dialect: Postgres,
+			stmt:    Select().Columns("id").From("events").WhereCond(Raw("a = ? AND b = ?", 1)),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidArgNumber) {
+					t.Fatalf("expected ErrInvalidArgNumber, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
+
+func TestSQL(t *testing.T) {
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		args    []interface{}
+		wantErr bool
+	}{
+		{
+			name:    "default",
+			dialect: Default,
+			stmt:    SQL("SELECT id, name FROM users WHERE role = ? AND active = ?", "admin", true),
+			expect:  `SELECT id, name FROM users WHERE role = ? AND active = ?`,
+			args:    []interface{}{"admin", true},
+		},
+		{
+			name:    "postgres",
+			dialect: Postgres,
+			stmt:    SQL("SELECT id, name FROM users WHERE role = ? AND active = ?", "admin", true),
+			expect:  `SELECT id, name FROM users WHERE role = $1 AND active = $2`,
+			args:    []interface{}{"admin", true},
+		},
+		{
+			name:    "postgres_numbered",
+			dialect: Postgres,
+			stmt:    SQL("SELECT id FROM events WHERE starts_at <= $1 AND ends_at > $1", 100),
+			expect:  `SELECT id FROM events WHERE starts_at <= $1 AND ends_at > $2`,
+			args:    []interface{}{100, 100},
+		},
+		{
+			name:    "no_args",
+			dialect: MySQL,
+			stmt:    SQL("SELECT count(*) FROM users"),
+			expect:  `SELECT count(*) FROM users`,
+		},
+		{
+			name:    "arg_count",
+			dialect: Postgres,
+			stmt:    SQL("SELECT id FROM users WHERE role = ?"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidArgNumber) {
+					t.Fatalf("expected ErrInvalidArgNumber, got: %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
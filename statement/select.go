@@ -19,6 +19,8 @@ var (
 	RightOuterJoin Join = "RIGHT OUTER JOIN"
 	// FullOuterJoin type
 	FullOuterJoin Join = "FULL OUTER JOIN"
+	// CrossJoin type
+	CrossJoin Join = "CROSS JOIN"
 )
 
 // SelectStatement statement.
@@ -27,19 +29,26 @@ type SelectStatement struct {
 	offsetCount    int64
 	order          string
 	isDistinct     bool
-	isForUpdate    bool
-	isSkipLocked   bool
+	lock           string
+	lockWait       string
+	lockOf         []string
 	tableStatement bool
-	with           Statement
+	tableAlias     string
+	with           *with
 	union          Statement
 	table          Statement
 	columns        []interface{}
 	groupBy        []string
+	distinctOn     []string
 	orderBy        []string
+	orderExprs     []Statement
 	comment        []Statement
 	join           []Statement
 	where          []Statement
 	having         []Statement
+	keyset         *keyset
+	managed        *Timestamps
+	indexHints     []indexHint
 }
 
 // Select creates a new `SELECT` statement.
@@ -50,16 +59,15 @@ func Select() *SelectStatement {
 // Comment adds a SQL comment to the generated query.
 // Each call to comment creates a new `-- <comment>` line.
 func (s *SelectStatement) Comment(c string, values ...interface{}) *SelectStatement {
-	buf := buffer.New()
-	defer buf.Release()
-
-	_, _ = buf.WriteString("-- ")
-	_, _ = buf.WriteString(c)
+	s.comment = append(s.comment, buildComment(c, values...))
+	return s
+}
 
-	p := &Part{}
-	p.Query = buf.String()
-	p.Values = values
-	s.comment = append(s.comment, p)
+// Tag adds a `/*key='value'*/` comment with the given tags in the sqlcommenter format to the generated query,
+// like `/*action='list',controller='orders'*/`, to correlate queries in the database logs with the code that issued them.
+// As comments, tags are built before the statement in their own line.
+func (s *SelectStatement) Tag(tags map[string]string) *SelectStatement {
+	s.comment = append(s.comment, buildTag(tags))
 	return s
 }
 
@@ -76,9 +84,13 @@ func (s *SelectStatement) Column(q string, values ...interface{}) *SelectStateme
 	return s
 }
 
-// From sets the table name or *Select statement for the `FROM` clause.
+// From sets the table name, *Select statement or Values list for the `FROM` clause.
 func (s *SelectStatement) From(table interface{}) *SelectStatement {
+	s.tableAlias = ""
+	s.tableStatement = false
 	switch table := table.(type) {
+	case *ValuesStatement:
+		s.table = table
 	case Statement:
 		s.tableStatement = true
 		s.table = table
@@ -89,22 +101,23 @@ func (s *SelectStatement) From(table interface{}) *SelectStatement {
 	return s
 }
 
-// Join adds a `JOIN ...` clause.
-func (s *SelectStatement) Join(join Join, table, cond string, values ...interface{}) *SelectStatement {
-	buf := buffer.New()
-	defer buf.Release()
-
-	_, _ = buf.WriteString(string(join))
-	_, _ = buf.WriteString(" ")
-	_, _ = buf.WriteString(table)
-	_, _ = buf.WriteString(" ON ")
-	_, _ = buf.WriteString(cond)
+// FromAs sets a derived table for the `FROM` clause, as `FROM (stmt) AS alias`,
+// or `FROM (stmt) alias` on the Oracle dialect.
+func (s *SelectStatement) FromAs(table Statement, alias string) *SelectStatement {
+	s.From(table)
+	s.tableAlias = alias
+	return s
+}
 
-	p := &Part{}
-	p.Values = values
-	p.Query = buf.String()
+// Join adds a `JOIN table ON cond` clause, the table can be aliased as `table alias`.
+// If cond is empty the `ON` clause is omitted, as for a CrossJoin.
+func (s *SelectStatement) Join(join Join, table, cond string, values ...interface{}) *SelectStatement {
+	c := &joinClause{join: join, table: table}
+	if cond != "" {
+		c.on = &Part{Query: cond, Values: values}
+	}
 
-	s.join = append(s.join, p)
+	s.join = append(s.join, c)
 	return s
 }
 
@@ -128,6 +141,29 @@ func (s *SelectStatement) JoinFull(table, cond string, values ...interface{}) *S
 	return s.Join(FullOuterJoin, table, cond, values...)
 }
 
+// JoinCross adds a `CROSS JOIN` clause.
+func (s *SelectStatement) JoinCross(table string) *SelectStatement {
+	return s.Join(CrossJoin, table, "")
+}
+
+// JoinValues adds a `JOIN (VALUES ...) AS alias(columns) ON cond` clause with the given values list.
+// If cond is empty the `ON` clause is omitted, as for a CrossJoin.
+func (s *SelectStatement) JoinValues(join Join, values *ValuesStatement, cond string, args ...interface{}) *SelectStatement {
+	c := &joinClause{join: join, values: values}
+	if cond != "" {
+		c.on = &Part{Query: cond, Values: args}
+	}
+
+	s.join = append(s.join, c)
+	return s
+}
+
+// JoinUsing adds a `JOIN table USING (columns)` clause.
+func (s *SelectStatement) JoinUsing(join Join, table string, columns ...string) *SelectStatement {
+	s.join = append(s.join, &joinClause{join: join, table: table, using: append([]string{}, columns...)})
+	return s
+}
+
 // Where adds a `WHERE` clause, multiple calls to Where are `ANDed` together.
 func (s *SelectStatement) Where(q string, values ...interface{}) *SelectStatement {
 	s.where = append(s.where, &Part{Query: q, Values: values})
@@ -140,12 +176,40 @@ func (s *SelectStatement) Having(q string, values ...interface{}) *SelectStateme
 	return s
 }
 
+// HavingCond adds a `HAVING cond` clause for conditions built with Cond, In, Between, Like, IsNull,
+// IsNotNull, And and Or, multiple calls to Having and HavingCond are `ANDed` together.
+func (s *SelectStatement) HavingCond(cond Statement) *SelectStatement {
+	s.having = append(s.having, cond)
+	return s
+}
+
 // WhereIn adds a `WHERE IN (values)` clause, multiple calls to WhereIn are `ANDed` together.
 func (s *SelectStatement) WhereIn(column string, values ...interface{}) *SelectStatement {
 	s.where = append(s.where, buildWhereIn(column, values...))
 	return s
 }
 
+// WhereCond adds a `WHERE cond` clause for conditions built with Cond, In, Between, Like, IsNull,
+// IsNotNull, And and Or, multiple calls to WhereCond are `ANDed` together.
+func (s *SelectStatement) WhereCond(cond Statement) *SelectStatement {
+	s.where = append(s.where, cond)
+	return s
+}
+
+// WhereKey adds a `WHERE (column = value AND ...)` clause matching the columns of a composite key
+// to the given values as with Key, multiple calls to WhereKey are `ANDed` together.
+func (s *SelectStatement) WhereKey(key map[string]interface{}) *SelectStatement {
+	s.where = append(s.where, Key(key))
+	return s
+}
+
+// WhereKeyStruct adds a `WHERE (column = value AND ...)` clause matching the fields of the given struct
+// tagged as key columns with `db:"column,key"` as with KeyStruct, multiple calls to WhereKeyStruct are `ANDed` together.
+func (s *SelectStatement) WhereKeyStruct(structValue interface{}) *SelectStatement {
+	s.where = append(s.where, KeyStruct(structValue))
+	return s
+}
+
 // GroupBy adds a `GROUP BY columns` clause.
 func (s *SelectStatement) GroupBy(columns ...string) *SelectStatement {
 	s.groupBy = append(s.groupBy, columns...)
@@ -166,7 +230,16 @@ func (s *SelectStatement) OrderDesc(columns ...string) *SelectStatement {
 	return s
 }
 
-// Limit adds a `LIMIT n` clause.
+// OrderExpr appends an expression with bound arguments, like a Case, to the `ORDER BY` columns.
+// Expressions are ordered in the order set with OrderAsc or OrderDesc, or ascending if none is set.
+func (s *SelectStatement) OrderExpr(expr Statement) *SelectStatement {
+	s.orderExprs = append(s.orderExprs, expr)
+	return s
+}
+
+// Limit adds a `LIMIT n` clause, built as `OFFSET m ROWS FETCH NEXT n ROWS ONLY` on the Oracle
+// and SQLServer dialects. On SQLServer unordered statements without an offset are built with `SELECT TOP n`,
+// and unordered statements with an offset fail with ErrMissingOrder.
 func (s *SelectStatement) Limit(n int64) *SelectStatement {
 	s.limitCount = n
 	return s
@@ -178,33 +251,81 @@ func (s *SelectStatement) Offset(n int64) *SelectStatement {
 	return s
 }
 
+// KeysetAfter adds a keyset pagination condition for the rows after the given values for columns,
+// as `WHERE (columns) > (values)`, or `<` if the statement is ordered with OrderDesc.
+// If no order is set the statement is ordered by the keyset columns ascending.
+// The keyset columns must be unique, usually ending with the primary key, and match the ORDER BY columns,
+// which are each ordered in the direction of the comparison, as `ORDER BY created_at DESC,id DESC`.
+func (s *SelectStatement) KeysetAfter(columns []string, values []interface{}) *SelectStatement {
+	s.keyset = &keyset{columns: columns, values: values}
+	return s
+}
+
+// Timestamps adds a `DeletedAt IS NULL` condition for the DeletedAt column from the given Timestamps,
+// excluding soft deleted rows from the `FROM` table. The column is qualified with the table alias if any.
+func (s *SelectStatement) Timestamps(ts Timestamps) *SelectStatement {
+	s.managed = &ts
+	return s
+}
+
 // Distinct adds a `DISTINCT` clause.
 func (s *SelectStatement) Distinct() *SelectStatement {
+	s.distinctOn = nil
 	s.isDistinct = true
 	return s
 }
 
-// ForUpdate a `FOR UPDATE` clause.
+// DistinctOn adds a Postgres `DISTINCT ON (columns)` clause, keeping only the first row of each
+// set of rows with the same values for the columns. The first row of each set is only defined by
+// the `ORDER BY`, which must start with the distinct columns in the same order, otherwise building
+// the statement returns ErrDistinctOrder. It is only supported on the Default and Postgres dialects.
+func (s *SelectStatement) DistinctOn(columns ...string) *SelectStatement {
+	s.isDistinct = false
+	s.distinctOn = columns
+	return s
+}
+
+// ForUpdate adds a `FOR UPDATE` locking clause.
 func (s *SelectStatement) ForUpdate() *SelectStatement {
-	s.isForUpdate = true
+	s.lock = "FOR UPDATE"
 	return s
 }
 
-// SkipLocked adds a `SKIP LOCKED` clause.
+// ForShare adds a `FOR SHARE` locking clause.
+func (s *SelectStatement) ForShare() *SelectStatement {
+	s.lock = "FOR SHARE"
+	return s
+}
+
+// Of restricts the locking clause to the given tables, as `FOR UPDATE OF tables`.
+func (s *SelectStatement) Of(tables ...string) *SelectStatement {
+	s.lockOf = tables
+	return s
+}
+
+// NoWait adds a `NOWAIT` modifier to the locking clause, which defaults to `FOR UPDATE`.
+func (s *SelectStatement) NoWait() *SelectStatement {
+	s.lockWait = "NOWAIT"
+	return s
+}
+
+// SkipLocked adds a `SKIP LOCKED` modifier to the locking clause, which defaults to `FOR UPDATE`.
 func (s *SelectStatement) SkipLocked() *SelectStatement {
-	s.isSkipLocked = true
+	s.lockWait = "SKIP LOCKED"
 	return s
 }
 
-// With adds a `WITH alias AS (stmt)`
+// With adds a `WITH alias AS (stmt)`, multiple calls add multiple common table expressions.
 func (s *SelectStatement) With(alias string, stmt Statement) *SelectStatement {
-	s.with = &with{recursive: false, alias: alias, stmt: stmt}
+	s.with = s.with.add(alias, false, stmt)
 	return s
 }
 
-// WithRecursive adds a `WITH RECURSIVE alias AS (stmt)`
-func (s *SelectStatement) WithRecursive(alias string, stmt Statement) *SelectStatement {
-	s.with = &with{recursive: true, alias: alias, stmt: stmt}
+// WithRecursive adds a `WITH RECURSIVE alias AS (stmt)`, where stmt is the anchor statement
+// and the optional recursive statements are combined with it as `stmt UNION ALL recursive`.
+// Multiple calls to With and WithRecursive add multiple common table expressions.
+func (s *SelectStatement) WithRecursive(alias string, stmt Statement, recursive ...Statement) *SelectStatement {
+	s.with = s.with.add(alias, true, append([]Statement{stmt}, recursive...)...)
 	return s
 }
 
@@ -220,8 +341,66 @@ func (s *SelectStatement) UnionAll(stmt Statement) *SelectStatement {
 	return s
 }
 
+// Clone returns a copy of the statement that can be modified without affecting the original,
+// allowing variants to be derived from a shared base statement.
+// Nested statements, like subqueries and unions, are shared by both statements.
+func (s *SelectStatement) Clone() *SelectStatement {
+	c := *s
+	c.with = s.with.clone()
+	c.columns = append(s.columns[:0:0], s.columns...)
+	c.groupBy = append(s.groupBy[:0:0], s.groupBy...)
+	c.distinctOn = append(s.distinctOn[:0:0], s.distinctOn...)
+	c.orderBy = append(s.orderBy[:0:0], s.orderBy...)
+	c.orderExprs = append(s.orderExprs[:0:0], s.orderExprs...)
+	c.lockOf = append(s.lockOf[:0:0], s.lockOf...)
+	c.comment = append(s.comment[:0:0], s.comment...)
+	c.join = append(s.join[:0:0], s.join...)
+	c.where = append(s.where[:0:0], s.where...)
+	c.having = append(s.having[:0:0], s.having...)
+	c.indexHints = append(s.indexHints[:0:0], s.indexHints...)
+	return &c
+}
+
+// Validate checks that the statement is fully specified, returning ErrIncomplete if it has no columns
+// or index hints for tables not in the statement, and ErrDistinctOrder if the `ORDER BY` does not start
+// with the `DISTINCT ON` columns.
+func (s *SelectStatement) Validate() (err error) {
+	if len(s.columns) == 0 {
+		return incomplete("SELECT", "columns")
+	}
+
+	if err = s.validHints(); err != nil {
+		return err
+	}
+
+	orderBy := s.orderBy
+	if len(orderBy) == 0 && s.keyset != nil {
+		orderBy = s.keyset.columns
+	}
+
+	if len(s.distinctOn) == 0 || (len(orderBy) == 0 && len(s.orderExprs) == 0) {
+		return nil
+	}
+
+	if len(orderBy) < len(s.distinctOn) {
+		return fmt.Errorf("%w: %v", ErrDistinctOrder, s.distinctOn)
+	}
+
+	for x := 0; x < len(s.distinctOn); x++ {
+		if f := strings.Fields(orderBy[x]); len(f) == 0 || f[0] != s.distinctOn[x] {
+			return fmt.Errorf("%w: %v", ErrDistinctOrder, s.distinctOn)
+		}
+	}
+
+	return nil
+}
+
 // Build builds the statement into the given buffer.
 func (s *SelectStatement) Build(buf Buffer) (err error) {
+	if err = s.Validate(); err != nil {
+		return err
+	}
+
 	for x := 0; x < len(s.comment); x++ {
 		if err = s.comment[x].Build(buf); err != nil {
 			return err
@@ -237,11 +416,31 @@ func (s *SelectStatement) Build(buf Buffer) (err error) {
 	}
 
 	_, _ = buf.WriteString("SELECT ")
+	s.buildOptimizerHints(buf)
 
 	if s.isDistinct {
 		_, _ = buf.WriteString("DISTINCT ")
 	}
 
+	// SQLServer requires ordering for OFFSET and FETCH, limit unordered statements with TOP
+	ordered := len(s.orderBy) > 0 || len(s.orderExprs) > 0 || s.keyset != nil
+	top := s.limitCount > 0 && s.offsetCount == 0 && !ordered && dialectOf(buf) == SQLServer
+	if top {
+		_, _ = buf.WriteString(fmt.Sprintf("TOP %d ", s.limitCount))
+	}
+
+	if len(s.distinctOn) > 0 {
+		switch d := dialectOf(buf); d {
+		case Default, Postgres:
+		default:
+			return unsupported("DISTINCT ON", d)
+		}
+
+		_, _ = buf.WriteString("DISTINCT ON (")
+		writeIdents(buf, s.distinctOn)
+		_, _ = buf.WriteString(") ")
+	}
+
 	for x := 0; x < len(s.columns); x++ {
 		if x > 0 {
 			_, _ = buf.WriteString(`,`)
@@ -254,7 +453,7 @@ func (s *SelectStatement) Build(buf Buffer) (err error) {
 			}
 
 		case string:
-			_, _ = buf.WriteString(c)
+			writeIdent(buf, c)
 		}
 	}
 
@@ -265,8 +464,21 @@ func (s *SelectStatement) Build(buf Buffer) (err error) {
 			_, _ = buf.WriteString(`( `)
 			err = s.table.Build(buf)
 			_, _ = buf.WriteString(` )`)
+
+			if s.tableAlias != "" {
+				if dialectOf(buf) != Oracle {
+					_, _ = buf.WriteString(" AS")
+				}
+				_, _ = buf.WriteString(" ")
+				writeIdent(buf, s.tableAlias)
+			}
 		case false:
-			err = s.table.Build(buf)
+			if p, ok := s.table.(*Part); ok && len(p.Values) == 0 && !strings.Contains(p.Query, "?") {
+				writeIdent(buf, p.Query)
+				err = buildTableHints(buf, s.hintsFor(p.Query))
+			} else {
+				err = s.table.Build(buf)
+			}
 		}
 
 		if err != nil {
@@ -276,19 +488,49 @@ func (s *SelectStatement) Build(buf Buffer) (err error) {
 
 	for x := 0; x < len(s.join); x++ {
 		_, _ = buf.WriteString(" ")
-		err = s.join[x].Build(buf)
+		if j, ok := s.join[x].(*joinClause); ok && len(s.indexHints) > 0 {
+			c := *j
+			c.hints = s.hintsFor(j.table)
+			err = c.Build(buf)
+		} else {
+			err = s.join[x].Build(buf)
+		}
 		if err != nil {
 			return err
 		}
 	}
 
-	if err = buildWhere(buf, s.where); err != nil {
+	where, orderBy, order := s.where, s.orderBy, s.order
+	if t, ok := s.table.(*Part); ok && s.managed != nil && !s.tableStatement {
+		if c := s.managed.column(t.Query, s.managed.DeletedAt); c != "" {
+			if f := strings.Fields(t.Query); len(f) > 1 {
+				c = tableAlias(t.Query) + "." + c
+			}
+			where = append(where[:len(where):len(where)], IsNull(c))
+		}
+	}
+
+	if s.keyset != nil {
+		if len(orderBy) == 0 {
+			orderBy, order = s.keyset.columns, "ASC"
+		}
+
+		k := *s.keyset
+		k.desc = order == "DESC"
+		where = append(where[:len(where):len(where)], &k)
+	}
+
+	if err = buildWhere(buf, where); err != nil {
 		return err
 	}
 
 	if len(s.groupBy) > 0 {
+		if err = s.validGroupBy(dialectOf(buf)); err != nil {
+			return err
+		}
+
 		_, _ = buf.WriteString(" GROUP BY ")
-		_, _ = buf.WriteString(strings.Join(s.groupBy, ","))
+		writeIdents(buf, s.groupBy)
 	}
 
 	for x := 0; x < len(s.having); x++ {
@@ -304,23 +546,49 @@ func (s *SelectStatement) Build(buf Buffer) (err error) {
 
 	}
 
-	if len(s.orderBy) > 0 {
+	if len(orderBy) > 0 || len(s.orderExprs) > 0 {
+		if order == "" {
+			order = "ASC"
+		}
+
 		_, _ = buf.WriteString(" ORDER BY ")
-		_, _ = buf.WriteString(strings.Join(s.orderBy, `,`))
-		_, _ = buf.WriteString(" ")
-		_, _ = buf.WriteString(s.order)
-	}
+		if s.keyset == nil {
+			writeIdents(buf, orderBy)
+		} else {
+			// every keyset column is ordered in the direction of the row value comparison
+			for x := 0; x < len(orderBy); x++ {
+				if x > 0 {
+					_, _ = buf.WriteString(",")
+				}
+				writeIdent(buf, orderBy[x])
+				_, _ = buf.WriteString(" ")
+				_, _ = buf.WriteString(order)
+			}
+		}
+
+		for x := 0; x < len(s.orderExprs); x++ {
+			if x > 0 || len(orderBy) > 0 {
+				_, _ = buf.WriteString(",")
+			}
+			if err = s.orderExprs[x].Build(buf); err != nil {
+				return err
+			}
+		}
 
-	if s.limitCount > 0 {
-		_, _ = buf.WriteString(fmt.Sprintf(" LIMIT %d OFFSET %d", s.limitCount, s.offsetCount))
+		if s.keyset == nil || len(s.orderExprs) > 0 {
+			_, _ = buf.WriteString(" ")
+			_, _ = buf.WriteString(order)
+		}
 	}
 
-	if s.isForUpdate {
-		_, _ = buf.WriteString(" FOR UPDATE")
+	if !top {
+		if err = buildLimit(buf, s.limitCount, s.offsetCount, ordered); err != nil {
+			return err
+		}
 	}
 
-	if s.isSkipLocked {
-		_, _ = buf.WriteString(" SKIP LOCKED")
+	if err = s.buildLock(buf); err != nil {
+		return err
 	}
 
 	if s.union != nil {
@@ -333,6 +601,65 @@ func (s *SelectStatement) Build(buf Buffer) (err error) {
 	return nil
 }
 
+// validGroupBy returns ErrUnsupported if the `GROUP BY` columns reference column aliases
+// on dialects that evaluate it before the select list, as Oracle and SQLServer.
+func (s *SelectStatement) validGroupBy(d Dialect) (err error) {
+	if d != Oracle && d != SQLServer {
+		return nil
+	}
+
+	for _, c := range s.columns {
+		a, ok := c.(*aliasExpr)
+		if !ok {
+			continue
+		}
+
+		for _, g := range s.groupBy {
+			if g == a.alias {
+				return unsupported("GROUP BY column alias "+a.alias, d)
+			}
+		}
+	}
+
+	return nil
+}
+
+// buildLock builds the locking clause for the buffer dialect.
+func (s *SelectStatement) buildLock(buf Buffer) (err error) {
+	if s.lock == "" && s.lockWait == "" && len(s.lockOf) == 0 {
+		return nil
+	}
+
+	lock := s.lock
+	if lock == "" {
+		lock = "FOR UPDATE"
+	}
+
+	switch d := dialectOf(buf); d {
+	case SQLite, SQLServer:
+		return unsupported(lock, d)
+	case Oracle:
+		if lock == "FOR SHARE" {
+			return unsupported(lock, d)
+		}
+	}
+
+	_, _ = buf.WriteString(" ")
+	_, _ = buf.WriteString(lock)
+
+	if len(s.lockOf) > 0 {
+		_, _ = buf.WriteString(" OF ")
+		writeIdents(buf, s.lockOf)
+	}
+
+	if s.lockWait != "" {
+		_, _ = buf.WriteString(" ")
+		_, _ = buf.WriteString(s.lockWait)
+	}
+
+	return nil
+}
+
 // String builds the statement and returns the resulting query string.
 func (s *SelectStatement) String() (q string, err error) {
 	buf := buffer.New()
@@ -344,3 +671,8 @@ func (s *SelectStatement) String() (q string, err error) {
 
 	return buf.String(), nil
 }
+
+// SQL builds the statement and returns the resulting parameterized query and arguments.
+func (s *SelectStatement) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
@@ -1,6 +1,9 @@
 package statement
 
-import "testing"
+import (
+	"errors"
+	"testing"
+)
 
 var (
 	selectCases = []struct {
@@ -81,6 +84,58 @@ WITH select_offices AS (SELECT country,city,address,postal_code FROM offices WHE
 				GroupBy("id", "name"),
 			wantErr: false,
 		},
+		{
+			name:   "with_multiple",
+			expect: `WITH active_users AS (SELECT id,office_id FROM users WHERE active = true), uk_offices AS (SELECT id FROM offices WHERE country = 'uk') SELECT u.id FROM active_users u INNER JOIN uk_offices o ON o.id = u.office_id`,
+			stmt: Select().
+				With("active_users", Select().Columns("id", "office_id").From("users").Where("active = ?", true)).
+				With("uk_offices", Select().Columns("id").From("offices").Where("country = ?", "uk")).
+				Columns("u.id").From("active_users u").JoinInner("uk_offices o", "o.id = u.office_id"),
+			wantErr: false,
+		},
+		{
+			name:   "with_recursive_hierarchy",
+			expect: `WITH RECURSIVE subordinates AS (SELECT id,manager_id,name FROM employees WHERE id = 1 UNION ALL SELECT e.id,e.manager_id,e.name FROM employees e INNER JOIN subordinates s ON s.id = e.manager_id) SELECT id,name FROM subordinates`,
+			stmt: Select().WithRecursive("subordinates",
+				Select().Columns("id", "manager_id", "name").From("employees").Where("id = ?", 1),
+				Select().Columns("e.id", "e.manager_id", "e.name").From("employees e").JoinInner("subordinates s", "s.id = e.manager_id"),
+			).Columns("id", "name").From("subordinates"),
+			wantErr: false,
+		},
+		{
+			name:   "for_update_skip_locked",
+			expect: `SELECT id,payload FROM jobs WHERE status = 'pending' ORDER BY id ASC LIMIT 1 OFFSET 0 FOR UPDATE SKIP LOCKED`,
+			stmt: Select().Columns("id", "payload").From("jobs").Where("status = ?", "pending").
+				OrderAsc("id").Limit(1).ForUpdate().SkipLocked(),
+			wantErr: false,
+		},
+		{
+			name:   "for_share_of_nowait",
+			expect: `SELECT j.id,q.name FROM jobs j INNER JOIN queues q ON q.id = j.queue_id FOR SHARE OF j NOWAIT`,
+			stmt: Select().Columns("j.id", "q.name").From("jobs j").JoinInner("queues q", "q.id = j.queue_id").
+				ForShare().Of("j").NoWait(),
+			wantErr: false,
+		},
+		{
+			name:    "distinct",
+			expect:  `SELECT DISTINCT country,city FROM users`,
+			stmt:    Select().Distinct().Columns("country", "city").From("users"),
+			wantErr: false,
+		},
+		{
+			name:   "distinct_on",
+			expect: `SELECT DISTINCT ON (customer_id) customer_id,id,total FROM orders ORDER BY customer_id,created DESC`,
+			stmt: Select().DistinctOn("customer_id").Columns("customer_id", "id", "total").From("orders").
+				OrderDesc("customer_id", "created"),
+			wantErr: false,
+		},
+		{
+			name:   "distinct_on_order",
+			expect: ``,
+			stmt: Select().DistinctOn("customer_id").Columns("customer_id", "id", "total").From("orders").
+				OrderDesc("created", "customer_id"),
+			wantErr: true,
+		},
 	}
 )
 
@@ -98,3 +153,24 @@ func TestSelect(t *testing.T) {
 		})
 	}
 }
+
+func TestSelectDistinctOn(t *testing.T) {
+	stmt := Select().DistinctOn("customer_id").Columns("customer_id", "id").From("orders").OrderAsc("customer_id")
+
+	q, _, err := stmt.SQL(WithDialect(Postgres))
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	if expect := `SELECT DISTINCT ON (customer_id) customer_id,id FROM orders ORDER BY customer_id ASC`; expect != q {
+		t.Fatalf("expected: %s, got: %s", expect, q)
+	}
+
+	if _, _, err = stmt.SQL(WithDialect(MySQL)); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got: %v", err)
+	}
+
+	if _, err = stmt.Clone().OrderAsc("id").String(); !errors.Is(err, ErrDistinctOrder) {
+		t.Fatalf("expected ErrDistinctOrder, got: %v", err)
+	}
+}
@@ -2,7 +2,10 @@ package statement
 
 import (
 	"fmt"
+	"net/url"
 	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/brunotm/norm/internal/buffer"
 	"github.com/brunotm/norm/internal/scan"
@@ -14,8 +17,46 @@ var (
 
 	// ErrInvalidArgNumber will be returned when there is a mismatch between placeholders and values for interpolation.
 	ErrInvalidArgNumber = fmt.Errorf("statement: invalid number of arguments")
+
+	// ErrColumnCount will be returned when the statements combined in a CompoundStatement
+	// project a different number of columns.
+	ErrColumnCount = fmt.Errorf("statement: mismatched number of columns in compound statement")
+
+	// ErrIncomplete will be returned when a statement is missing a required clause,
+	// like the columns of a select or the assignments of an update.
+	ErrIncomplete = fmt.Errorf("statement: incomplete statement")
+
+	// ErrMissingWhere will be returned when building an update or delete statement without
+	// a `WHERE` clause with the WithRequireWhere option.
+	ErrMissingWhere = fmt.Errorf("statement: missing where clause")
+
+	// ErrDistinctOrder will be returned when the `ORDER BY` of a select statement with a
+	// `DISTINCT ON` clause does not start with the distinct columns.
+	ErrDistinctOrder = fmt.Errorf("statement: DISTINCT ON columns must lead the ORDER BY")
+
+	// ErrInvalidIdent will be returned when building a statement with an invalid table or column
+	// identifier with the WithStrictIdents option.
+	ErrInvalidIdent = fmt.Errorf("statement: invalid identifier")
+
+	// ErrMissingOrder is returned when building a statement with an offset and no `ORDER BY` clause
+	// for the SQLServer dialect, which requires ordering for `OFFSET n ROWS FETCH NEXT m ROWS ONLY`.
+	ErrMissingOrder = fmt.Errorf("statement: OFFSET requires an ORDER BY clause")
 )
 
+// incomplete returns an ErrIncomplete error for the given statement and missing clause.
+func incomplete(stmt, clause string) error {
+	return fmt.Errorf("%w: %s without %s", ErrIncomplete, stmt, clause)
+}
+
+// checkWhere returns an ErrMissingWhere error for the given statement if the buffer was
+// created with the WithRequireWhere option and there are no where conditions.
+func checkWhere(buf Buffer, stmt string, where []Statement) error {
+	if p, ok := buf.(*params); ok && p.requireWhere && len(where) == 0 {
+		return fmt.Errorf("%w: %s", ErrMissingWhere, stmt)
+	}
+	return nil
+}
+
 // Buffer represents the write buffer for building statements.
 // Fits nicely with a strings.Builder or a bytes.Buffer.
 type Buffer interface {
@@ -68,6 +109,7 @@ func InterfaceSlice(slice interface{}) []interface{} {
 }
 
 // buildWhereIn builds a `WHERE IN (values)` clause.
+// An empty list of values is built as a constant false `1=0` condition.
 func buildWhereIn(column string, values ...interface{}) (p *Part) {
 	buf := buffer.New()
 	defer buf.Release()
@@ -78,8 +120,22 @@ func buildWhereIn(column string, values ...interface{}) (p *Part) {
 		values = InterfaceSlice(values[0])
 	}
 
-	_, _ = buf.WriteString(column)
-	_, _ = buf.WriteString(" IN (")
+	if len(values) == 0 {
+		p.Query = "1=0"
+		return p
+	}
+
+	// subqueries are built within the IN parentheses
+	if len(values) == 1 {
+		if _, ok := values[0].(Statement); ok {
+			p.Query = "? IN ?"
+			p.Values = []interface{}{columnIdent(column), values[0]}
+			return p
+		}
+	}
+
+	_, _ = buf.WriteString("? IN (")
+	p.Values = append(p.Values, columnIdent(column))
 	for x := 0; x < len(values); x++ {
 		if x > 0 {
 			_, _ = buf.WriteString(",")
@@ -92,34 +148,315 @@ func buildWhereIn(column string, values ...interface{}) (p *Part) {
 	return p
 }
 
-// with represents a `WITH` clause.
+// buildLimit builds the limit and offset clause for the buffer dialect, as `LIMIT n OFFSET m`,
+// or `OFFSET m ROWS FETCH NEXT n ROWS ONLY` on the Oracle and SQLServer dialects.
+// SQLServer requires the statement to be ordered, so unordered statements must use `TOP n` instead.
+func buildLimit(buf Buffer, limit, offset int64, ordered bool) (err error) {
+	if limit <= 0 {
+		return nil
+	}
+
+	switch d := dialectOf(buf); d {
+	case SQLServer, Oracle:
+		if d == SQLServer && !ordered {
+			return fmt.Errorf("%w: LIMIT %d OFFSET %d, dialect: %s", ErrMissingOrder, limit, offset, d)
+		}
+		_, _ = buf.WriteString(fmt.Sprintf(" OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", offset, limit))
+	default:
+		_, _ = buf.WriteString(fmt.Sprintf(" LIMIT %d OFFSET %d", limit, offset))
+	}
+
+	return nil
+}
+
+// joinClause is a `JOIN table ON cond`, `JOIN table USING (columns)` or `JOIN LATERAL (stmt) alias` clause of a select statement.
+type joinClause struct {
+	join    Join
+	table   string
+	values  *ValuesStatement
+	lateral Statement
+	hints   []indexHint
+	on      *Part
+	using   []string
+}
+
+// Build builds the clause into the given buffer.
+func (j *joinClause) Build(buf Buffer) (err error) {
+	if j.lateral != nil {
+		return j.buildLateral(buf)
+	}
+
+	_, _ = buf.WriteString(string(j.join))
+	_, _ = buf.WriteString(" ")
+
+	if j.values != nil {
+		if err = j.values.Build(buf); err != nil {
+			return err
+		}
+	} else {
+		writeIdent(buf, j.table)
+		if err = buildTableHints(buf, j.hints); err != nil {
+			return err
+		}
+	}
+
+	if j.using != nil {
+		_, _ = buf.WriteString(" USING (")
+		writeIdents(buf, j.using)
+		_, _ = buf.WriteString(")")
+		return nil
+	}
+
+	if j.on != nil {
+		_, _ = buf.WriteString(" ON ")
+		return j.on.Build(buf)
+	}
+
+	return nil
+}
+
+// String builds the clause and returns the resulting query string.
+func (j *joinClause) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = j.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// joinTable represents a table joined to the target table of an update or delete statement.
+type joinTable struct {
+	table string
+	on    Statement
+}
+
+// buildJoinTables builds the given tables as `INNER JOIN table ON cond` clauses.
+func buildJoinTables(buf Buffer, tables []joinTable) (err error) {
+	for x := 0; x < len(tables); x++ {
+		_, _ = buf.WriteString(" INNER JOIN ")
+		writeIdent(buf, tables[x].table)
+		_, _ = buf.WriteString(" ON ")
+		if err = tables[x].on.Build(buf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// buildTableList builds the given tables as a `keyword table, table` clause and returns their join conditions
+// prepended to the given where conditions.
+func buildTableList(buf Buffer, keyword string, tables []joinTable, where []Statement) (w []Statement) {
+	if len(tables) == 0 {
+		return where
+	}
+
+	_, _ = buf.WriteString(keyword)
+	w = make([]Statement, 0, len(tables)+len(where))
+	for x := 0; x < len(tables); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		writeIdent(buf, tables[x].table)
+		w = append(w, tables[x].on)
+	}
+
+	return append(w, where...)
+}
+
+// tableAlias returns the alias of a `table alias` or `table AS alias` reference, or the table name.
+func tableAlias(table string) (alias string) {
+	f := strings.Fields(table)
+	if len(f) == 0 {
+		return table
+	}
+	return f[len(f)-1]
+}
+
+// buildReturning builds a `RETURNING columns` clause.
+// On the SQLServer dialect returning columns are built as an `OUTPUT` clause by buildOutput.
+func buildReturning(buf Buffer, columns []string) (err error) {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	switch d := dialectOf(buf); d {
+	case SQLServer:
+		return nil
+	case MySQL, Oracle:
+		return unsupported("RETURNING", d)
+	}
+
+	_, _ = buf.WriteString(" RETURNING ")
+	writeIdents(buf, columns)
+	return nil
+}
+
+// buildOutput builds a `OUTPUT prefix.columns` clause on the SQLServer dialect.
+func buildOutput(buf Buffer, prefix string, columns []string) {
+	if len(columns) == 0 || dialectOf(buf) != SQLServer {
+		return
+	}
+
+	_, _ = buf.WriteString(" OUTPUT ")
+	for x := 0; x < len(columns); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(",")
+		}
+		_, _ = buf.WriteString(prefix)
+		_, _ = buf.WriteString(".")
+		writeIdent(buf, columns[x])
+	}
+}
+
+// buildComment builds a `-- <comment>` line.
+func buildComment(c string, values ...interface{}) (s Statement) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	_, _ = buf.WriteString("-- ")
+	_, _ = buf.WriteString(c)
+
+	return &comment{part: &Part{Query: buf.String(), Values: values}}
+}
+
+// buildTag builds a `/*key='value',...*/` comment in the sqlcommenter format, with the keys sorted
+// and the keys and values URL encoded, so that they can't contain quotes, placeholders or end the comment.
+func buildTag(tags map[string]string) (s Statement) {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := buffer.New()
+	defer buf.Release()
+
+	_, _ = buf.WriteString("/*")
+	for x, k := range keys {
+		if x > 0 {
+			_, _ = buf.WriteString(",")
+		}
+		_, _ = buf.WriteString(tagEscape(k))
+		_, _ = buf.WriteString("='")
+		_, _ = buf.WriteString(tagEscape(tags[k]))
+		_, _ = buf.WriteString("'")
+	}
+	_, _ = buf.WriteString("*/")
+
+	return &comment{part: &Part{Query: buf.String()}}
+}
+
+// tagEscape URL encodes the given tag key or value, with spaces encoded as %20.
+func tagEscape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// comment represents a SQL comment. Values are always interpolated
+// as placeholders within comments are not seen by the database.
+type comment struct {
+	part *Part
+}
+
+// Build builds the statement into the given buffer.
+func (s *comment) Build(buf Buffer) (err error) {
+	b := buffer.New()
+	defer b.Release()
+
+	if err = s.part.Build(b); err != nil {
+		return err
+	}
+
+	_, _ = buf.WriteString(b.String())
+	return nil
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *comment) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// with represents a `WITH` clause with one or more common table expressions.
 type with struct {
+	ctes []cte
+}
+
+// cte is a common table expression, with optional recursive terms that are
+// combined with the anchor statement by `UNION ALL`.
+type cte struct {
 	recursive bool
 	alias     string
-	stmt      Statement
+	stmt      []Statement
+}
+
+// add adds a common table expression to the clause, creating it if w is nil.
+func (w *with) add(alias string, recursive bool, stmt ...Statement) *with {
+	if w == nil {
+		w = &with{}
+	}
+
+	w.ctes = append(w.ctes, cte{recursive: recursive, alias: alias, stmt: stmt})
+	return w
+}
+
+// clone returns a copy of the common table expressions that can be added to independently.
+func (w *with) clone() *with {
+	if w == nil {
+		return nil
+	}
+	return &with{ctes: append(w.ctes[:0:0], w.ctes...)}
 }
 
 // Build builds the statement into the given buffer.
+// The `RECURSIVE` keyword is omitted on the Oracle and SQLServer dialects, which don't require it.
 func (s *with) Build(buf Buffer) (err error) {
-	if s.alias == "" {
-		return ErrEmptyWithAlias
-	}
+	_, _ = buf.WriteString("WITH ")
 
-	var w string
-	switch s.recursive {
-	case false:
-		w = "WITH "
-	case true:
-		w = "WITH RECURSIVE "
+	switch dialectOf(buf) {
+	case Oracle, SQLServer:
+	default:
+		for x := 0; x < len(s.ctes); x++ {
+			if s.ctes[x].recursive {
+				_, _ = buf.WriteString("RECURSIVE ")
+				break
+			}
+		}
 	}
 
-	_, _ = buf.WriteString(w)
-	_, _ = buf.WriteString(s.alias)
-	_, _ = buf.WriteString(" AS (")
-	if err = s.stmt.Build(buf); err != nil {
-		return err
+	for x := 0; x < len(s.ctes); x++ {
+		c := s.ctes[x]
+		if c.alias == "" {
+			return ErrEmptyWithAlias
+		}
+
+		if x > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+
+		_, _ = buf.WriteString(c.alias)
+		_, _ = buf.WriteString(" AS (")
+		for y := 0; y < len(c.stmt); y++ {
+			if y > 0 {
+				_, _ = buf.WriteString(" UNION ALL ")
+			}
+
+			if err = c.stmt[y].Build(buf); err != nil {
+				return err
+			}
+		}
+		_, _ = buf.WriteString(")")
 	}
-	_, _ = buf.WriteString(")")
+
 	return nil
 }
 
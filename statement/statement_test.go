@@ -0,0 +1,172 @@
+package statement
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	// three where clauses leave spare capacity in the base slices
+	sel := Select().Columns("id").From("users").With("active", Select().Columns("id").From("sessions")).
+		Where("a = ?", 1).Where("b = ?", 2).Where("c = ?", 3)
+	upd := Update().Table("users").Set("name", "john").Where("a = ?", 1).Where("b = ?", 2).Where("c = ?", 3)
+	del := Delete().From("users").Where("a = ?", 1).Where("b = ?", 2).Where("c = ?", 3)
+	ins := Insert().Into("users").Columns("id").Values(1).Values(2).Values(3)
+	cmp := Union(Select().Columns("id").From("a"), Select().Columns("id").From("b")).
+		Union(Select().Columns("id").From("c"))
+
+	cases := []struct {
+		name    string
+		base    Statement
+		derive  func(v int) Statement
+		variant string
+		expect  string
+	}{
+		{
+			name: "select",
+			base: sel,
+			derive: func(v int) Statement {
+				return sel.Clone().Where("d = ?", v).JoinInner("roles", "roles.id = users.role_id").
+					With("admins", Select().Columns("id").From("admins")).OrderAsc("id")
+			},
+			expect:  `WITH active AS (SELECT id FROM sessions) SELECT id FROM users WHERE a = 1 AND b = 2 AND c = 3`,
+			variant: `WITH active AS (SELECT id FROM sessions), admins AS (SELECT id FROM admins) SELECT id FROM users INNER JOIN roles ON roles.id = users.role_id WHERE a = 1 AND b = 2 AND c = 3 AND d = 4 ORDER BY id ASC`,
+		},
+		{
+			name:    "update",
+			base:    upd,
+			derive:  func(v int) Statement { return upd.Clone().Set("email", v).Where("d = ?", v) },
+			expect:  `UPDATE users SET name = 'john' WHERE a = 1 AND b = 2 AND c = 3`,
+			variant: `UPDATE users SET email = 4, name = 'john' WHERE a = 1 AND b = 2 AND c = 3 AND d = 4`,
+		},
+		{
+			name:    "delete",
+			base:    del,
+			derive:  func(v int) Statement { return del.Clone().Where("d = ?", v).Using("roles", "roles.id = users.role_id") },
+			expect:  `DELETE FROM users WHERE a = 1 AND b = 2 AND c = 3`,
+			variant: `DELETE FROM users USING roles WHERE roles.id = users.role_id AND a = 1 AND b = 2 AND c = 3 AND d = 4`,
+		},
+		{
+			name:    "insert",
+			base:    ins,
+			derive:  func(v int) Statement { return ins.Clone().Values(v).Returning("id") },
+			expect:  `INSERT INTO users(id) VALUES (1),(2),(3)`,
+			variant: `INSERT INTO users(id) VALUES (1),(2),(3),(4) RETURNING id`,
+		},
+		{
+			name: "compound",
+			base: cmp,
+			derive: func(v int) Statement {
+				return cmp.Clone().Union(Select().Columns("id").From("d").Where("v = ?", v)).Limit(1)
+			},
+			expect:  `SELECT id FROM a UNION SELECT id FROM b UNION SELECT id FROM c`,
+			variant: `SELECT id FROM a UNION SELECT id FROM b UNION SELECT id FROM c UNION SELECT id FROM d WHERE v = 4 LIMIT 1 OFFSET 0`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			// derive multiple variants before building, each must not affect the base or the other variants
+			a, b := tt.derive(4), tt.derive(5)
+
+			q, err := a.String()
+			if err != nil {
+				t.Fatalf("error building clone: %s", err)
+			}
+
+			if q != tt.variant {
+				t.Fatalf("expected: %s, got: %s", tt.variant, q)
+			}
+
+			if _, err = b.String(); err != nil {
+				t.Fatalf("error building clone: %s", err)
+			}
+
+			if q, err = tt.base.String(); err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if q != tt.expect {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cases := []struct {
+		name    string
+		stmt    Parameterized
+		opts    []Option
+		wantErr error
+	}{
+		{name: "select", stmt: Select().Columns("id").From("users")},
+		{name: "select_without_from", stmt: Select().Columns("1")},
+		{name: "select_without_columns", stmt: Select().From("users"), wantErr: ErrIncomplete},
+		{name: "insert", stmt: Insert().Into("users").Columns("id").Values(1)},
+		{name: "insert_from_select", stmt: Insert().Into("users").FromSelect(Select().Columns("id").From("accounts"))},
+		{name: "insert_without_table", stmt: Insert().Columns("id").Values(1), wantErr: ErrIncomplete},
+		{name: "insert_without_values", stmt: Insert().Into("users").Columns("id"), wantErr: ErrIncomplete},
+		{name: "update", stmt: Update().Table("users").Set("name", "john")},
+		{name: "update_without_table", stmt: Update().Set("name", "john"), wantErr: ErrIncomplete},
+		{name: "update_without_set", stmt: Update().Table("users").Where("id = ?", 1), wantErr: ErrIncomplete},
+		{name: "update_without_where", stmt: Update().Table("users").Set("name", "john"),
+			opts: []Option{WithRequireWhere()}, wantErr: ErrMissingWhere},
+		{name: "update_with_where", stmt: Update().Table("users").Set("name", "john").Where("id = ?", 1),
+			opts: []Option{WithRequireWhere()}},
+		{name: "delete", stmt: Delete().From("users")},
+		{name: "delete_without_table", stmt: Delete().Where("id = ?", 1), wantErr: ErrIncomplete},
+		{name: "delete_without_where", stmt: Delete().From("users"),
+			opts: []Option{WithRequireWhere()}, wantErr: ErrMissingWhere},
+		{name: "delete_with_where", stmt: Delete().From("users").Where("id = ?", 1),
+			opts: []Option{WithRequireWhere()}},
+		{name: "nested", stmt: Select().Columns("id").From(Select().From("users")), wantErr: ErrIncomplete},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.stmt.SQL(tt.opts...)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got: %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestTag(t *testing.T) {
+	tags := map[string]string{"controller": "orders", "action": "list", "route": "/orders/{id}?x='1'*/"}
+
+	cases := []struct {
+		name   string
+		stmt   Parameterized
+		expect string
+	}{
+		{
+			name: "select",
+			stmt: Select().Tag(tags).Columns("id").From("orders").Where("id = ?", 1),
+			expect: `/*action='list',controller='orders',route='%2Forders%2F%7Bid%7D%3Fx%3D%271%27%2A%2F'*/
+SELECT id FROM orders WHERE id = $1`,
+		},
+		{
+			name: "update_comment",
+			stmt: Update().Comment("request id: ?", 12435).Tag(map[string]string{"app": "billing svc"}).
+				Table("orders").Set("paid", true).Where("id = ?", 1),
+			expect: `-- request id: 12435
+/*app='billing%20svc'*/
+UPDATE orders SET paid = $1 WHERE id = $2`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _, err := tt.stmt.SQL(WithDialect(Postgres))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+		})
+	}
+}
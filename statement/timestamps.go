@@ -0,0 +1,59 @@
+package statement
+
+import (
+	"strings"
+)
+
+// Now is a value for the current timestamp, built as `now()` on the Postgres dialect
+// and as `CURRENT_TIMESTAMP` otherwise.
+type Now struct{}
+
+func (Now) build(buf Buffer) (err error) {
+	if dialectOf(buf) == Postgres {
+		_, _ = buf.WriteString("now()")
+		return nil
+	}
+
+	_, _ = buf.WriteString("CURRENT_TIMESTAMP")
+	return nil
+}
+
+// Timestamps defines the columns automatically managed by the statements that opt in with their
+// Timestamps method, so admin statements can bypass them. Empty columns are not managed.
+// It is meant to be declared once, for all tables or for the given Tables.
+type Timestamps struct {
+	// CreatedAt is set to Now for each row of insert statements with VALUES rows,
+	// unless it is in the insert columns.
+	CreatedAt string
+
+	// UpdatedAt is set to Now by update statements, unless it is explicitly set.
+	UpdatedAt string
+
+	// DeletedAt is filtered as `DeletedAt IS NULL` by select statements, qualified with
+	// the table alias if any, to exclude soft deleted rows.
+	DeletedAt string
+
+	// Tables are the tables with managed columns, if empty all tables are managed.
+	Tables []string
+}
+
+// column returns the given column if it is managed for the given table reference, which can be aliased
+// as `table alias`, or an empty string otherwise.
+func (t Timestamps) column(table, column string) string {
+	if len(t.Tables) == 0 {
+		return column
+	}
+
+	f := strings.Fields(table)
+	if len(f) == 0 {
+		return ""
+	}
+
+	for x := 0; x < len(t.Tables); x++ {
+		if t.Tables[x] == f[0] {
+			return column
+		}
+	}
+
+	return ""
+}
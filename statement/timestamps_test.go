@@ -0,0 +1,90 @@
+package statement
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTimestamps(t *testing.T) {
+	audited := Timestamps{CreatedAt: "created_at", UpdatedAt: "updated_at", DeletedAt: "deleted_at"}
+	users := Timestamps{CreatedAt: "created_at", DeletedAt: "deleted_at", Tables: []string{"users"}}
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		args    []interface{}
+	}{
+		{
+			name:    "insert",
+			dialect: Postgres,
+			stmt:    Insert().Into("users").Columns("id", "name").Values(1, "john").Values(2, "jane").Timestamps(audited),
+			expect:  `INSERT INTO users(id,name,created_at) VALUES ($1,$2,now()),($3,$4,now())`,
+			args:    []interface{}{1, "john", 2, "jane"},
+		},
+		{
+			name:    "insert_explicit",
+			dialect: MySQL,
+			stmt:    Insert().Into("users").Columns("id", "created_at").Values(1, "2020-01-01").Timestamps(audited),
+			expect:  `INSERT INTO users(id,created_at) VALUES (?,?)`,
+			args:    []interface{}{1, "2020-01-01"},
+		},
+		{
+			name:    "update",
+			dialect: MySQL,
+			stmt:    Update().Table("users").Set("name", "john").Where("id = ?", 1).Timestamps(audited),
+			expect:  `UPDATE users SET name = ?, updated_at = CURRENT_TIMESTAMP WHERE id = ?`,
+			args:    []interface{}{"john", 1},
+		},
+		{
+			name:    "update_now",
+			dialect: Postgres,
+			stmt:    Update().Table("users").Set("seen_at", Now{}).Where("id = ?", 1),
+			expect:  `UPDATE users SET seen_at = now() WHERE id = $1`,
+			args:    []interface{}{1},
+		},
+		{
+			name:    "select",
+			dialect: Postgres,
+			stmt:    Select().Columns("id").From("users").Where("role = ?", "admin").Timestamps(audited),
+			expect:  `SELECT id FROM users WHERE role = $1 AND deleted_at IS NULL`,
+			args:    []interface{}{"admin"},
+		},
+		{
+			name:    "select_alias",
+			dialect: Postgres,
+			stmt:    Select().Columns("u.id").From("users u").JoinInner("roles r", "r.id = u.role_id").Timestamps(users),
+			expect:  `SELECT u.id FROM users u INNER JOIN roles r ON r.id = u.role_id WHERE u.deleted_at IS NULL`,
+		},
+		{
+			name:    "unmanaged_table",
+			dialect: Postgres,
+			stmt:    Select().Columns("id").From("roles").Timestamps(users),
+			expect:  `SELECT id FROM roles`,
+		},
+		{
+			name:    "bypass",
+			dialect: Postgres,
+			stmt:    Select().Columns("id").From("users"),
+			expect:  `SELECT id FROM users`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+}
@@ -1,20 +1,23 @@
 package statement
 
 import (
+	"reflect"
 	"sort"
-	"strings"
 
 	"github.com/brunotm/norm/internal/buffer"
+	"github.com/brunotm/norm/internal/scan"
 )
 
 // UpdateStatement statement.
 type UpdateStatement struct {
 	table     string
-	with      Statement
+	with      *with
 	values    map[string]interface{}
 	where     []Statement
+	from      []joinTable
 	comment   []Statement
 	returning []string
+	managed   *Timestamps
 }
 
 // Update creates a new update statement
@@ -25,16 +28,15 @@ func Update() (s *UpdateStatement) {
 // Comment adds a SQL comment to the generated query.
 // Each call to comment creates a new `-- <comment>` line.
 func (s *UpdateStatement) Comment(c string, values ...interface{}) *UpdateStatement {
-	buf := buffer.New()
-	defer buf.Release()
-
-	_, _ = buf.WriteString("-- ")
-	_, _ = buf.WriteString(c)
+	s.comment = append(s.comment, buildComment(c, values...))
+	return s
+}
 
-	p := &Part{}
-	p.Query = buf.String()
-	p.Values = values
-	s.comment = append(s.comment, p)
+// Tag adds a `/*key='value'*/` comment with the given tags in the sqlcommenter format to the generated query,
+// like `/*action='list',controller='orders'*/`, to correlate queries in the database logs with the code that issued them.
+// As comments, tags are built before the statement in their own line.
+func (s *UpdateStatement) Tag(tags map[string]string) *UpdateStatement {
+	s.comment = append(s.comment, buildTag(tags))
 	return s
 }
 
@@ -59,9 +61,45 @@ func (s *UpdateStatement) SetMap(m map[string]interface{}) *UpdateStatement {
 	return s
 }
 
+// SetStruct adds a `SET column = value` clause for each field of the given struct, mapped to columns
+// as with Insert().Record(). Fields tagged with `db:"-"` or as read only with `db:"column,readonly"`
+// and the fields mapped to the excluded columns, like the primary key, are not updated.
+// Values that are not a struct are ignored.
+func (s *UpdateStatement) SetStruct(structValue interface{}, exclude ...string) *UpdateStatement {
+	v := reflect.Indirect(reflect.ValueOf(structValue))
+	if v.Kind() != reflect.Struct {
+		return s
+	}
+
+	excluded := make(map[string]bool, len(exclude))
+	for _, column := range exclude {
+		excluded[column] = true
+	}
+
+	m := scan.StructMap(v.Type())
+	for column, index := range m {
+		field := v.Type().FieldByIndex(index)
+		if excluded[column] || scan.IsReadOnly(field) || scan.IsParent(m, index) {
+			continue
+		}
+		s.values[column] = recordValue(field, v.FieldByIndex(index))
+	}
+
+	return s
+}
+
+// From adds a `FROM table` clause joining the table with the given condition, as
+// `UPDATE target SET ... FROM table WHERE cond`. On the MySQL dialect it is built as
+// `UPDATE target INNER JOIN table ON cond SET ...` and on the SQLServer dialect as
+// `UPDATE target SET ... FROM target INNER JOIN table ON cond`. Multiple calls join multiple tables.
+func (s *UpdateStatement) From(table, on string, values ...interface{}) *UpdateStatement {
+	s.from = append(s.from, joinTable{table: table, on: &Part{Query: on, Values: values}})
+	return s
+}
+
 // With adds a `WITH alias AS (stmt)` clause.
 func (s *UpdateStatement) With(alias string, stmt Statement) *UpdateStatement {
-	s.with = &with{alias: alias, stmt: stmt}
+	s.with = s.with.add(alias, false, stmt)
 	return s
 }
 
@@ -77,14 +115,79 @@ func (s *UpdateStatement) WhereIn(column string, values ...interface{}) *UpdateS
 	return s
 }
 
+// WhereCond adds a `WHERE cond` clause for conditions built with Cond, In, Between, Like, IsNull,
+// IsNotNull, And and Or, multiple calls to WhereCond are `ANDed` together.
+func (s *UpdateStatement) WhereCond(cond Statement) *UpdateStatement {
+	s.where = append(s.where, cond)
+	return s
+}
+
+// WhereKey adds a `WHERE (column = value AND ...)` clause matching the columns of a composite key
+// to the given values as with Key, multiple calls to WhereKey are `ANDed` together.
+func (s *UpdateStatement) WhereKey(key map[string]interface{}) *UpdateStatement {
+	s.where = append(s.where, Key(key))
+	return s
+}
+
+// WhereKeyStruct adds a `WHERE (column = value AND ...)` clause matching the fields of the given struct
+// tagged as key columns with `db:"column,key"` as with KeyStruct, multiple calls to WhereKeyStruct are `ANDed` together.
+func (s *UpdateStatement) WhereKeyStruct(structValue interface{}) *UpdateStatement {
+	s.where = append(s.where, KeyStruct(structValue))
+	return s
+}
+
+// Timestamps sets the UpdatedAt column from the given Timestamps to Now, unless it is explicitly set.
+func (s *UpdateStatement) Timestamps(ts Timestamps) *UpdateStatement {
+	s.managed = &ts
+	return s
+}
+
 // Returning adds a `RETURNING columns` clause.
 func (s *UpdateStatement) Returning(columns ...string) *UpdateStatement {
 	s.returning = columns
 	return s
 }
 
+// Clone returns a copy of the statement that can be modified without affecting the original,
+// allowing variants to be derived from a shared base statement.
+// Nested statements, like subqueries, are shared by both statements.
+func (s *UpdateStatement) Clone() *UpdateStatement {
+	c := *s
+	c.with = s.with.clone()
+	c.values = make(map[string]interface{}, len(s.values))
+	for k, v := range s.values {
+		c.values[k] = v
+	}
+	c.where = append(s.where[:0:0], s.where...)
+	c.from = append(s.from[:0:0], s.from...)
+	c.comment = append(s.comment[:0:0], s.comment...)
+	c.returning = append(s.returning[:0:0], s.returning...)
+	return &c
+}
+
+// Validate checks that the statement is fully specified, returning ErrIncomplete
+// if it has no table or no assignments.
+func (s *UpdateStatement) Validate() (err error) {
+	switch {
+	case s.table == "":
+		return incomplete("UPDATE", "table")
+	case len(s.values) == 0:
+		return incomplete("UPDATE", "SET")
+	}
+	return nil
+}
+
 // Build builds the statement into the given buffer.
+// With the WithRequireWhere option statements without a `WHERE` clause return ErrMissingWhere.
 func (s *UpdateStatement) Build(buf Buffer) (err error) {
+	if err = s.Validate(); err != nil {
+		return err
+	}
+
+	if err = checkWhere(buf, "UPDATE", s.where); err != nil {
+		return err
+	}
+
 	for x := 0; x < len(s.comment); x++ {
 		if err = s.comment[x].Build(buf); err != nil {
 			return err
@@ -99,12 +202,40 @@ func (s *UpdateStatement) Build(buf Buffer) (err error) {
 		_, _ = buf.WriteString(" ")
 	}
 
+	d := dialectOf(buf)
+	if len(s.from) > 0 && d == Oracle {
+		return unsupported("UPDATE FROM", d)
+	}
+
 	_, _ = buf.WriteString("UPDATE ")
-	_, _ = buf.WriteString(s.table)
+	switch {
+	case len(s.from) > 0 && d == SQLServer:
+		writeIdent(buf, tableAlias(s.table))
+	case len(s.from) > 0 && d == MySQL:
+		writeIdent(buf, s.table)
+		if err = buildJoinTables(buf, s.from); err != nil {
+			return err
+		}
+	default:
+		writeIdent(buf, s.table)
+	}
 	_, _ = buf.WriteString(" SET")
 
-	sorted := make([]string, 0, len(s.values))
-	for k := range s.values {
+	values := s.values
+	if s.managed != nil {
+		if c := s.managed.column(s.table, s.managed.UpdatedAt); c != "" {
+			if _, ok := values[c]; !ok {
+				values = make(map[string]interface{}, len(s.values)+1)
+				for k, v := range s.values {
+					values[k] = v
+				}
+				values[c] = Now{}
+			}
+		}
+	}
+
+	sorted := make([]string, 0, len(values))
+	for k := range values {
 		sorted = append(sorted, k)
 	}
 	sort.Strings(sorted)
@@ -114,24 +245,34 @@ func (s *UpdateStatement) Build(buf Buffer) (err error) {
 			_, _ = buf.WriteString(",")
 		}
 		_, _ = buf.WriteString(" ")
-		_, _ = buf.WriteString(sorted[x])
+		writeIdent(buf, sorted[x])
 		_, _ = buf.WriteString(" = ")
 
-		if err = writeValue(buf, s.values[sorted[x]], false); err != nil {
+		if err = buildValue(buf, values[sorted[x]], false); err != nil {
 			return err
 		}
 	}
 
-	if err = buildWhere(buf, s.where); err != nil {
-		return err
+	buildOutput(buf, "INSERTED", s.returning)
+
+	where := s.where
+	switch {
+	case len(s.from) == 0 || d == MySQL:
+	case d == SQLServer:
+		_, _ = buf.WriteString(" FROM ")
+		writeIdent(buf, s.table)
+		if err = buildJoinTables(buf, s.from); err != nil {
+			return err
+		}
+	default:
+		where = buildTableList(buf, " FROM ", s.from, where)
 	}
 
-	if len(s.returning) > 0 {
-		_, _ = buf.WriteString(" RETURNING ")
-		_, _ = buf.WriteString(strings.Join(s.returning, ","))
+	if err = buildWhere(buf, where); err != nil {
+		return err
 	}
 
-	return nil
+	return buildReturning(buf, s.returning)
 }
 
 // String builds the statement and returns the resulting query string.
@@ -145,3 +286,8 @@ func (s *UpdateStatement) String() (q string, err error) {
 
 	return buf.String(), nil
 }
+
+// SQL builds the statement and returns the resulting parameterized query and arguments.
+func (s *UpdateStatement) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
@@ -2,8 +2,17 @@ package statement
 
 import (
 	"testing"
+	"time"
 )
 
+type updateUser struct {
+	ID        int64  `db:"id"`
+	Name      string `db:"name"`
+	Email     string
+	Password  string    `db:"-"`
+	CreatedAt time.Time `db:"created_at,readonly"`
+}
+
 var (
 	updateCases = []struct {
 		name    string
@@ -18,6 +27,14 @@ var (
 				Set("role", "admin").Where("id = ?", 123),
 			wantErr: false,
 		},
+		{
+			name:   "set_struct",
+			expect: `UPDATE users SET email = 'john.doe@email.com', name = 'john' WHERE id = 123`,
+			stmt: Update().Table("users").
+				SetStruct(&updateUser{ID: 123, Name: "john", Email: "john.doe@email.com", Password: "secret"}, "id").
+				Where("id = ?", 123),
+			wantErr: false,
+		},
 		{
 			name:   "simple_setmap",
 			expect: `UPDATE users SET email = 'john.doe@email.com', role = 'admin', user = 'john.doe' WHERE id = 123`,
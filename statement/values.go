@@ -4,6 +4,8 @@ import (
 	"database/sql/driver"
 	"encoding/hex"
 	"fmt"
+	"math/big"
+	"reflect"
 	"strconv"
 	"strings"
 	"time"
@@ -11,7 +13,51 @@ import (
 
 var rfc3339micro = "'2006-01-02T15:04:05.999999Z07:00'"
 
+// DefaultValue is a value for the column default, built as the `DEFAULT` keyword instead of a bound argument,
+// as for the rows of a multi row insert where some rows use the column default and others an explicit value.
+// It is not supported on the SQLite dialect.
+type DefaultValue struct{}
+
+func (DefaultValue) build(buf Buffer) (err error) {
+	if d := dialectOf(buf); d == SQLite {
+		return unsupported("DEFAULT value", d)
+	}
+
+	_, _ = buf.WriteString("DEFAULT")
+	return nil
+}
+
+// buildValue builds a value which can be a Statement, Ident, Excluded, Now, DefaultValue or an argument.
+func buildValue(buf Buffer, arg interface{}, keyword bool) (err error) {
+	switch arg := arg.(type) {
+	case Statement:
+		_, _ = buf.WriteString("(")
+		err = arg.Build(buf)
+		_, _ = buf.WriteString(")")
+	case Ident:
+		_, _ = buf.WriteString(string(arg))
+	case columnIdent:
+		writeIdent(buf, string(arg))
+	case Excluded:
+		err = arg.build(buf)
+	case Now:
+		err = arg.build(buf)
+	case DefaultValue:
+		err = arg.build(buf)
+	case recordArray:
+		err = arg.build(buf, keyword)
+	default:
+		err = writeValue(buf, arg, keyword)
+	}
+
+	return err
+}
+
 func writeValue(buf Buffer, arg interface{}, keyword bool) (err error) {
+	if w, ok := buf.(argWriter); ok && !keyword {
+		return w.WriteArg(arg)
+	}
+
 	if v, ok := arg.(driver.Valuer); ok {
 		if arg, err = v.Value(); err != nil {
 			return err
@@ -47,15 +93,70 @@ func writeValue(buf Buffer, arg interface{}, keyword bool) (err error) {
 		}
 	case time.Time:
 		_, _ = buf.WriteString(arg.Format(rfc3339micro))
+	case *big.Int, *big.Float, *big.Rat:
+		text, ok, err := bigText(arg)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			text = "null"
+		}
+		_, _ = buf.WriteString(text)
 	case fmt.Stringer:
 		quoteString(arg.String(), buf)
 	default:
+		if v := underlying(arg); reflect.TypeOf(v) != reflect.TypeOf(arg) {
+			return writeValue(buf, v, keyword)
+		}
 		return fmt.Errorf("statement: invalid arg type: %T, value: %#v", arg, arg)
 	}
 
 	return nil
 }
 
+// bigText returns the exact decimal representation of the given *big.Int, *big.Float or *big.Rat,
+// false if it is nil, or an error if it is a *big.Rat without a finite decimal representation, as 1/3.
+func bigText(arg interface{}) (text string, ok bool, err error) {
+	switch v := arg.(type) {
+	case *big.Int:
+		if v != nil {
+			return v.String(), true, nil
+		}
+	case *big.Float:
+		if v != nil {
+			return v.Text('f', -1), true, nil
+		}
+	case *big.Rat:
+		if v == nil {
+			break
+		}
+
+		// a fraction has a finite decimal representation if its denominator only has factors of 2 and 5,
+		// with as many decimal digits as the largest exponent of these factors
+		d, digits := new(big.Int).Set(v.Denom()), 0
+		for _, f := range []int64{2, 5} {
+			factor, q, m := big.NewInt(f), new(big.Int), new(big.Int)
+			n := 0
+			for q.DivMod(d, factor, m); m.Sign() == 0; q.DivMod(d, factor, m) {
+				d.Set(q)
+				n++
+			}
+			if n > digits {
+				digits = n
+			}
+		}
+
+		if !d.IsInt64() || d.Int64() != 1 {
+			return "", false, fmt.Errorf("statement: invalid decimal value, no finite representation: %s", v)
+		}
+		return v.FloatString(digits), true, nil
+	default:
+		return "", false, fmt.Errorf("statement: invalid arg type: %T, value: %#v", arg, arg)
+	}
+
+	return "", false, nil
+}
+
 // TODO: consider manually inlining this
 func quoteString(str string, buf Buffer) {
 	_, _ = buf.WriteString(`'`)
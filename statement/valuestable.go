@@ -0,0 +1,121 @@
+package statement
+
+import (
+	"fmt"
+
+	"github.com/brunotm/norm/internal/buffer"
+)
+
+// ValuesStatement is a `(VALUES (v1,v2),(v3,v4)) AS alias(columns)` list for use as a table source
+// in the `FROM` clause or in joins, to select from or join against a set of client provided rows
+// without a temporary table.
+type ValuesStatement struct {
+	rows    [][]interface{}
+	alias   string
+	columns []string
+}
+
+// Values creates a new values list with the given rows. Values are bound as arguments,
+// or built in place if they are a Statement or an Ident.
+func Values(rows ...[]interface{}) *ValuesStatement {
+	return &ValuesStatement{rows: rows}
+}
+
+// As sets the alias and the column names of the values list, as `AS alias(columns)`.
+func (s *ValuesStatement) As(alias string, columns ...string) *ValuesStatement {
+	s.alias = alias
+	s.columns = columns
+	return s
+}
+
+// Validate checks that the values list is fully specified, returning ErrIncomplete if it has no rows
+// or no alias, and ErrInvalidArgNumber if the rows don't have the same number of values as the columns.
+func (s *ValuesStatement) Validate() (err error) {
+	switch {
+	case len(s.rows) == 0:
+		return incomplete("VALUES", "rows")
+	case s.alias == "":
+		return incomplete("VALUES", "alias")
+	}
+
+	n := len(s.columns)
+	if n == 0 {
+		n = len(s.rows[0])
+	}
+
+	for x := 0; x < len(s.rows); x++ {
+		if len(s.rows[x]) != n || n == 0 {
+			return fmt.Errorf("%w: values row %d has %d values, expected %d",
+				ErrInvalidArgNumber, x+1, len(s.rows[x]), n)
+		}
+	}
+
+	return nil
+}
+
+// Build builds the values list into the given buffer. Rows are built as `ROW(v1,v2)` on the MySQL dialect,
+// column names are not supported on SQLite and values lists are not supported on Oracle.
+func (s *ValuesStatement) Build(buf Buffer) (err error) {
+	if err = s.Validate(); err != nil {
+		return err
+	}
+
+	d := dialectOf(buf)
+	switch {
+	case d == Oracle:
+		return unsupported("VALUES", d)
+	case d == SQLite && len(s.columns) > 0:
+		return unsupported("VALUES column names", d)
+	}
+
+	_, _ = buf.WriteString("(VALUES ")
+
+	for x := 0; x < len(s.rows); x++ {
+		if x > 0 {
+			_, _ = buf.WriteString(",")
+		}
+
+		if d == MySQL {
+			_, _ = buf.WriteString("ROW")
+		}
+
+		_, _ = buf.WriteString("(")
+		for y := 0; y < len(s.rows[x]); y++ {
+			if y > 0 {
+				_, _ = buf.WriteString(",")
+			}
+			if err = buildValue(buf, s.rows[x][y], false); err != nil {
+				return err
+			}
+		}
+		_, _ = buf.WriteString(")")
+	}
+
+	_, _ = buf.WriteString(") AS ")
+	writeIdent(buf, s.alias)
+
+	if len(s.columns) > 0 {
+		_, _ = buf.WriteString("(")
+		writeIdents(buf, s.columns)
+		_, _ = buf.WriteString(")")
+	}
+
+	return nil
+}
+
+// String builds the values list and returns the resulting query string.
+func (s *ValuesStatement) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// SQL builds the values list and returns the resulting parameterized query and arguments.
+func (s *ValuesStatement) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
@@ -0,0 +1,78 @@
+package statement
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestValues(t *testing.T) {
+	rows := [][]interface{}{{1, "a"}, {2, "b"}}
+
+	cases := []struct {
+		name    string
+		stmt    Parameterized
+		dialect Dialect
+		expect  string
+		args    []interface{}
+	}{
+		{
+			name:    "from",
+			stmt:    Select().Columns("*").From(Values(rows...).As("t", "id", "name")).Where("t.id > ?", 0),
+			dialect: Postgres,
+			expect:  `SELECT * FROM (VALUES ($1,$2),($3,$4)) AS t(id,name) WHERE t.id > $5`,
+			args:    []interface{}{1, "a", 2, "b", 0},
+		},
+		{
+			name: "join",
+			stmt: Select().Columns("u.id", "t.name").From("users u").Where("u.active = ?", true).
+				JoinValues(InnerJoin, Values(rows...).As("t", "id", "name"), "t.id = u.id AND u.kind = ?", "x"),
+			dialect: Postgres,
+			expect:  `SELECT u.id,t.name FROM users u INNER JOIN (VALUES ($1,$2),($3,$4)) AS t(id,name) ON t.id = u.id AND u.kind = $5 WHERE u.active = $6`,
+			args:    []interface{}{1, "a", 2, "b", "x", true},
+		},
+		{
+			name:    "mysql",
+			stmt:    Select().Columns("*").From(Values(rows...).As("t", "id", "name")),
+			dialect: MySQL,
+			expect:  `SELECT * FROM (VALUES ROW(?,?),ROW(?,?)) AS t(id,name)`,
+			args:    []interface{}{1, "a", 2, "b"},
+		},
+		{
+			name:    "sqlserver",
+			stmt:    Select().Columns("*").From(Values(rows...).As("t", "id", "name")),
+			dialect: SQLServer,
+			expect:  `SELECT * FROM (VALUES (@p1,@p2),(@p3,@p4)) AS t(id,name)`,
+			args:    []interface{}{1, "a", 2, "b"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+
+	if _, err := Values(rows...).String(); !errors.Is(err, ErrIncomplete) {
+		t.Fatalf("expected ErrIncomplete without alias, got: %v", err)
+	}
+
+	if _, err := Values([]interface{}{1, "a"}, []interface{}{2}).As("t", "id", "name").String(); !errors.Is(err, ErrInvalidArgNumber) {
+		t.Fatalf("expected ErrInvalidArgNumber, got: %v", err)
+	}
+
+	if _, _, err := Values(rows...).As("t", "id", "name").SQL(WithDialect(Oracle)); !errors.Is(err, ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported, got: %v", err)
+	}
+}
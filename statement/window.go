@@ -0,0 +1,97 @@
+package statement
+
+import (
+	"strings"
+)
+
+// WindowSpec is the window definition of a window function `OVER (...)` clause, created with Over.
+type WindowSpec struct {
+	partitionBy []string
+	orderBy     []string
+	frame       string
+}
+
+// Over creates a new window definition for use with Window.
+func Over() *WindowSpec {
+	return &WindowSpec{}
+}
+
+// PartitionBy sets the `PARTITION BY columns` of the window.
+func (w *WindowSpec) PartitionBy(columns ...string) *WindowSpec {
+	w.partitionBy = columns
+	return w
+}
+
+// OrderBy sets the `ORDER BY columns` of the window, columns can specify their order as `column DESC`.
+func (w *WindowSpec) OrderBy(columns ...string) *WindowSpec {
+	w.orderBy = columns
+	return w
+}
+
+// Rows sets a `ROWS BETWEEN start AND end` frame for the window,
+// as Rows("UNBOUNDED PRECEDING", "CURRENT ROW").
+func (w *WindowSpec) Rows(start, end string) *WindowSpec {
+	w.frame = "ROWS BETWEEN " + start + " AND " + end
+	return w
+}
+
+// Range sets a `RANGE BETWEEN start AND end` frame for the window,
+// as Range("UNBOUNDED PRECEDING", "CURRENT ROW").
+func (w *WindowSpec) Range(start, end string) *WindowSpec {
+	w.frame = "RANGE BETWEEN " + start + " AND " + end
+	return w
+}
+
+// String returns the `OVER (...)` clause of the window.
+func (w *WindowSpec) String() string {
+	var clauses []string
+
+	if len(w.partitionBy) > 0 {
+		clauses = append(clauses, "PARTITION BY "+strings.Join(w.partitionBy, ","))
+	}
+
+	if len(w.orderBy) > 0 {
+		clauses = append(clauses, "ORDER BY "+strings.Join(w.orderBy, ","))
+	}
+
+	if w.frame != "" {
+		clauses = append(clauses, w.frame)
+	}
+
+	return "OVER (" + strings.Join(clauses, " ") + ")"
+}
+
+// Window returns a `fn OVER (...)` window function expression for use in columns,
+// as Window(Sum("total"), Over().PartitionBy("customer_id").OrderBy("created")).
+// A nil window builds an empty `OVER ()` clause.
+func Window(fn string, over *WindowSpec) string {
+	if over == nil {
+		over = Over()
+	}
+	return fn + " " + over.String()
+}
+
+// RowNumber returns a `ROW_NUMBER()` window function for use with Window.
+func RowNumber() string {
+	return "ROW_NUMBER()"
+}
+
+// Rank returns a `RANK()` window function for use with Window.
+func Rank() string {
+	return "RANK()"
+}
+
+// DenseRank returns a `DENSE_RANK()` window function for use with Window.
+func DenseRank() string {
+	return "DENSE_RANK()"
+}
+
+// Lag returns a `LAG(expr)` window function for use with Window.
+func Lag(expr string) string {
+	return "LAG(" + expr + ")"
+}
+
+// Lead returns a `LEAD(expr)` window function for use with Window.
+func Lead(expr string) string {
+	return "LEAD(" + expr + ")"
+}
@@ -0,0 +1,52 @@
+package statement
+
+import (
+	"testing"
+)
+
+func TestWindow(t *testing.T) {
+	cases := []struct {
+		name   string
+		stmt   Parameterized
+		expect string
+	}{
+		{
+			name: "running_total",
+			stmt: Select().Columns("customer_id", "created",
+				As(Window(Sum("total"), Over().PartitionBy("customer_id").OrderBy("created").
+					Rows("UNBOUNDED PRECEDING", "CURRENT ROW")), "running_total")).
+				From("orders").Where("created > ?", "2021-01-01"),
+			expect: `SELECT customer_id,created,SUM(total) OVER (PARTITION BY customer_id ORDER BY created ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS running_total FROM orders WHERE created > $1`,
+		},
+		{
+			name: "ranking",
+			stmt: Select().Columns("id",
+				As(Window(RowNumber(), Over().PartitionBy("dept", "role").OrderBy("salary DESC")), "n"),
+				As(Window(Rank(), Over().OrderBy("salary DESC")), "r"),
+				As(Window(DenseRank(), nil), "d")).
+				From("employees"),
+			expect: `SELECT id,ROW_NUMBER() OVER (PARTITION BY dept,role ORDER BY salary DESC) AS n,RANK() OVER (ORDER BY salary DESC) AS r,DENSE_RANK() OVER () AS d FROM employees`,
+		},
+		{
+			name: "lag_lead",
+			stmt: Select().Columns("day",
+				As(Window(Lag("total"), Over().OrderBy("day")), "previous"),
+				As(Window(Lead("total"), Over().OrderBy("day").Range("CURRENT ROW", "UNBOUNDED FOLLOWING")), "next")).
+				From("sales"),
+			expect: `SELECT day,LAG(total) OVER (ORDER BY day) AS previous,LEAD(total) OVER (ORDER BY day RANGE BETWEEN CURRENT ROW AND UNBOUNDED FOLLOWING) AS next FROM sales`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _, err := tt.stmt.SQL(WithDialect(Postgres))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package statement
+
+import (
+	"github.com/brunotm/norm/internal/buffer"
+)
+
+// Count returns a statement counting the rows returned by the select statement, as
+// `SELECT COUNT(*) FROM (stmt) AS sub`, where the select is built without its ordering,
+// limit, offset and keyset pagination. Comments and common table expressions are built
+// in the outer statement. It allows retrieving the total number of rows for a paginated query.
+func (s *SelectStatement) Count() Parameterized {
+	inner := *s
+	inner.comment, inner.with, inner.keyset = nil, nil, nil
+	inner.orderBy, inner.orderExprs, inner.order = nil, nil, ""
+	inner.limitCount, inner.offsetCount = 0, 0
+
+	return &wrapped{comment: s.comment, with: s.with, stmt: &inner}
+}
+
+// Exists returns a statement reporting whether the select statement returns any rows, as
+// `SELECT EXISTS(stmt)`. On the Oracle and SQLServer dialects it is built as
+// `SELECT CASE WHEN EXISTS(stmt) THEN 1 ELSE 0 END`, from `DUAL` on Oracle.
+func (s *SelectStatement) Exists() Parameterized {
+	inner := *s
+	inner.comment, inner.with = nil, nil
+
+	return &wrapped{comment: s.comment, with: s.with, stmt: &inner, exists: true}
+}
+
+// wrapped represents a statement that wraps a select statement for counting or checking its rows.
+type wrapped struct {
+	exists  bool
+	comment []Statement
+	with    *with
+	stmt    Statement
+}
+
+// Build builds the statement into the given buffer.
+func (s *wrapped) Build(buf Buffer) (err error) {
+	for x := 0; x < len(s.comment); x++ {
+		if err = s.comment[x].Build(buf); err != nil {
+			return err
+		}
+		_, _ = buf.WriteString("\n")
+	}
+
+	if s.with != nil {
+		if err = s.with.Build(buf); err != nil {
+			return err
+		}
+		_, _ = buf.WriteString(" ")
+	}
+
+	d := dialectOf(buf)
+	if !s.exists {
+		_, _ = buf.WriteString("SELECT COUNT(*) FROM (")
+		if err = s.stmt.Build(buf); err != nil {
+			return err
+		}
+
+		// Oracle does not support AS for table aliases
+		if d == Oracle {
+			_, _ = buf.WriteString(") sub")
+		} else {
+			_, _ = buf.WriteString(") AS sub")
+		}
+		return nil
+	}
+
+	switch d {
+	case Oracle, SQLServer:
+		_, _ = buf.WriteString("SELECT CASE WHEN EXISTS(")
+		if err = s.stmt.Build(buf); err != nil {
+			return err
+		}
+		_, _ = buf.WriteString(") THEN 1 ELSE 0 END")
+		if d == Oracle {
+			_, _ = buf.WriteString(" FROM DUAL")
+		}
+
+	default:
+		_, _ = buf.WriteString("SELECT EXISTS(")
+		if err = s.stmt.Build(buf); err != nil {
+			return err
+		}
+		_, _ = buf.WriteString(")")
+	}
+
+	return nil
+}
+
+// String builds the statement and returns the resulting query string.
+func (s *wrapped) String() (q string, err error) {
+	buf := buffer.New()
+	defer buf.Release()
+
+	if err = s.Build(buf); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// SQL builds the statement and returns the resulting parameterized query and arguments.
+func (s *wrapped) SQL(opts ...Option) (q string, args []interface{}, err error) {
+	return buildSQL(s, opts...)
+}
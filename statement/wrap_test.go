@@ -0,0 +1,104 @@
+package statement
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelectCountExists(t *testing.T) {
+	page := func() *SelectStatement {
+		return Select().Columns("id", "name").From("users").
+			Where("role = ?", "admin").WhereIn("status", "active", "pending").
+			OrderDesc("created_at").Limit(20).Offset(40)
+	}
+
+	cases := []struct {
+		name    string
+		dialect Dialect
+		stmt    Parameterized
+		expect  string
+		args    []interface{}
+	}{
+		{
+			name:    "count",
+			dialect: Postgres,
+			stmt:    page().Count(),
+			expect:  `SELECT COUNT(*) FROM (SELECT id,name FROM users WHERE role = $1 AND status IN ($2,$3)) AS sub`,
+			args:    []interface{}{"admin", "active", "pending"},
+		},
+		{
+			name:    "count_keyset",
+			dialect: MySQL,
+			stmt:    Select().Columns("id").From("users").Where("role = ?", "admin").KeysetAfter([]string{"id"}, []interface{}{10}).Limit(10).Count(),
+			expect:  `SELECT COUNT(*) FROM (SELECT id FROM users WHERE role = ?) AS sub`,
+			args:    []interface{}{"admin"},
+		},
+		{
+			name:    "count_with",
+			dialect: Postgres,
+			stmt: Select().Comment("count").With("admins", Select().Columns("id").From("users").Where("role = ?", "admin")).
+				Columns("id").From("admins").OrderAsc("id").Count(),
+			expect: "-- count\nWITH admins AS (SELECT id FROM users WHERE role = $1) SELECT COUNT(*) FROM (SELECT id FROM admins) AS sub",
+			args:   []interface{}{"admin"},
+		},
+		{
+			name:    "count_oracle",
+			dialect: Oracle,
+			stmt:    page().Count(),
+			expect:  `SELECT COUNT(*) FROM (SELECT id,name FROM users WHERE role = :1 AND status IN (:2,:3)) sub`,
+			args:    []interface{}{"admin", "active", "pending"},
+		},
+		{
+			name:    "exists",
+			dialect: Postgres,
+			stmt:    Select().Columns("1").From("users").Where("email = ?", "john@email.com").Exists(),
+			expect:  `SELECT EXISTS(SELECT 1 FROM users WHERE email = $1)`,
+			args:    []interface{}{"john@email.com"},
+		},
+		{
+			name:    "exists_oracle",
+			dialect: Oracle,
+			stmt:    Select().Columns("1").From("users").Where("email = ?", "john@email.com").Exists(),
+			expect:  `SELECT CASE WHEN EXISTS(SELECT 1 FROM users WHERE email = :1) THEN 1 ELSE 0 END FROM DUAL`,
+			args:    []interface{}{"john@email.com"},
+		},
+		{
+			name:    "exists_sqlserver",
+			dialect: SQLServer,
+			stmt:    Select().Columns("1").From("users").Where("email = ?", "john@email.com").Exists(),
+			expect:  `SELECT CASE WHEN EXISTS(SELECT 1 FROM users WHERE email = @p1) THEN 1 ELSE 0 END`,
+			args:    []interface{}{"john@email.com"},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			q, args, err := tt.stmt.SQL(WithDialect(tt.dialect))
+			if err != nil {
+				t.Fatalf("error building statement: %s", err)
+			}
+
+			if tt.expect != q {
+				t.Fatalf("expected: %s, got: %s", tt.expect, q)
+			}
+
+			if !reflect.DeepEqual(tt.args, args) {
+				t.Fatalf("expected args: %#v, got: %#v", tt.args, args)
+			}
+		})
+	}
+
+	// the wrapped statement must not modify the original
+	stmt := page()
+	_, _ = stmt.Count().String()
+
+	q, err := stmt.String()
+	if err != nil {
+		t.Fatalf("error building statement: %s", err)
+	}
+
+	expect := `SELECT id,name FROM users WHERE role = 'admin' AND status IN ('active','pending') ORDER BY created_at DESC LIMIT 20 OFFSET 40`
+	if q != expect {
+		t.Fatalf("expected: %s, got: %s", expect, q)
+	}
+}